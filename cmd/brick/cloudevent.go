@@ -0,0 +1,82 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/atc0005/brick/internal/events"
+)
+
+// cloudEvent is a CloudEvents 1.0 event using the JSON event format. The data
+// attribute holds the JSON representation of an event Record.
+// https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/formats/json-format.md
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	Type            string          `json:"type"`
+	Source          string          `json:"source"`
+	ID              string          `json:"id"`
+	Time            string          `json:"time,omitempty"`
+	Subject         string          `json:"subject,omitempty"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// cloudEventType returns the CloudEvents event type for the provided Action.
+// The event types are part of the interface offered to webhook receivers and
+// are derived from the short name for each Action.
+func cloudEventType(action string) string {
+	return cloudEventTypePrefix + events.ActionName(action)
+}
+
+// createCloudEvent receives an event Record and the source attribute value
+// and generates a CloudEvent. The event ID is derived from the event Record
+// so that retried deliveries of the same notification can be recognized as
+// duplicates by the receiver.
+func createCloudEvent(record events.Record, source string) (cloudEvent, error) {
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return cloudEvent{}, err
+	}
+
+	sum := sha256.Sum256(data)
+
+	event := cloudEvent{
+		SpecVersion:     cloudEventSpecVersion,
+		Type:            cloudEventType(record.Action),
+		Source:          source,
+		ID:              hex.EncodeToString(sum[:16]),
+		Subject:         record.Alert.Username,
+		DataContentType: cloudEventDataMediaType,
+		Data:            data,
+	}
+
+	// The arrival time is recorded in RFC 3339 format, but is checked before
+	// use as the time attribute is required to use this format.
+	if _, err := time.Parse(time.RFC3339, record.Alert.ArrivalTime); err == nil {
+		event.Time = record.Alert.ArrivalTime
+	} else {
+		log.Debugf("createCloudEvent: omitting time attribute; invalid arrival time %q: %v", record.Alert.ArrivalTime, err)
+	}
+
+	return event, nil
+}
@@ -16,6 +16,8 @@
 
 package main
 
+import "time"
+
 // MB represents 1 Megabyte
 const MB int64 = 1048576
 
@@ -24,3 +26,42 @@ const (
 	recordActionStep3of3      string = "[step 3 of 3]"
 	recordActionUnknownRecord string = "[UNKNOWN]"
 )
+
+// Block Kit limits applied to Slack messages.
+// https://api.slack.com/reference/block-kit/blocks
+const (
+	slackMaxBlocks        int = 50
+	slackHeaderMaxLength  int = 150
+	slackTextMaxLength    int = 3000
+	slackFieldMaxLength   int = 2000
+	slackSectionMaxFields int = 10
+)
+
+// Settings used when submitting CloudEvents to generic webhook targets.
+// https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/spec.md
+const (
+	webhookServicePrefix    string = "webhook"
+	webhookSignatureHeader  string = "X-Brick-Signature-256"
+	cloudEventSpecVersion   string = "1.0"
+	cloudEventContentType   string = "application/cloudevents+json; charset=UTF-8"
+	cloudEventTypePrefix    string = "com.github.atc0005.brick."
+	cloudEventDataMediaType string = "application/json"
+)
+
+// deadLettersRequestTimeout is the timeout applied to requests submitted to
+// the running instance of this application by the deadletters subcommand.
+const deadLettersRequestTimeout time.Duration = 30 * time.Second
+
+// notifyMgrName is the name of the notifications manager as recorded for use
+// by readiness checks. Each notification service started by the
+// notifications manager is recorded using the service name followed by
+// "Notifier" (e.g., "teamsNotifier").
+const notifyMgrName string = "NotifyMgr"
+
+// digestTimeFormat is the layout used to display the period covered by
+// digest reports.
+const digestTimeFormat string = "2006-01-02 15:04 MST"
+
+// emailAlertAttachmentName is the filename used when attaching the alert
+// payload to email notifications.
+const emailAlertAttachmentName string = "alert.json"
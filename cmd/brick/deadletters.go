@@ -0,0 +1,166 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/atc0005/brick/internal/config"
+	"github.com/atc0005/brick/internal/outbox"
+)
+
+// deadLetter is the representation of a dead-letter notification returned
+// by the dead-letter API endpoints.
+type deadLetter struct {
+	ID          string    `json:"id"`
+	Service     string    `json:"service"`
+	Attempts    int       `json:"attempts"`
+	Queued      time.Time `json:"queued"`
+	LastAttempt time.Time `json:"last_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+	Action      string    `json:"action"`
+	Username    string    `json:"username"`
+	UserIP      string    `json:"user_ip"`
+	AlertName   string    `json:"alert_name"`
+	SearchID    string    `json:"search_id"`
+}
+
+// newDeadLetters converts the provided outbox items for use in API
+// responses. The alert request headers are intentionally excluded as they
+// may include credentials supplied by the alert sender.
+func newDeadLetters(items []outbox.Item) []deadLetter {
+
+	deadLetters := make([]deadLetter, 0, len(items))
+	for _, item := range items {
+		deadLetters = append(deadLetters, deadLetter{
+			ID:          item.ID,
+			Service:     item.Service,
+			Attempts:    item.Attempts,
+			Queued:      item.Queued,
+			LastAttempt: item.LastAttempt,
+			LastError:   item.LastError,
+			Action:      item.Record.Action,
+			Username:    item.Record.Alert.Username,
+			UserIP:      item.Record.Alert.UserIP,
+			AlertName:   item.Record.Alert.AlertName,
+			SearchID:    item.Record.Alert.SearchID,
+		})
+	}
+
+	return deadLetters
+}
+
+// runDeadLettersCommand submits the deadletters subcommand action requested
+// by the user to the running instance of this application and writes the
+// dead-letter notifications returned to the provided io.Writer.
+func runDeadLettersCommand(appConfig *config.Config, w io.Writer) error {
+
+	// The running instance only accepts these requests from the local
+	// system, so a loopback address is used if the instance listens on all
+	// addresses.
+	host := appConfig.LocalIPAddress()
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	baseURL := "http://" + net.JoinHostPort(host, strconv.Itoa(appConfig.LocalTCPPort()))
+
+	client := &http.Client{Timeout: deadLettersRequestTimeout}
+
+	var resp *http.Response
+	var err error
+	switch appConfig.DeadLettersCommand() {
+	case config.DeadLettersCommandRetry:
+		query := url.Values{}
+		for _, id := range appConfig.DeadLettersRetryIDs() {
+			query.Add("id", id)
+		}
+		reqURL := baseURL + apiV1DeadLettersRetryEndpointPattern
+		if len(query) > 0 {
+			reqURL += "?" + query.Encode()
+		}
+		resp, err = client.Post(reqURL, "", nil)
+
+	default:
+		resp, err = client.Get(baseURL + apiV1DeadLettersEndpointPattern)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1*MB))
+		return fmt.Errorf(
+			"unexpected response from %s: %s: %s",
+			resp.Request.URL,
+			resp.Status,
+			strings.TrimSpace(string(body)),
+		)
+	}
+
+	var deadLetters []deadLetter
+	if err := json.NewDecoder(resp.Body).Decode(&deadLetters); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", resp.Request.URL, err)
+	}
+
+	if appConfig.DeadLettersCommand() == config.DeadLettersCommandRetry {
+		fmt.Fprintf(w, "%d notifications queued for delivery again\n\n", len(deadLetters))
+	}
+
+	return writeDeadLettersTable(w, deadLetters)
+}
+
+// writeDeadLettersTable writes the provided dead-letter notifications as a
+// table of aligned columns.
+func writeDeadLettersTable(w io.Writer, deadLetters []deadLetter) error {
+
+	if len(deadLetters) == 0 {
+		_, err := fmt.Fprintln(w, "No dead-letter notifications")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ID\tSERVICE\tATTEMPTS\tQUEUED\tACTION\tUSERNAME\tLAST ERROR")
+	for _, dl := range deadLetters {
+		fmt.Fprintf(
+			tw,
+			"%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			dl.ID,
+			dl.Service,
+			dl.Attempts,
+			dl.Queued.Format(time.RFC3339),
+			dl.Action,
+			dl.Username,
+			// errors may span multiple lines
+			strings.Join(strings.Fields(dl.LastError), " "),
+		)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,424 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/atc0005/brick/internal/caller"
+	"github.com/atc0005/brick/internal/config"
+	"github.com/atc0005/brick/internal/digest"
+	"github.com/atc0005/brick/internal/notify"
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+	"github.com/atc0005/go-teams-notify/v2/messagecard"
+)
+
+// digestNotifier is implemented by notifiers which are able to send digest
+// reports.
+type digestNotifier interface {
+	Name() string
+	SendDigest(ctx context.Context, report digest.Report) error
+}
+
+// digestPeriodStart returns the beginning of the provided digest report
+// period (daily or weekly) ending at the provided time.
+func digestPeriodStart(period string, end time.Time) time.Time {
+	switch period {
+	case config.DigestScheduleWeekly:
+		return end.AddDate(0, 0, -7)
+	default:
+		return end.AddDate(0, 0, -1)
+	}
+}
+
+// buildDigest creates a digest report for the provided period (daily or
+// weekly) ending at the provided time using the JSON events log and disabled
+// users file specified by the provided configuration.
+func buildDigest(cfg *config.Config, period string, end time.Time) (digest.Report, error) {
+
+	if cfg.JSONEventsLogFile() == "" {
+		return digest.Report{}, errors.New("digest reports require the JSON events log to be enabled")
+	}
+
+	return digest.BuildFromFile(
+		cfg.JSONEventsLogFile(),
+		cfg.DisabledUsersFile(),
+		digestPeriodStart(period, end),
+		end,
+		cfg.DigestTop(),
+	)
+}
+
+// getDigestTitle generates the title for the provided digest report using
+// the provided prefix.
+func getDigestTitle(prefix string, report digest.Report) string {
+	return fmt.Sprintf(
+		"%sDigest report for %s to %s",
+		prefix,
+		report.Start.Format(digestTimeFormat),
+		report.End.Format(digestTimeFormat),
+	)
+}
+
+// digestSummaryPair is a single summary value included in a digest report.
+type digestSummaryPair struct {
+	key   string
+	value int
+}
+
+// getDigestSummary returns the summary values included in the provided
+// digest report in display order.
+func getDigestSummary(report digest.Report) []digestSummaryPair {
+	return []digestSummaryPair{
+		{"Disable requests received", report.Reports},
+		{"Usernames disabled", report.Disabled},
+		{"Usernames already disabled", report.AlreadyDisabled},
+		{"Usernames ignored", report.Ignored},
+		{"Stale alerts skipped", report.Skipped},
+		{"User sessions terminated", report.SessionsTerminated},
+		{"Failures", report.Failures},
+		{"Usernames currently disabled", report.CurrentlyDisabled},
+	}
+}
+
+// createDigestText generates a plain text (lightly formatted) representation
+// of the provided digest report suitable for email notifications or display
+// on the console.
+func createDigestText(report digest.Report) string {
+
+	var text strings.Builder
+
+	text.WriteString("**Summary**\n\n")
+	for _, pair := range getDigestSummary(report) {
+		fmt.Fprintf(&text, "* %s: %d\n", pair.key, pair.value)
+	}
+
+	writeCounts := func(title string, counts []digest.Count) {
+		fmt.Fprintf(&text, "\n\n**%s**\n\n", title)
+		if len(counts) == 0 {
+			text.WriteString("* None\n")
+			return
+		}
+		for i, count := range counts {
+			fmt.Fprintf(&text, "%d. %s: %d\n", i+1, count.Name, count.Count)
+		}
+	}
+
+	writeCounts("Top Alert Names", report.TopAlertNames)
+	writeCounts("Repeat Offenders", report.RepeatOffenders)
+
+	return text.String()
+}
+
+// createDigestTeamsMessage receives a digest report and generates a
+// MessageCard which is used to generate a Microsoft Teams message.
+func createDigestTeamsMessage(report digest.Report) *messagecard.MessageCard {
+
+	myFuncName := caller.GetFuncName()
+
+	msgCard := messagecard.NewMessageCard()
+	msgCard.Title = getDigestTitle(config.MyAppName+": ", report)
+	msgCard.Text = fmt.Sprintf("%d disable requests received", report.Reports)
+
+	addSection := func(section *messagecard.Section) {
+		if err := msgCard.AddSection(section); err != nil {
+			errMsg := fmt.Sprintf("Error returned from attempt to add %q section: %v", section.Title, err)
+			log.Errorf("%s: %v", myFuncName, errMsg)
+			msgCard.Text = msgCard.Text + "\n\n" + messagecard.TryToFormatAsCodeSnippet(errMsg)
+		}
+	}
+
+	summarySection := messagecard.NewSection()
+	summarySection.Title = "## Summary"
+	summarySection.StartGroup = true
+	for _, pair := range getDigestSummary(report) {
+		addFactPair(msgCard, summarySection, pair.key, strconv.Itoa(pair.value))
+	}
+	addSection(summarySection)
+
+	countsSection := func(title string, counts []digest.Count) {
+		section := messagecard.NewSection()
+		section.Title = "## " + title
+		section.StartGroup = true
+		if len(counts) == 0 {
+			section.Text = "None"
+		}
+		for _, count := range counts {
+			addFactPair(msgCard, section, count.Name, strconv.Itoa(count.Count))
+		}
+		addSection(section)
+	}
+
+	countsSection("Top Alert Names", report.TopAlertNames)
+	countsSection("Repeat Offenders", report.RepeatOffenders)
+
+	trailerSection := messagecard.NewSection()
+	trailerSection.StartGroup = true
+	trailerSection.Text = messagecard.ConvertEOLToBreak(config.MessageTrailer(config.BrandingMarkdownFormat))
+	addSection(trailerSection)
+
+	return msgCard
+}
+
+// createDigestAdaptiveCard receives a digest report and generates an
+// Adaptive Card message which is used to generate a Microsoft Teams message.
+func createDigestAdaptiveCard(report digest.Report) (*adaptivecard.Message, error) {
+
+	card := adaptivecard.NewCard()
+	card.SetFullWidth()
+
+	card.Body = append(card.Body,
+		adaptivecard.NewTitleTextBlock(getDigestTitle(config.MyAppName+": ", report), true),
+		adaptivecard.NewTextBlock(fmt.Sprintf("%d disable requests received", report.Reports), true),
+	)
+
+	summaryFacts := make([]adaptivecard.Fact, 0, 8)
+	for _, pair := range getDigestSummary(report) {
+		summaryFacts = append(summaryFacts, adaptiveCardFact(pair.key, strconv.Itoa(pair.value)))
+	}
+	addAdaptiveCardSection(&card, "Summary", "", summaryFacts)
+
+	countsSection := func(title string, counts []digest.Count) {
+		if len(counts) == 0 {
+			addAdaptiveCardSection(&card, title, "None", nil)
+			return
+		}
+		facts := make([]adaptivecard.Fact, 0, len(counts))
+		for _, count := range counts {
+			facts = append(facts, adaptiveCardFact(count.Name, strconv.Itoa(count.Count)))
+		}
+		addAdaptiveCardSection(&card, title, "", facts)
+	}
+
+	countsSection("Top Alert Names", report.TopAlertNames)
+	countsSection("Repeat Offenders", report.RepeatOffenders)
+
+	trailer := adaptivecard.NewTextBlock(adaptivecard.ConvertEOL(config.MessageTrailer(config.BrandingMarkdownFormat)), true)
+	trailer.Size = adaptivecard.SizeSmall
+	trailer.Separator = true
+	card.Body = append(card.Body, trailer)
+
+	return adaptivecard.NewMessageFromCard(card)
+}
+
+// createDigestHTML generates an HTML representation of the provided digest
+// report suitable for the HTML part of email notifications.
+func createDigestHTML(report digest.Report) string {
+
+	var body strings.Builder
+
+	title := html.EscapeString(getDigestTitle(config.MyAppName+": ", report))
+
+	fmt.Fprintf(&body, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n", title)
+	body.WriteString("<body style=\"font-family: sans-serif;\">\n")
+	fmt.Fprintf(&body, "<h2>%s</h2>\n", title)
+
+	body.WriteString("<h3>Summary</h3>\n<table border=\"1\" cellpadding=\"4\" cellspacing=\"0\">\n")
+	for _, pair := range getDigestSummary(report) {
+		fmt.Fprintf(&body, "<tr><th align=\"left\">%s</th><td>%d</td></tr>\n", html.EscapeString(pair.key), pair.value)
+	}
+	body.WriteString("</table>\n")
+
+	writeCounts := func(title string, counts []digest.Count) {
+		fmt.Fprintf(&body, "<h3>%s</h3>\n", html.EscapeString(title))
+		if len(counts) == 0 {
+			body.WriteString("<p>None</p>\n")
+			return
+		}
+		body.WriteString("<ol>\n")
+		for _, count := range counts {
+			fmt.Fprintf(&body, "<li>%s: %d</li>\n", html.EscapeString(count.Name), count.Count)
+		}
+		body.WriteString("</ol>\n")
+	}
+
+	writeCounts("Top Alert Names", report.TopAlertNames)
+	writeCounts("Repeat Offenders", report.RepeatOffenders)
+
+	fmt.Fprintf(&body, "<p><small>%s</small></p>\n", config.MessageTrailer(config.BrandingHTMLFormat))
+	body.WriteString("</body>\n</html>\n")
+
+	return body.String()
+}
+
+// createDigestEmailMessage receives a digest report and a collection of
+// settings used to generate a formatted email message with plain text and
+// HTML parts.
+func createDigestEmailMessage(report digest.Report, emailCfg emailConfig) (string, error) {
+	content := emailContent{
+		subject: getDigestTitle(config.MyAppName+": ", report),
+		textBody: fmt.Sprintf(
+			"%s\n\n%s\n",
+			createDigestText(report),
+			config.MessageTrailer(config.BrandingTextileFormat),
+		),
+		htmlBody: createDigestHTML(report),
+	}
+
+	return buildEmailMessage(emailCfg, content, time.Now())
+}
+
+// sendDigestWithRetries submits the provided digest report using the
+// provided notifier, retrying failed attempts as specified by the provided
+// settings.
+func sendDigestWithRetries(ctx context.Context, notifier digestNotifier, settings notify.Settings, report digest.Report) error {
+
+	attempts := settings.Retries + 1
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+
+		attemptCtx, cancel := context.WithTimeout(ctx, settings.Timeout)
+		err = notifier.SendDigest(attemptCtx, report)
+		cancel()
+
+		if err == nil {
+			log.Infof("%s: digest report sent after %d of %d attempts", notifier.Name(), attempt, attempts)
+			return nil
+		}
+
+		log.Warnf("%s: digest report attempt %d of %d failed: %v", notifier.Name(), attempt, attempts, err)
+
+		if attempt == attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(settings.RetryDelay):
+		}
+	}
+
+	return fmt.Errorf("failed to send %s digest report after %d attempts: %w", notifier.Name(), attempts, err)
+}
+
+// sendDigest submits the provided digest report using each enabled notifier
+// which supports digest reports (e.g., email and Microsoft Teams). Each
+// notifier is created using the provided configuration and templates.
+func sendDigest(ctx context.Context, cfg *config.Config, templates *notificationTemplates, report digest.Report) error {
+
+	var sent int
+	var errs []error
+
+	for _, registration := range notifierRegistry {
+
+		if !registration.enabled(cfg) {
+			continue
+		}
+
+		for _, configured := range registration.newNotifiers(cfg, templates) {
+
+			notifier, ok := configured.notifier.(digestNotifier)
+			if !ok {
+				continue
+			}
+
+			sent++
+			if err := sendDigestWithRetries(ctx, notifier, configured.settings, report); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if sent == 0 {
+		return errors.New("no notifiers which support digest reports (email, Microsoft Teams) are enabled")
+	}
+
+	return errors.Join(errs...)
+}
+
+// DigestMgr builds and sends digest reports on the schedule specified by the
+// provided configuration until the provided context is cancelled. Each
+// digest report covers the period (day or week) ending at the scheduled
+// time. Notifiers are created using the provided templates.
+func DigestMgr(ctx context.Context, cfg *config.Config, templates *notificationTemplates) {
+
+	log.Debug("DigestMgr: Running")
+
+	// validated as part of config initialization
+	hour, minute, _ := digest.ParseTimeOfDay(cfg.DigestTime())
+	weekday, _ := digest.ParseWeekday(cfg.DigestWeekday())
+	period := cfg.DigestSchedule()
+	weekly := period == config.DigestScheduleWeekly
+
+	for {
+
+		next := digest.NextRun(time.Now(), hour, minute, weekday, weekly)
+		log.Infof("DigestMgr: Next %s digest report scheduled for %s", period, next.Format(digestTimeFormat))
+
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Debugf("DigestMgr: Received Done signal: %v, shutting down ...", ctx.Err())
+			return
+
+		case <-timer.C:
+		}
+
+		report, err := buildDigest(cfg, period, next)
+		if err != nil {
+			log.Errorf("DigestMgr: Failed to build %s digest report: %v", period, err)
+			continue
+		}
+
+		if err := sendDigest(ctx, cfg, templates, report); err != nil {
+			log.Errorf("DigestMgr: Failed to send %s digest report: %v", period, err)
+		}
+	}
+}
+
+// runDigestCommand builds the digest report requested using the digest
+// subcommand and either writes it to the provided io.Writer or sends it
+// using the configured notifiers.
+func runDigestCommand(appConfig *config.Config, w io.Writer) error {
+
+	period := appConfig.DigestCommandPeriod()
+
+	report, err := buildDigest(appConfig, period, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if appConfig.DigestCommandSend() {
+		templates, err := newNotificationTemplates(appConfig)
+		if err != nil {
+			return fmt.Errorf("failed to initialize notification templates: %w", err)
+		}
+
+		return sendDigest(context.Background(), appConfig, templates, report)
+	}
+
+	_, err = fmt.Fprintf(
+		w,
+		"%s\n\n%s",
+		getDigestTitle("", report),
+		createDigestText(report),
+	)
+
+	return err
+}
@@ -18,7 +18,9 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -28,9 +30,16 @@ import (
 
 	"github.com/apex/log"
 
+	"github.com/atc0005/brick/internal/config"
+	"github.com/atc0005/brick/internal/dedup"
 	"github.com/atc0005/brick/internal/events"
 	"github.com/atc0005/brick/internal/files"
+	"github.com/atc0005/brick/internal/health"
+	"github.com/atc0005/brick/internal/journal"
+	"github.com/atc0005/brick/internal/metrics"
+	"github.com/atc0005/brick/internal/outbox"
 	"github.com/atc0005/brick/internal/textutils"
+	"github.com/atc0005/brick/internal/usernames"
 )
 
 // API endpoint patterns supported by this application
@@ -41,6 +50,11 @@ const (
 	apiV1DisableUserEndpointPattern             string = "/api/v1/users/disable"
 	apiV1ViewDisabledUsersEndpointPattern       string = "/api/v1/users/list"
 	apiV1ViewDisabledUsersStatusEndpointPattern string = "/api/v1/users/status"
+	metricsEndpointPattern                      string = "/metrics"
+	healthzEndpointPattern                      string = "/healthz"
+	readyzEndpointPattern                       string = "/readyz"
+	apiV1DeadLettersEndpointPattern             string = "/api/v1/notifications/deadletters"
+	apiV1DeadLettersRetryEndpointPattern        string = "/api/v1/notifications/deadletters/retry"
 )
 
 // frontPageHandler is our catch-all handler. By default it tells clients to
@@ -94,7 +108,14 @@ func disableUserHandler(
 	reportedUserEventsLog *files.ReportedUserEventsLog,
 	disabledUsers *files.DisabledUsers,
 	ignoredSources files.IgnoredSources,
+	usernameNormalizer *usernames.Normalizer,
 	notifyWorkQueue chan<- events.Record,
+	recordSinks []events.RecordSink,
+	disableWorkers *workerPool,
+	disableJournal *journal.Journal,
+	dedupCache *dedup.Cache,
+	staleAlertMaxAge time.Duration,
+	staleAlertAction string,
 	terminateSessions bool,
 	ezproxyActiveFilePath string,
 	ezproxySessionsSearchDelay int,
@@ -107,6 +128,9 @@ func disableUserHandler(
 		// fmt.Fprintf(mw, "disableUserHandler endpoint hit\n")
 		log.Debug("disableUserHandler handler hit")
 
+		received := time.Now()
+		metrics.PayloadsReceived.Inc()
+
 		// If a list of trusted IPs is not provided by the sysadmin, the
 		// default behavior is to accept payloads from all IP Addresses. This
 		// behavior/logic is balanced by configuring the trusted IP Addresses
@@ -121,6 +145,7 @@ func disableUserHandler(
 					remoteIPAddr,
 					ipHostSplitErr,
 				)
+				metrics.PayloadsRejected.Inc(metrics.RejectReasonInvalidRemoteAddress)
 				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 				fmt.Fprint(w, errMsg)
 				return
@@ -140,6 +165,7 @@ func disableUserHandler(
 					"remote_ip_addr":          remoteIPAddr,
 					"trusted_payload_senders": strings.Join(trustedPayloadSenders, ", "),
 				}).Error(errMsg)
+				metrics.PayloadsRejected.Inc(metrics.RejectReasonUntrustedSender)
 				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
 				fmt.Fprint(w, errMsg)
 				return
@@ -164,6 +190,7 @@ func disableUserHandler(
 					"Please see the README for examples and then try again.",
 				http.MethodPost,
 			)
+			metrics.PayloadsRejected.Inc(metrics.RejectReasonMethodNotAllowed)
 			// TODO: Can apex/log hook into this and handle output?
 			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 			fmt.Fprint(w, errorMsg)
@@ -179,6 +206,7 @@ func disableUserHandler(
 		// allow later access to r.Body for JSON-decoding purposes
 		requestBody, requestBodyReadErr := io.ReadAll(r.Body)
 		if requestBodyReadErr != nil {
+			metrics.PayloadsRejected.Inc(metrics.RejectReasonReadError)
 			http.Error(w, requestBodyReadErr.Error(), http.StatusBadRequest)
 			return
 		}
@@ -193,6 +221,7 @@ func disableUserHandler(
 		var payloadV2 events.SplunkAlertPayloadV2
 		if err := json.NewDecoder(r.Body).Decode(&payloadV2); err != nil {
 			log.Errorf("Error decoding r.Body into payloadV2:\n%v\n\n", err)
+			metrics.PayloadsRejected.Inc(metrics.RejectReasonDecodeError)
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
@@ -202,6 +231,7 @@ func disableUserHandler(
 		// all fields that we've included in SplunkAlertPayloadV2
 		if err := events.ValidatePayload(payloadV2); err != nil {
 			log.Error(err.Error())
+			metrics.PayloadsRejected.Inc(metrics.RejectReasonValidationFailed)
 
 			// Inform Splunk that we received an invalid payload
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -209,6 +239,192 @@ func disableUserHandler(
 
 		}
 
+		// if we made it this far, the payload checks out and we should be
+		// able to safely retrieve values that we need. We will also append
+		// payload sender metadata values such as headers, endpoint path, etc
+		// so that we can report those later. Username normalization is
+		// applied once here so that all later checks and file entries use
+		// the same value.
+		alert, err := newAlertEvent(payloadV2, usernameNormalizer, alertRequest{
+			received: received,
+			senderIP: events.GetIP(r),
+			path:     r.URL.Path,
+			method:   r.Method,
+			headers:  r.Header,
+			body:     requestBody,
+		})
+		if err != nil {
+			log.Error(err.Error())
+			metrics.PayloadsRejected.Inc(metrics.RejectReasonEmptyUsername)
+
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		username := alert.Username
+
+		if username != payloadV2.Result.Username {
+			log.Debugf(
+				"disableUserHandler: reported username %q normalized to %q",
+				payloadV2.Result.Username,
+				username,
+			)
+		}
+
+		// Stale (or future-dated) alerts are handled as the sysadmin
+		// specified once the time of the reported user activity is known.
+		var rejectReason string
+		if alert.EventTime != "" {
+			if alert.Latency > 0 {
+				metrics.AlertLatency.Observe(alert.Latency.Seconds())
+			}
+
+			if staleAlertMaxAge > 0 {
+				switch {
+				case alert.Latency > staleAlertMaxAge:
+					alert.StaleReason = staleAlertReason(alert.Latency, staleAlertMaxAge)
+					rejectReason = metrics.RejectReasonStaleAlert
+
+				case alert.Latency < -config.AlertMaxClockSkew:
+					alert.StaleReason = fmt.Sprintf(
+						"reported activity is dated %v after the alert was received",
+						-alert.Latency,
+					)
+					rejectReason = metrics.RejectReasonFutureAlert
+				}
+			}
+		}
+
+		if alert.StaleReason != "" && staleAlertAction == config.StaleAlertActionReject {
+			log.WithFields(log.Fields{
+				"username":   username,
+				"search_id":  payloadV2.Sid,
+				"event_time": alert.EventTime,
+			}).Warnf(
+				"disableUserHandler: rejecting report for user %q from IP %q; %s",
+				username,
+				payloadV2.Result.SourceIP,
+				alert.StaleReason,
+			)
+			metrics.PayloadsRejected.Inc(rejectReason)
+
+			http.Error(
+				w,
+				"payload rejected; "+alert.StaleReason,
+				http.StatusUnprocessableEntity,
+			)
+			return
+		}
+
+		// Repeated deliveries of the same alert (e.g., Splunk retries or
+		// multiple search heads) are confirmed, but not processed again.
+		if dedupCache != nil {
+			duplicate, firstSeen, err := dedupCache.Add(payloadV2.Sid, username)
+			if err != nil {
+				log.Errorf("disableUserHandler: failed to record alert in duplicate alert cache: %v", err)
+			}
+
+			if duplicate {
+				log.WithFields(log.Fields{
+					"username":   username,
+					"search_id":  payloadV2.Sid,
+					"first_seen": firstSeen.Format(time.RFC3339),
+				}).Infof(
+					"disableUserHandler: ignoring duplicate report for user %q from IP %q",
+					username,
+					payloadV2.Result.SourceIP,
+				)
+				metrics.PayloadsDuplicate.Inc()
+
+				w.WriteHeader(http.StatusAlreadyReported)
+				if _, err := io.WriteString(w, "OK: Duplicate payload ignored\n"); err != nil {
+					log.Error("disableUserHandler: Failed to send duplicate status response to payload sender")
+				}
+				return
+			}
+		}
+
+		// forgetAlert is used if the alert is rejected after it was
+		// remembered so that the sender's retry is not treated as a duplicate
+		forgetAlert := func() {
+			if dedupCache == nil {
+				return
+			}
+			if err := dedupCache.Remove(payloadV2.Sid, username); err != nil {
+				log.Errorf("disableUserHandler: failed to remove rejected alert from duplicate alert cache: %v", err)
+			}
+		}
+
+		// Record the alert before confirming receipt so that it is processed
+		// again at startup if this application stops before processing is
+		// complete.
+		var journalID string
+		if disableJournal != nil {
+			id, err := disableJournal.Append(alert)
+			if err != nil {
+				log.Errorf("disableUserHandler: failed to record alert in journal: %v", err)
+				metrics.PayloadsRejected.Inc(metrics.RejectReasonJournalError)
+				forgetAlert()
+
+				http.Error(
+					w,
+					"failed to record payload; retry later",
+					http.StatusInternalServerError,
+				)
+				return
+			}
+			journalID = id
+		}
+
+		// All return values from subfunction calls are dropped into the
+		// notifyWorkQueue channel; nothing is returned here for further
+		// processing.
+		//
+		// NOTE: Because this is processed by the worker pool, the client
+		// (e.g., monitoring system) gets a near-immediate response back and
+		// the connection is closed. If the intake queue is full the payload
+		// is rejected so that the sender can retry it later.
+		accepted := disableWorkers.Submit(disableJob(
+			alert,
+			journalID,
+			received,
+			disabledUsers,
+			reportedUserEventsLog,
+			ignoredSources,
+			notifyWorkQueue,
+			recordSinks,
+			disableJournal,
+			terminateSessions,
+			ezproxyActiveFilePath,
+			ezproxySessionsSearchDelay,
+			ezproxySessionSearchRetries,
+			ezproxyExecutable,
+		))
+
+		if !accepted {
+			log.Errorf(
+				"disableUserHandler: disable request queue is full; rejecting report for user %q from IP %q",
+				alert.Username,
+				alert.UserIP,
+			)
+			metrics.PayloadsRejected.Inc(metrics.RejectReasonQueueFull)
+			forgetAlert()
+
+			// the sender is told to retry, so this alert is not replayed
+			if disableJournal != nil {
+				if err := disableJournal.Complete(journalID); err != nil {
+					log.Errorf("disableUserHandler: failed to remove rejected alert from journal: %v", err)
+				}
+			}
+
+			w.Header().Set("Retry-After", "30")
+			http.Error(
+				w,
+				"disable request queue is full; retry later",
+				http.StatusServiceUnavailable,
+			)
+			return
+		}
+
 		// Explicitly confirm that the payload was received so that the sender
 		// can go ahead and disconnect. This prevents holding up the sender
 		// while this application performs further (unrelated from the
@@ -229,44 +445,344 @@ func disableUserHandler(
 			log.Warn("disableUserHandler: Not flushing http.ResponseWriter may cause a noticeable delay between requests")
 		}
 
-		// if we made it this far, the payload checks out and we should be
-		// able to safely retrieve values that we need. We will also append
-		// payload sender metadata values such as headers, endpoint path, etc
-		// so that we can report those later.
-		alert := events.SplunkAlertEvent{
-			Username:        payloadV2.Result.Username,
-			UserIP:          payloadV2.Result.SourceIP,
-			PayloadSenderIP: events.GetIP(r),
-			ArrivalTime:     time.Now().Format(time.RFC3339),
-			LocalTime:       time.Now().Format("2006-01-02 15:04:05"),
-			AlertName:       payloadV2.SearchName,
-			SearchID:        payloadV2.Sid,
-			EndpointPath:    r.URL.Path,
-			HTTPMethod:      r.Method,
-			Headers:         r.Header,
-		}
+	}
+}
 
-		// All return values from subfunction calls are dropped into the
-		// notifyWorkQueue channel; nothing is returned here for further
-		// processing.
-		//
-		// NOTE: Because this is executed in a goroutine, the client (e.g.,
-		// monitoring system) gets a near-immediate response back and the
-		// connection is closed. There are probably other/better ways to
-		// achieve that specific result without using a goroutine, but the
-		// effect is worth noting for further exploration later.
-		go files.ProcessDisableEvent(
+// alertRequest holds the details of the request which delivered an alert
+// payload.
+type alertRequest struct {
+	received time.Time
+	senderIP string
+	path     string
+	method   string
+	headers  http.Header
+	body     []byte
+}
+
+// newAlertEvent creates an alert from the decoded payload and the details of
+// the request which delivered it. The reported username is normalized using
+// the provided normalizer; an error is returned if the username is empty
+// after normalization. The time of the reported user activity (and the
+// latency of the alert) is left empty if it cannot be determined.
+func newAlertEvent(
+	payloadV2 events.SplunkAlertPayloadV2,
+	usernameNormalizer *usernames.Normalizer,
+	req alertRequest,
+) (events.SplunkAlertEvent, error) {
+
+	username := usernameNormalizer.Normalize(payloadV2.Result.Username)
+	if username == "" {
+		return events.SplunkAlertEvent{}, fmt.Errorf(
+			"payload validation failed; username %q is empty after normalization",
+			payloadV2.Result.Username,
+		)
+	}
+
+	// Determine when the reported user activity occurred so that the delay
+	// before the alert arrived can be reported.
+	var eventTime string
+	var latency time.Duration
+	activityTime, err := events.EventTime(payloadV2)
+	switch {
+	case err != nil:
+		log.Warnf(
+			"unable to determine time of reported activity for user %q: %v",
+			username,
+			err,
+		)
+
+	default:
+		eventTime = activityTime.Format(time.RFC3339)
+		latency = req.received.Sub(activityTime).Round(time.Second)
+	}
+
+	// Retain the payload as received (e.g., for email attachments) if it
+	// holds a single JSON value; trailing content is ignored by the decoder,
+	// but would prevent the payload from being recorded.
+	var payload json.RawMessage
+	if json.Valid(req.body) {
+		payload = req.body
+	}
+
+	return events.SplunkAlertEvent{
+		Username:         username,
+		ReportedUsername: payloadV2.Result.Username,
+		UserIP:           payloadV2.Result.SourceIP,
+		PayloadSenderIP:  req.senderIP,
+		ArrivalTime:      req.received.Format(time.RFC3339),
+		EventTime:        eventTime,
+		Latency:          latency,
+		LocalTime:        req.received.Format("2006-01-02 15:04:05"),
+		AlertName:        payloadV2.SearchName,
+		SearchID:         payloadV2.Sid,
+		EndpointPath:     req.path,
+		HTTPMethod:       req.method,
+		Headers:          req.headers,
+		Payload:          payload,
+	}, nil
+}
+
+// staleAlertReason returns the reason recorded for an alert whose reported
+// activity occurred the provided latency before the alert was received. The
+// maximum age is noted if specified.
+func staleAlertReason(latency time.Duration, maxAge time.Duration) string {
+	reason := fmt.Sprintf(
+		"reported activity occurred %v before the alert was received",
+		latency,
+	)
+	if maxAge > 0 {
+		reason += fmt.Sprintf(" (maximum age %v)", maxAge)
+	}
+
+	return reason
+}
+
+// disableJob returns a worker pool job which processes the alert. If a
+// journal is provided, the alert is marked complete in the journal once
+// processing finishes. Alerts whose processing was cancelled are left in the
+// journal so that they are processed again at next startup.
+func disableJob(
+	alert events.SplunkAlertEvent,
+	journalID string,
+	received time.Time,
+	disabledUsers *files.DisabledUsers,
+	reportedUserEventsLog *files.ReportedUserEventsLog,
+	ignoredSources files.IgnoredSources,
+	notifyWorkQueue chan<- events.Record,
+	recordSinks []events.RecordSink,
+	disableJournal *journal.Journal,
+	terminateSessions bool,
+	ezproxyActiveFilePath string,
+	ezproxySessionsSearchDelay int,
+	ezproxySessionSearchRetries int,
+	ezproxyExecutable string,
+) func(context.Context) {
+
+	return func(ctx context.Context) {
+		files.ProcessDisableEvent(
+			ctx,
 			alert,
 			disabledUsers,
 			reportedUserEventsLog,
 			ignoredSources,
 			notifyWorkQueue,
+			recordSinks,
 			terminateSessions,
 			ezproxyActiveFilePath,
 			ezproxySessionsSearchDelay,
 			ezproxySessionSearchRetries,
 			ezproxyExecutable,
 		)
+		metrics.PayloadProcessingDuration.Observe(time.Since(received).Seconds())
+
+		if disableJournal == nil {
+			return
+		}
+
+		if ctx.Err() != nil {
+			log.Warnf(
+				"disableJob: processing of report for user %q was cancelled; leaving in journal for next startup",
+				alert.Username,
+			)
+			return
+		}
+
+		if err := disableJournal.Complete(journalID); err != nil {
+			log.Errorf("disableJob: failed to mark journal entry complete: %v", err)
+		}
+	}
+}
+
+// metricsHandler writes all metrics held by the provided registry in the
+// Prometheus text exposition format.
+func metricsHandler(registry *metrics.Registry) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		log.Debug("metricsHandler endpoint hit")
+
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+
+		w.Header().Set("Content-Type", metrics.ContentType)
+		if _, err := registry.WriteTo(w); err != nil {
+			log.Errorf("metricsHandler: failed to write metrics: %v", err)
+		}
+	}
+}
+
+// healthzHandler reports that this application is running and able to
+// respond to requests. This is intended for use as a liveness probe.
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+
+	log.Debug("healthzHandler endpoint hit")
+
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+
+	writeHealthReport(w, health.Report{
+		Status: health.StatusOK,
+		Checks: []health.Result{},
+	})
+}
+
+// readyzHandler performs the provided checks and reports the result of each.
+// A 503 Service Unavailable status code is returned if any required check
+// fails. This is intended for use as a readiness probe.
+func readyzHandler(checks []health.Check) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		log.Debug("readyzHandler endpoint hit")
+
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+
+		report := health.Run(checks...)
+		for _, result := range report.Checks {
+			if result.Status != health.StatusOK {
+				log.Debugf(
+					"readyzHandler: check %s status %s: %s",
+					result.Name,
+					result.Status,
+					result.Error,
+				)
+			}
+		}
+
+		writeHealthReport(w, report)
+	}
+}
+
+// writeHealthReport writes the provided report as JSON, using a 503 Service
+// Unavailable status code if the report indicates a failure.
+func writeHealthReport(w http.ResponseWriter, report health.Report) {
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if !report.OK() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
 
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Errorf("failed to write health report: %v", err)
 	}
 }
+
+// deadLettersHandler lists the notifications which could not be delivered.
+// Requests are only accepted from the local system as notifications include
+// details of reported users.
+func deadLettersHandler(notifyOutbox *outbox.Outbox) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		log.Debug("deadLettersHandler endpoint hit")
+
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+
+		if !localRequest(w, r) {
+			return
+		}
+
+		writeDeadLetters(w, notifyOutbox.DeadLetters())
+	}
+}
+
+// deadLettersRetryHandler queues the dead-letter notifications specified by
+// one or more id query parameters (or all dead-letter notifications if none
+// are specified) for delivery again. Requests are only accepted from the
+// local system.
+func deadLettersRetryHandler(notifyOutbox *outbox.Outbox) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		log.Debug("deadLettersRetryHandler endpoint hit")
+
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, r, http.MethodPost)
+			return
+		}
+
+		if !localRequest(w, r) {
+			return
+		}
+
+		retried, err := notifyOutbox.Retry(r.URL.Query()["id"]...)
+		if err != nil {
+			log.Errorf("deadLettersRetryHandler: failed to retry dead-letter notifications: %v", err)
+
+			status := http.StatusInternalServerError
+			if errors.Is(err, outbox.ErrNotFound) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		log.Infof(
+			"deadLettersRetryHandler: %d dead-letter notifications queued for delivery again",
+			len(retried),
+		)
+
+		writeDeadLetters(w, retried)
+	}
+}
+
+// writeDeadLetters writes the provided outbox items as JSON.
+func writeDeadLetters(w http.ResponseWriter, items []outbox.Item) {
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := json.NewEncoder(w).Encode(newDeadLetters(items)); err != nil {
+		log.Errorf("failed to write dead-letter notifications: %v", err)
+	}
+}
+
+// localRequest reports whether the request was received from the local
+// system. A 403 Forbidden status code is returned to the client if not.
+func localRequest(w http.ResponseWriter, r *http.Request) bool {
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err == nil {
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			return true
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"url_path":    r.URL.Path,
+		"http_method": r.Method,
+		"remote_addr": r.RemoteAddr,
+	}).Warn("rejecting request from remote system on local-only endpoint")
+
+	http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+
+	return false
+}
+
+// methodNotAllowed responds to a request using an unsupported HTTP method.
+func methodNotAllowed(w http.ResponseWriter, r *http.Request, allowedMethod string) {
+
+	log.WithFields(log.Fields{
+		"url_path":    r.URL.Path,
+		"http_method": r.Method,
+	}).Debugf("non-%s request received on %s-only endpoint", allowedMethod, allowedMethod)
+	errorMsg := fmt.Sprintf(
+		"Sorry, this endpoint only accepts %s requests. "+
+			"Please see the README for examples and then try again.",
+		allowedMethod,
+	)
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	fmt.Fprint(w, errorMsg)
+}
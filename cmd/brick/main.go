@@ -22,14 +22,24 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/atc0005/brick/internal/config"
+	"github.com/atc0005/brick/internal/dedup"
 	"github.com/atc0005/brick/internal/events"
 	"github.com/atc0005/brick/internal/files"
+	"github.com/atc0005/brick/internal/health"
+	"github.com/atc0005/brick/internal/journal"
+	"github.com/atc0005/brick/internal/metrics"
+	"github.com/atc0005/brick/internal/outbox"
+	"github.com/atc0005/brick/internal/siem"
+	"github.com/atc0005/brick/internal/syslog"
+	"github.com/atc0005/brick/internal/usernames"
 	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
 
 	"github.com/apex/log"
@@ -62,6 +72,38 @@ func main() {
 		appExitCode = 1
 		return
 	}
+	// The digest subcommand builds the requested digest report from the
+	// files used by the running instance of this application without
+	// starting another instance.
+	if appConfig.DigestCommand() {
+		if err := runDigestCommand(appConfig, os.Stdout); err != nil {
+			log.Errorf("Failed to generate digest report: %s", err)
+			appExitCode = 1
+		}
+		return
+	}
+
+	// The preview subcommand renders notifications for a sample alert
+	// payload without sending them or starting another instance.
+	if appConfig.PreviewCommand() {
+		if err := runPreviewCommand(appConfig, os.Stdout); err != nil {
+			log.Errorf("Failed to preview notifications: %s", err)
+			appExitCode = 1
+		}
+		return
+	}
+
+	// The deadletters subcommand is handled by the running instance of this
+	// application; the request is submitted and the response displayed
+	// without starting another instance.
+	if appConfig.DeadLettersCommand() != "" {
+		if err := runDeadLettersCommand(appConfig, os.Stdout); err != nil {
+			log.Errorf("Failed to %s dead-letter notifications: %s", appConfig.DeadLettersCommand(), err)
+			appExitCode = 1
+		}
+		return
+	}
+
 	log.Debug("Initializing application")
 
 	log.Debugf("AppConfig: %+v", appConfig)
@@ -116,18 +158,47 @@ func main() {
 		syscall.SIGTERM, // full restart
 	)
 
+	// SIGHUP is handled separately; it is used to request that log files be
+	// reopened (e.g., after rotation by logrotate) and does not trigger a
+	// shutdown.
+	reopen := make(chan os.Signal, 1)
+	signal.Notify(reopen, syscall.SIGHUP)
+
 	// Where events will be sent for processing. We use a buffered channel in
 	// an effort to reduce the delay for client requests.
 	notifyWorkQueue := make(chan events.Record, config.NotifyMgrQueueDepth)
 
-	// Create "notifications manager" function as persistent goroutine to
-	// process incoming notification requests.
-	go NotifyMgr(ctx, appConfig, notifyWorkQueue, notifyDone)
+	// Record the running state of the notifications manager and the
+	// notifiers that it starts for use by readiness checks.
+	tracker := health.NewTracker()
+	tracker.Expect(notifyMgrName)
+
+	// NotifyMgr uses a separate context so that it continues to send
+	// notifications for disable requests still being processed after a
+	// shutdown is requested. This context is cancelled once the disable
+	// request workers have finished.
+	notifyCtx, notifyCancel := context.WithCancel(context.Background())
+	defer notifyCancel()
+
+	// Process disable requests using a fixed number of workers. Accepted
+	// requests are drained during shutdown before NotifyMgr is stopped.
+	disableWorkers := newWorkerPool(
+		"disableWorkers",
+		config.DisableWorkerPoolSize,
+		config.DisableWorkerQueueDepth,
+	)
+	metrics.DisableQueueDepth.SetFunc("disableWorkQueue", func() float64 {
+		return float64(disableWorkers.Len())
+	})
 
 	// Setup "listener" to cancel the parent context when Signal.Notify()
 	// indicates that SIGINT has been received
 	go shutdownListener(ctx, quit, cancel)
 
+	// Setup "listener" to reopen log files when Signal.Notify() indicates
+	// that SIGHUP has been received
+	go reopenListener(ctx, reopen, appConfig.ReopenLogFiles)
+
 	// Setup "listener" to shutdown the running http server when
 	// the parent context has been cancelled
 	go gracefulShutdown(ctx, httpServer, config.HTTPServerShutdownTimeout, httpDone)
@@ -135,22 +206,240 @@ func main() {
 	// build objects representing output files, the templates used to generate
 	// those files and any files containing "ignored" users/IPs using our
 	// newly constructed config object.
-	reportedUserEventsLog := files.NewReportedUserEventsLog(
+	reportedUserEventsLog, err := files.NewReportedUserEventsLog(
 		appConfig.ReportedUsersLogFile(),
 		appConfig.ReportedUsersLogFilePermissions(),
+		appConfig.ReportedUsersLogFileOwner(),
+		appConfig.ReportedUsersLogFileGroup(),
+		files.ReportedUserEventsTemplateFiles{
+			Report:        appConfig.ReportedUsersReportTemplateFile(),
+			DisableFirst:  appConfig.ReportedUsersDisabledTemplateFile(),
+			DisableRepeat: appConfig.ReportedUsersAlreadyDisabledTemplateFile(),
+			Ignored:       appConfig.ReportedUsersIgnoredTemplateFile(),
+			Terminated:    appConfig.ReportedUsersTerminatedTemplateFile(),
+		},
 	)
+	if err != nil {
+		log.Errorf("Failed to initialize reported user events log templates: %s", err)
+		appExitCode = 1
+		return
+	}
 
-	disabledUsers := files.NewDisabledUsers(
+	disabledUsers, err := files.NewDisabledUsers(
 		appConfig.DisabledUsersFile(),
 		appConfig.DisabledUsersFileEntrySuffix(),
 		appConfig.DisabledUsersFilePermissions(),
+		appConfig.DisabledUsersFileOwner(),
+		appConfig.DisabledUsersFileGroup(),
+		appConfig.DisabledUsersTemplateFile(),
 	)
+	if err != nil {
+		log.Errorf("Failed to initialize disabled users file template: %s", err)
+		appExitCode = 1
+		return
+	}
+
+	// build the templates used to generate notifications; user-provided
+	// template files are validated now instead of when the first
+	// notification is sent.
+	notifyTemplates, err := newNotificationTemplates(appConfig)
+	if err != nil {
+		log.Errorf("Failed to initialize notification templates: %s", err)
+		appExitCode = 1
+		return
+	}
+
+	// Optional outputs which receive a copy of every event record.
+	var recordSinks []events.RecordSink
+	if appConfig.JSONEventsLogFile() != "" {
+		log.Infof("Recording events in JSON format to %q", appConfig.JSONEventsLogFile())
+		recordSinks = append(recordSinks, files.NewJSONEventsLog(
+			appConfig.JSONEventsLogFile(),
+			appConfig.JSONEventsLogFilePermissions(),
+		))
+	}
+
+	if appConfig.SIEMFile() != "" {
+		log.Infof(
+			"Recording events in %s format to %q",
+			appConfig.SIEMFormat(),
+			appConfig.SIEMFile(),
+		)
+		recordSinks = append(recordSinks, siem.NewFileSink(
+			siem.Format(appConfig.SIEMFormat()),
+			config.Version(),
+			appConfig.SIEMFile(),
+			appConfig.SIEMFilePermissions(),
+		))
+	}
+
+	if appConfig.SIEMSyslogAddress() != "" {
+
+		// validated as part of config initialization
+		facility, _ := syslog.ParseFacility(appConfig.SIEMSyslogFacility())
+
+		siemSyslogWriter, err := syslog.Dial(
+			appConfig.SIEMSyslogNetwork(),
+			appConfig.SIEMSyslogAddress(),
+			facility,
+			siem.SyslogTag,
+		)
+		if err != nil {
+			log.Errorf("Failed to initialize SIEM syslog output: %s", err)
+			appExitCode = 1
+			return
+		}
+		defer func() {
+			if err := siemSyslogWriter.Close(); err != nil {
+				log.Errorf("Failed to close SIEM syslog output: %s", err)
+			}
+		}()
+
+		log.Infof(
+			"Sending events in %s format to syslog daemon at %q using %s",
+			appConfig.SIEMFormat(),
+			appConfig.SIEMSyslogAddress(),
+			appConfig.SIEMSyslogNetwork(),
+		)
+		recordSinks = append(recordSinks, siem.NewSyslogSink(
+			siem.Format(appConfig.SIEMFormat()),
+			config.Version(),
+			siemSyslogWriter,
+		))
+	}
+
+	usernameNormalizer, err := usernames.NewNormalizer(
+		appConfig.UsernameLowercase(),
+		appConfig.UsernameStripRealm(),
+		appConfig.UsernameStripDomain(),
+		appConfig.UsernameAliasFile(),
+	)
+	if err != nil {
+		log.Errorf("Failed to initialize username normalization: %s", err)
+		appExitCode = 1
+		return
+	}
 
 	ignoredSources := files.NewIgnoredSources(
 		appConfig.IgnoredUsersFile(),
 		appConfig.IgnoredIPAddressesFile(),
 		appConfig.IgnoreLookupErrors(),
+		usernameNormalizer,
+	)
+
+	// Prepare output files and confirm that input files are usable before
+	// accepting requests; problems with these files would otherwise only
+	// surface once the first payload is processed.
+	preflightReport := health.Run(
+		preflightChecks(appConfig, disabledUsers, reportedUserEventsLog)...,
 	)
+	logPreflightReport(preflightReport)
+	if !preflightReport.OK() {
+		log.Error("Startup preflight checks failed")
+		appExitCode = 1
+		return
+	}
+	log.Info("Startup preflight checks passed")
+
+	// Open the journal of accepted disable requests, collecting any which
+	// were not finished when this application last stopped so that they can
+	// be processed again.
+	var disableJournal *journal.Journal
+	var unfinishedEntries []journal.Entry
+	if appConfig.JournalFile() != "" {
+		disableJournal, unfinishedEntries, err = journal.Open(
+			appConfig.JournalFile(),
+			appConfig.JournalFilePermissions(),
+		)
+		if err != nil {
+			log.Errorf("Failed to open journal: %s", err)
+			appExitCode = 1
+			return
+		}
+
+		defer func() {
+			if err := disableJournal.Close(); err != nil {
+				log.Errorf("Failed to close journal: %s", err)
+			}
+		}()
+
+		log.Infof("Recording accepted disable requests in journal %q", appConfig.JournalFile())
+	} else {
+		log.Warn("CAUTION: Journal disabled; disable requests accepted but not yet processed are lost if brick stops")
+	}
+
+	// Open the outbox used to hold notifications until they are delivered,
+	// including any left undelivered when this application last stopped.
+	notifyOutbox, err := outbox.Open(
+		appConfig.OutboxFile(),
+		appConfig.OutboxFilePermissions(),
+		appConfig.OutboxMaxAttempts(),
+		time.Duration(appConfig.OutboxRetryDelay())*time.Minute,
+	)
+	if err != nil {
+		log.Errorf("Failed to open notifications outbox: %s", err)
+		appExitCode = 1
+		return
+	}
+
+	defer func() {
+		if err := notifyOutbox.Close(); err != nil {
+			log.Errorf("Failed to close notifications outbox: %s", err)
+		}
+	}()
+
+	switch {
+	case appConfig.OutboxFile() != "":
+		log.Infof("Recording notifications in outbox %q until delivered", appConfig.OutboxFile())
+	default:
+		log.Warn("CAUTION: Outbox file disabled; notifications not yet delivered are lost if brick stops")
+	}
+
+	metrics.NotifyOutboxItems.SetFunc(outbox.StatusPending, func() float64 {
+		return float64(notifyOutbox.Len(outbox.StatusPending))
+	})
+	metrics.NotifyOutboxItems.SetFunc(outbox.StatusDead, func() float64 {
+		return float64(notifyOutbox.Len(outbox.StatusDead))
+	})
+
+	// Create "notifications manager" function as persistent goroutine to
+	// process incoming notification requests.
+	go NotifyMgr(notifyCtx, appConfig, notifyTemplates, notifyWorkQueue, notifyOutbox, notifyDone, tracker)
+
+	// Send scheduled digest reports until shutdown is requested.
+	switch {
+	case appConfig.DigestSchedule() != config.DigestScheduleOff:
+		go DigestMgr(ctx, appConfig, notifyTemplates)
+	default:
+		log.Debug("Scheduled digest reports disabled")
+	}
+
+	// Remember received alerts so that repeated deliveries are not
+	// processed again.
+	var dedupCache *dedup.Cache
+	if appConfig.DedupTTL() > 0 {
+		dedupCache, err = dedup.New(
+			time.Duration(appConfig.DedupTTL())*time.Minute,
+			appConfig.DedupFile(),
+			appConfig.DedupFilePermissions(),
+		)
+		if err != nil {
+			log.Errorf("Failed to initialize duplicate alert detection: %s", err)
+			appExitCode = 1
+			return
+		}
+
+		defer func() {
+			if err := dedupCache.Close(); err != nil {
+				log.Errorf("Failed to close duplicate alert cache: %s", err)
+			}
+		}()
+
+		log.Infof(
+			"Ignoring repeated deliveries of the same alert for %d minutes",
+			appConfig.DedupTTL(),
+		)
+	}
 
 	// log this to help troubleshoot why payloads are (or are not) filtered
 	switch {
@@ -160,10 +449,77 @@ func main() {
 		log.Warn("CAUTION: Restricting payload sender IP Addresses disabled")
 	}
 
+	// Checks performed for each readiness probe. Failed lookups against the
+	// ignore files are reported as warnings if the sysadmin opted to
+	// disregard lookup errors. Sessions are looked up even if termination is
+	// disabled, but a failed lookup only prevents termination.
+	readinessChecks := []health.Check{
+		{
+			Name: "disabled_users_file",
+			Run:  func() error { return health.FileWritable(appConfig.DisabledUsersFile()) },
+		},
+		{
+			Name: "reported_users_log_file",
+			Run:  func() error { return health.FileWritable(appConfig.ReportedUsersLogFile()) },
+		},
+		{
+			Name:     "ignored_users_file",
+			Optional: appConfig.IgnoreLookupErrors(),
+			Run:      func() error { return health.FileReadable(appConfig.IgnoredUsersFile()) },
+		},
+		{
+			Name:     "ignored_ip_addresses_file",
+			Optional: appConfig.IgnoreLookupErrors(),
+			Run:      func() error { return health.FileReadable(appConfig.IgnoredIPAddressesFile()) },
+		},
+		{
+			Name:     "ezproxy_active_file",
+			Optional: !appConfig.EZproxyTerminateSessions(),
+			Run:      func() error { return health.ActiveFile(appConfig.EZproxyActiveFilePath()) },
+		},
+	}
+
+	if appConfig.JournalFile() != "" {
+		readinessChecks = append(readinessChecks, health.Check{
+			Name: "journal_file",
+			Run:  func() error { return health.FileWritable(appConfig.JournalFile()) },
+		})
+	}
+
+	if appConfig.OutboxFile() != "" {
+		readinessChecks = append(readinessChecks, health.Check{
+			Name: "outbox_file",
+			Run:  func() error { return health.FileWritable(appConfig.OutboxFile()) },
+		})
+	}
+
+	if appConfig.DedupTTL() > 0 && appConfig.DedupFile() != "" {
+		readinessChecks = append(readinessChecks, health.Check{
+			Name: "dedup_file",
+			Run:  func() error { return health.FileWritable(appConfig.DedupFile()) },
+		})
+	}
+
+	if appConfig.EZproxyTerminateSessions() {
+		readinessChecks = append(readinessChecks, health.Check{
+			Name: "ezproxy_executable",
+			Run:  func() error { return health.Executable(appConfig.EZproxyExecutablePath()) },
+		})
+	}
+
+	readinessChecks = append(readinessChecks, health.Check{
+		Name: "notifiers",
+		Run:  tracker.Check,
+	})
+
 	// GET requests
 	mux.HandleFunc(frontpageEndpointPattern, frontPageHandler)
+	mux.HandleFunc(healthzEndpointPattern, healthzHandler)
+	mux.HandleFunc(readyzEndpointPattern, readyzHandler(readinessChecks))
 	mux.HandleFunc(apiV1ViewDisabledUsersEndpointPattern, viewDisabledUsersHandler)
 	mux.HandleFunc(apiV1ViewDisabledUsersStatusEndpointPattern, viewDisabledUserStatusHandler)
+	mux.HandleFunc(apiV1DeadLettersEndpointPattern, deadLettersHandler(notifyOutbox))
+	mux.HandleFunc(apiV1DeadLettersRetryEndpointPattern, deadLettersRetryHandler(notifyOutbox))
 
 	// POST request
 	mux.HandleFunc(
@@ -174,7 +530,14 @@ func main() {
 			reportedUserEventsLog,
 			disabledUsers,
 			ignoredSources,
+			usernameNormalizer,
 			notifyWorkQueue,
+			recordSinks,
+			disableWorkers,
+			disableJournal,
+			dedupCache,
+			time.Duration(appConfig.StaleAlertMaxAge())*time.Minute,
+			appConfig.StaleAlertAction(),
 			appConfig.EZproxyTerminateSessions(),
 			appConfig.EZproxyActiveFilePath(),
 			appConfig.EZproxySearchDelay(),
@@ -183,6 +546,114 @@ func main() {
 		),
 	)
 
+	// The metrics endpoint is exposed on the main listener unless a separate
+	// port is specified. The separate listener is bound here so that any
+	// problems (e.g., port already in use) are reported before the main
+	// listener is started.
+	metricsDone := make(chan struct{}, 1)
+	switch {
+	case !appConfig.MetricsEnabled():
+		close(metricsDone)
+
+	case appConfig.MetricsLocalTCPPort() == 0:
+		close(metricsDone)
+		mux.HandleFunc(metricsEndpointPattern, metricsHandler(metrics.Default))
+		log.Infof("Metrics are available on the main listener at %s", metricsEndpointPattern)
+
+	default:
+		metricsMux := http.NewServeMux()
+		metricsMux.HandleFunc(metricsEndpointPattern, metricsHandler(metrics.Default))
+
+		metricsServer := &http.Server{
+			ReadHeaderTimeout: config.HTTPServerReadHeaderTimeout,
+			ReadTimeout:       config.HTTPServerReadTimeout,
+			WriteTimeout:      config.HTTPServerWriteTimeout,
+			Handler:           metricsMux,
+			Addr: fmt.Sprintf(
+				"%s:%d",
+				appConfig.MetricsLocalIPAddress(),
+				appConfig.MetricsLocalTCPPort(),
+			),
+		}
+
+		metricsListener, err := net.Listen("tcp", metricsServer.Addr)
+		if err != nil {
+			log.Errorf("Failed to start metrics listener: %s", err)
+			appExitCode = 1
+			return
+		}
+
+		go func() {
+			if err := metricsServer.Serve(metricsListener); err != nil &&
+				!errors.Is(err, http.ErrServerClosed) {
+				log.Errorf("error occurred while running metrics httpServer: %v", err)
+			}
+		}()
+
+		// Shutdown the metrics listener alongside the main listener. This
+		// is handled separately from gracefulShutdown to avoid repeating
+		// the shutdown notice.
+		go func() {
+			<-ctx.Done()
+
+			ctxShutdown, cancelShutdown := context.WithTimeout(
+				context.Background(),
+				config.HTTPServerShutdownTimeout,
+			)
+			defer cancelShutdown()
+
+			if err := metricsServer.Shutdown(ctxShutdown); err != nil {
+				log.Errorf("could not gracefully shutdown the metrics server: %v", err)
+			}
+			close(metricsDone)
+		}()
+
+		log.Infof("Metrics are available on %s port %d at %s",
+			appConfig.MetricsLocalIPAddress(),
+			appConfig.MetricsLocalTCPPort(),
+			metricsEndpointPattern,
+		)
+	}
+
+	// Process disable requests which were accepted but not finished when
+	// this application last stopped. These are queued alongside new requests
+	// and wait for space in the queue instead of being rejected.
+	if len(unfinishedEntries) > 0 {
+		log.Warnf("Processing %d unfinished disable requests from journal", len(unfinishedEntries))
+
+		go func() {
+			for _, entry := range unfinishedEntries {
+				log.Infof(
+					"Processing unfinished report for user %q from IP %q accepted at %s",
+					entry.Alert.Username,
+					entry.Alert.UserIP,
+					entry.Time.Format(time.RFC3339),
+				)
+
+				queued := disableWorkers.SubmitWait(disableJob(
+					*entry.Alert,
+					entry.ID,
+					time.Now(),
+					disabledUsers,
+					reportedUserEventsLog,
+					ignoredSources,
+					notifyWorkQueue,
+					recordSinks,
+					disableJournal,
+					appConfig.EZproxyTerminateSessions(),
+					appConfig.EZproxyActiveFilePath(),
+					appConfig.EZproxySearchDelay(),
+					appConfig.EZproxySearchRetries(),
+					appConfig.EZproxyExecutablePath(),
+				))
+				if !queued {
+					log.Warn("Shutting down; remaining unfinished disable requests left in journal for next startup")
+					return
+				}
+			}
+		}()
+	}
+
 	// listen on specified port and IP Address, block until app is terminated
 	log.Infof("%s %s is listening on %s port %d",
 		config.MyAppName,
@@ -211,6 +682,21 @@ func main() {
 	<-httpDone
 	log.Debug("Received gracefulShutdown completion signal")
 
+	log.Debug("Waiting on metrics gracefulShutdown completion signal")
+	<-metricsDone
+	log.Debug("Received metrics gracefulShutdown completion signal")
+
+	// No new disable requests are accepted once the http server is shutdown;
+	// finish processing those already accepted so that each is recorded and
+	// notifications are sent before NotifyMgr is stopped.
+	log.Debug("Waiting on disable request workers to finish")
+	if !disableWorkers.Stop(config.DisableWorkerDrainTimeout) {
+		log.Error("Not all accepted disable requests finished processing")
+	}
+	log.Debug("Disable request workers finished")
+
+	notifyCancel()
+
 	log.Debug("Waiting on NotifyMgr completion signal")
 	<-notifyDone
 	log.Debug("Received NotifyMgr completion signal")
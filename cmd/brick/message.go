@@ -19,12 +19,15 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
+	htmltemplate "html/template"
+	"net"
 	"net/http"
 	"net/smtp"
+	"sort"
 	"strconv"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/apex/log"
@@ -33,7 +36,7 @@ import (
 	"github.com/atc0005/brick/internal/events"
 	"github.com/atc0005/go-ezproxy"
 
-	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
 	"github.com/atc0005/go-teams-notify/v2/messagecard"
 )
 
@@ -127,6 +130,22 @@ func getTerminationResultsList(sTermResults []ezproxy.TerminateUserSessionResult
 	return sessionResultsStringSets
 }
 
+// getStepSummary generates a brief, single line summary of a step included
+// in a consolidated event Record.
+func getStepSummary(step events.Step) string {
+
+	summary := step.Action
+	if step.Note != "" {
+		summary += ": " + step.Note
+	}
+
+	if step.Error != nil {
+		summary += " (Error: " + step.Error.Error() + ")"
+	}
+
+	return summary
+}
+
 // getMsgSummaryText evaluates the provided event Record and builds a message
 // suitable for display as the main or summary notification text. This message
 // is generated first from the Note field if available, second from the Error
@@ -190,6 +209,9 @@ func getMsgTitle(msgTitlePrefix string, record events.Record) string {
 	case events.ActionSuccessIgnoredIPAddress, events.ActionFailureIgnoredIPAddress:
 		msgCardTitle = msgTitlePrefix + recordActionStep2of3 + " " + record.Action
 
+	case events.ActionSkippedStaleAlert:
+		msgCardTitle = msgTitlePrefix + recordActionStep2of3 + " " + record.Action
+
 	case events.ActionSuccessTerminatedUserSession,
 		events.ActionFailureUserSessionLookupFailure,
 		events.ActionFailureTerminatedUserSession,
@@ -204,9 +226,10 @@ func getMsgTitle(msgTitlePrefix string, record events.Record) string {
 	return msgCardTitle
 }
 
-// createTeamsMessage receives an event Record and generates a MessageCard
-// which is used to generate a Microsoft Teams message.
-func createTeamsMessage(record events.Record) *messagecard.MessageCard {
+// createTeamsMessage receives an event Record along with the summary text
+// for the message and generates a MessageCard which is used to generate a
+// Microsoft Teams message.
+func createTeamsMessage(record events.Record, summaryText string) *messagecard.MessageCard {
 
 	myFuncName := caller.GetFuncName()
 
@@ -220,7 +243,7 @@ func createTeamsMessage(record events.Record) *messagecard.MessageCard {
 	msgCard.Title = getMsgTitle(msgCardTitlePrefix, record)
 
 	// msgCard.Text = record.Note
-	msgCard.Text = getMsgSummaryText(record)
+	msgCard.Text = summaryText
 
 	/*
 		Errors Section
@@ -243,6 +266,25 @@ func createTeamsMessage(record events.Record) *messagecard.MessageCard {
 		msgCard.Text = msgCard.Text + "\n\n" + messagecard.TryToFormatAsCodeSnippet(errMsg)
 	}
 
+	// If event Records were consolidated, create Processing Steps section
+	if len(record.Steps) > 0 {
+
+		processingStepsSection := messagecard.NewSection()
+		processingStepsSection.Title = "## Processing Steps"
+		processingStepsSection.StartGroup = true
+
+		for i, step := range record.Steps {
+			addFactPair(msgCard, processingStepsSection, fmt.Sprintf("Step %d", i+1), getStepSummary(step))
+		}
+
+		if err := msgCard.AddSection(processingStepsSection); err != nil {
+			errMsg := fmt.Sprintf("Error returned from attempt to add processingStepsSection: %v", err)
+			log.Errorf("%s: %v", myFuncName, errMsg)
+			msgCard.Text = msgCard.Text + "\n\n" + messagecard.TryToFormatAsCodeSnippet(errMsg)
+		}
+
+	}
+
 	// If Session Termination is enabled, create Termination Results section
 	if record.SessionTerminationResults != nil {
 
@@ -288,6 +330,10 @@ func createTeamsMessage(record events.Record) *messagecard.MessageCard {
 	alertRequestSummarySection.StartGroup = true
 
 	addFactPair(msgCard, alertRequestSummarySection, "Received at", record.Alert.LocalTime)
+	if record.Alert.EventTime != "" {
+		addFactPair(msgCard, alertRequestSummarySection, "Activity at", record.Alert.EventTime)
+		addFactPair(msgCard, alertRequestSummarySection, "Latency", record.Alert.Latency.String())
+	}
 	addFactPair(msgCard, alertRequestSummarySection, "Endpoint path", record.Alert.EndpointPath)
 	addFactPair(msgCard, alertRequestSummarySection, "HTTP Method", record.Alert.HTTPMethod)
 	addFactPair(msgCard, alertRequestSummarySection, "Alert Sender IP", record.Alert.PayloadSenderIP)
@@ -302,45 +348,49 @@ func createTeamsMessage(record events.Record) *messagecard.MessageCard {
 		Alert Request Headers Section
 	*/
 
-	alertRequestHeadersSection := messagecard.NewSection()
-	alertRequestHeadersSection.StartGroup = true
-	alertRequestHeadersSection.Title = "## Alert Request Headers"
+	// Headers are not available for notifications restored from the outbox
+	// as they are not stored; the section is omitted in that case.
+	if len(record.Alert.Headers) > 0 {
+		alertRequestHeadersSection := messagecard.NewSection()
+		alertRequestHeadersSection.StartGroup = true
+		alertRequestHeadersSection.Title = "## Alert Request Headers"
 
-	alertRequestHeadersSection.Text = fmt.Sprintf(
-		"%d alert request headers provided",
-		len(record.Alert.Headers),
-	)
+		alertRequestHeadersSection.Text = fmt.Sprintf(
+			"%d alert request headers provided",
+			len(record.Alert.Headers),
+		)
 
-	// process alert request headers
+		// process alert request headers
 
-	// Create a copy of the original so that we don't modify the original
-	// alert headers; other notifications (e.g., email) will need a fresh copy
-	// of those values so that any formatting applied here doesn't "spill
-	// over" to those notifications.
-	requestHeadersCopy := make(http.Header)
-	for key, value := range record.Alert.Headers {
-		requestHeadersCopy[key] = value
-	}
+		// Create a copy of the original so that we don't modify the original
+		// alert headers; other notifications (e.g., email) will need a fresh copy
+		// of those values so that any formatting applied here doesn't "spill
+		// over" to those notifications.
+		requestHeadersCopy := make(http.Header)
+		for key, value := range record.Alert.Headers {
+			requestHeadersCopy[key] = value
+		}
 
-	for header, values := range requestHeadersCopy {
+		for header, values := range requestHeadersCopy {
 
-		// As with the enclosing map, we create a copy here so that we don't
-		// modify the original (which is used also by email notifications).
-		headerValuesCopy := make([]string, len(values))
-		copy(headerValuesCopy, values)
+			// As with the enclosing map, we create a copy here so that we don't
+			// modify the original (which is used also by email notifications).
+			headerValuesCopy := make([]string, len(values))
+			copy(headerValuesCopy, values)
 
-		for index, value := range headerValuesCopy {
-			// update value with code snippet formatting, assign back using
-			// the available index value
-			headerValuesCopy[index] = messagecard.TryToFormatAsCodeSnippet(value)
+			for index, value := range headerValuesCopy {
+				// update value with code snippet formatting, assign back using
+				// the available index value
+				headerValuesCopy[index] = messagecard.TryToFormatAsCodeSnippet(value)
+			}
+			addFactPair(msgCard, alertRequestHeadersSection, header, headerValuesCopy...)
 		}
-		addFactPair(msgCard, alertRequestHeadersSection, header, headerValuesCopy...)
-	}
 
-	if err := msgCard.AddSection(alertRequestHeadersSection); err != nil {
-		errMsg := fmt.Sprintf("Error returned from attempt to add alertRequestHeadersSection: %v", err)
-		log.Errorf("%s: %v", myFuncName, errMsg)
-		msgCard.Text = msgCard.Text + "\n\n" + messagecard.TryToFormatAsCodeSnippet(errMsg)
+		if err := msgCard.AddSection(alertRequestHeadersSection); err != nil {
+			errMsg := fmt.Sprintf("Error returned from attempt to add alertRequestHeadersSection: %v", err)
+			log.Errorf("%s: %v", myFuncName, errMsg)
+			msgCard.Text = msgCard.Text + "\n\n" + messagecard.TryToFormatAsCodeSnippet(errMsg)
+		}
 	}
 
 	/*
@@ -359,189 +409,489 @@ func createTeamsMessage(record events.Record) *messagecard.MessageCard {
 	return msgCard
 }
 
-// sendTeamsMessage wraps and orchestrates an external library function call
-// to send Microsoft Teams messages. This includes honoring the provided
-// schedule in order to comply with remote API rate limits.
-func sendTeamsMessage(
-	ctx context.Context,
-	webhookURL string,
-	msgCard *messagecard.MessageCard,
-	schedule time.Time,
-	retries int,
-	retriesDelay int,
-) NotifyResult {
+// adaptiveCardFact creates an Adaptive Card Fact from the provided key and
+// one or many values related to the key. Adaptive Cards require a non-empty
+// value for each Fact, so a placeholder is used if no value is available.
+func adaptiveCardFact(key string, values ...string) adaptivecard.Fact {
+
+	value := strings.Join(values, ", ")
+	if strings.TrimSpace(value) == "" {
+		value = "N/A"
+	}
+
+	return adaptivecard.Fact{
+		Title: key,
+		Value: value,
+	}
+}
+
+// addAdaptiveCardSection adds a section to the provided Adaptive Card using
+// the provided title, text and facts; the text and facts are optional. Each
+// section is placed in a separate Container to emulate the grouping of
+// MessageCard sections. If an error occurs, the error is added to the end of
+// the Card to help make this failure more prominent.
+func addAdaptiveCardSection(card *adaptivecard.Card, title string, text string, facts []adaptivecard.Fact) {
+
+	container := adaptivecard.NewContainer()
+	container.Separator = true
+	container.Spacing = adaptivecard.SpacingLarge
+
+	heading := adaptivecard.NewTextBlock(title, true)
+	heading.Size = adaptivecard.SizeMedium
+	heading.Weight = adaptivecard.WeightBolder
+	container.Items = append(container.Items, heading)
+
+	if text != "" {
+		container.Items = append(container.Items, adaptivecard.NewTextBlock(text, true))
+	}
+
+	if len(facts) > 0 {
+		factSet := adaptivecard.NewFactSet()
+		factSet.Facts = facts
+		container.Items = append(container.Items, adaptivecard.Element(factSet))
+	}
+
+	if err := card.AddContainer(false, container); err != nil {
+		errMsg := fmt.Sprintf("Error returned from attempt to add %q section: %v", title, err)
+		log.Errorf("%s: %v", caller.GetFuncName(), errMsg)
+
+		errBlock := adaptivecard.NewTextBlock(errMsg, true)
+		errBlock.Color = adaptivecard.ColorAttention
+		card.Body = append(card.Body, errBlock)
+	}
+}
+
+// createTeamsAdaptiveCard receives an event Record along with the summary
+// text for the message and generates an Adaptive Card message which is used
+// to generate a Microsoft Teams message. This
+// format is required by Power Automate Workflows webhook URLs and provides
+// the same content as the MessageCard generated by createTeamsMessage.
+func createTeamsAdaptiveCard(record events.Record, summaryText string) (*adaptivecard.Message, error) {
 
 	myFuncName := caller.GetFuncName()
 
-	// Note: We already do validation elsewhere, and the library call does
-	// even more validation, but we can handle this obvious empty argument
-	// problem directly
-	if webhookURL == "" {
-		return NotifyResult{
-			Err: fmt.Errorf(
-				"%s: webhookURL not defined, skipping message submission to Microsoft Teams channel",
-				myFuncName,
-			),
-			Success: false,
+	log.Debugf("%s: alert received: %#v", myFuncName, record)
+
+	card := adaptivecard.NewCard()
+	card.SetFullWidth()
+
+	msgCardTitlePrefix := config.MyAppName + ": "
+
+	card.Body = append(card.Body,
+		adaptivecard.NewTitleTextBlock(getMsgTitle(msgCardTitlePrefix, record), true),
+		adaptivecard.NewTextBlock(summaryText, true),
+	)
+
+	/*
+		Errors Section
+	*/
+
+	switch {
+	case record.Error != nil:
+		addAdaptiveCardSection(&card, "Disable User Request Errors", "",
+			[]adaptivecard.Fact{adaptiveCardFact("Error", record.Error.Error())})
+	case record.Error == nil:
+		addAdaptiveCardSection(&card, "Disable User Request Errors", "None", nil)
+	}
+
+	// If event Records were consolidated, create Processing Steps section
+	if len(record.Steps) > 0 {
+		stepFacts := make([]adaptivecard.Fact, 0, len(record.Steps))
+		for i, step := range record.Steps {
+			stepFacts = append(stepFacts, adaptiveCardFact(fmt.Sprintf("Step %d", i+1), getStepSummary(step)))
 		}
+
+		addAdaptiveCardSection(&card, "Processing Steps", "", stepFacts)
+	}
+
+	// If Session Termination is enabled, create Termination Results section
+	if record.SessionTerminationResults != nil {
+		addAdaptiveCardSection(
+			&card,
+			"Session Termination Results",
+			getTerminationResultsList(record.SessionTerminationResults),
+			nil,
+		)
 	}
 
-	log.Debugf("%s: Time now is %v", myFuncName, time.Now().Format("15:04:05"))
-	log.Debugf("%s: Notification scheduled for: %v", myFuncName, schedule.Format("15:04:05"))
+	/*
+		Disable User Request Details Section - Core of alert details
+	*/
 
-	// Set delay timer to meet received notification schedule. This helps
-	// ensure that we delay the appropriate amount of time before we make our
-	// first attempt at sending a message to Microsoft Teams.
-	notificationDelay := time.Until(schedule)
+	addAdaptiveCardSection(&card, "Disable User Request Details", "", []adaptivecard.Fact{
+		adaptiveCardFact("Username", record.Alert.Username),
+		adaptiveCardFact("User IP", record.Alert.UserIP),
+		adaptiveCardFact("Alert/Search Name", record.Alert.AlertName),
+		adaptiveCardFact("Alert/Search ID", record.Alert.SearchID),
+	})
 
-	notificationDelayTimer := time.NewTimer(notificationDelay)
-	defer notificationDelayTimer.Stop()
-	log.Debugf("%s: notificationDelayTimer created at %v with duration %v",
-		myFuncName,
-		time.Now().Format("15:04:05"),
-		notificationDelay,
-	)
+	/*
+		Alert Request Summary Section - General client request details
+	*/
 
-	log.Debugf(
-		"%s: Waiting for either context or notificationDelayTimer to expire before sending notification",
-		myFuncName,
+	summaryFacts := []adaptivecard.Fact{
+		adaptiveCardFact("Received at", record.Alert.LocalTime),
+	}
+	if record.Alert.EventTime != "" {
+		summaryFacts = append(summaryFacts,
+			adaptiveCardFact("Activity at", record.Alert.EventTime),
+			adaptiveCardFact("Latency", record.Alert.Latency.String()),
+		)
+	}
+	summaryFacts = append(summaryFacts,
+		adaptiveCardFact("Endpoint path", record.Alert.EndpointPath),
+		adaptiveCardFact("HTTP Method", record.Alert.HTTPMethod),
+		adaptiveCardFact("Alert Sender IP", record.Alert.PayloadSenderIP),
 	)
 
-	select {
-	case <-ctx.Done():
-		ctxErr := ctx.Err()
-		msg := NotifyResult{
-			Val: fmt.Sprintf("%s: Received Done signal at %v: %v, shutting down",
-				myFuncName,
-				time.Now().Format("15:04:05"),
-				ctxErr.Error(),
-			),
-			Success: false,
+	addAdaptiveCardSection(&card, "Alert Request Summary", "", summaryFacts)
+
+	/*
+		Alert Request Headers Section
+	*/
+
+	// Headers are not available for notifications restored from the outbox
+	// as they are not stored; the section is omitted in that case.
+	if len(record.Alert.Headers) > 0 {
+		// Sort the header names so that the order is stable between messages.
+		headerNames := make([]string, 0, len(record.Alert.Headers))
+		for header := range record.Alert.Headers {
+			headerNames = append(headerNames, header)
 		}
-		log.Debug(msg.Val)
-		return msg
+		sort.Strings(headerNames)
 
-	// Delay between message submission attempts; this will *always*
-	// delay, regardless of whether the attempt is the first one or not
-	case <-notificationDelayTimer.C:
+		headerFacts := make([]adaptivecard.Fact, 0, len(headerNames))
+		for _, header := range headerNames {
+			headerFacts = append(headerFacts, adaptiveCardFact(header, record.Alert.Headers[header]...))
+		}
 
-		log.Debugf("%s: Waited %v before notification attempt at %v",
-			myFuncName,
-			notificationDelay,
-			time.Now().Format("15:04:05"),
+		addAdaptiveCardSection(
+			&card,
+			"Alert Request Headers",
+			fmt.Sprintf("%d alert request headers provided", len(record.Alert.Headers)),
+			headerFacts,
 		)
+	}
 
-		ctxExpires, ctxExpired := ctx.Deadline()
-		if ctxExpired {
-			log.Debugf(
-				"%s: WaitTimeout context expires at: %v",
-				myFuncName,
-				ctxExpires.Format("15:04:05"),
-			)
+	/*
+		Adaptive Card Branding/Trailer Section
+	*/
+
+	trailer := adaptivecard.NewTextBlock(adaptivecard.ConvertEOL(config.MessageTrailer(config.BrandingMarkdownFormat)), true)
+	trailer.Size = adaptivecard.SizeSmall
+	trailer.Separator = true
+	card.Body = append(card.Body, trailer)
+
+	return adaptivecard.NewMessageFromCard(card)
+}
+
+// slackMessage is the payload submitted to a Slack incoming webhook. The
+// Text field is used as the fallback for notifications where blocks cannot
+// be displayed.
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// slackBlock is a Block Kit layout block.
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+// slackText is a Block Kit text composition object.
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// slackEscape escapes the characters which Slack uses for control sequences
+// in mrkdwn text.
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// slackTruncate limits the provided text to the specified number of
+// characters, indicating with a trailing ellipsis that text was removed.
+func slackTruncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	return string(runes[:limit-1]) + "…"
+}
+
+// slackField formats a key and one or many values related to the key as a
+// mrkdwn field. As with Teams messages, values are formatted as code in an
+// effort to keep Slack from parsing special characters as formatting
+// characters.
+func slackField(key string, values ...string) slackText {
+
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		switch {
+		case value == "", strings.Contains(value, "`"):
+			formatted = append(formatted, slackEscape(value))
+		default:
+			formatted = append(formatted, "`"+slackEscape(value)+"`")
 		}
+	}
 
-		// check to see if context has expired during our delay
-		if ctx.Err() != nil {
-			msg := NotifyResult{
-				Val: fmt.Sprintf(
-					"%s: context expired or cancelled at %v: %v, attempting to abort message submission",
-					myFuncName,
-					time.Now().Format("15:04:05"),
-					ctx.Err().Error(),
-				),
-				Success: false,
-			}
+	return slackText{
+		Type: "mrkdwn",
+		Text: slackTruncate(
+			"*"+slackEscape(key)+"*\n"+strings.Join(formatted, ", "),
+			slackFieldMaxLength,
+		),
+	}
+}
 
-			log.Debug(msg.Val)
+// addSlackSection adds a divider followed by a section with the provided
+// title, optional text and fields to the Slack message. Fields are split
+// across as many sections as needed to respect Block Kit limits. Sections
+// which do not fit within the Block Kit limit on the number of blocks in a
+// message are omitted.
+func addSlackSection(msg *slackMessage, title string, text string, fields []slackText) {
+
+	// leave room for the branding trailer
+	available := slackMaxBlocks - 1 - len(msg.Blocks)
+	if available < 2 {
+		log.Warnf("Slack message block limit reached, omitting %q section", title)
+		return
+	}
+
+	sectionText := "*" + slackEscape(title) + "*"
+	if text != "" {
+		sectionText += "\n" + text
+	}
+
+	msg.Blocks = append(msg.Blocks, slackBlock{Type: "divider"})
+	available--
+
+	section := slackBlock{
+		Type: "section",
+		Text: &slackText{
+			Type: "mrkdwn",
+			Text: slackTruncate(sectionText, slackTextMaxLength),
+		},
+	}
 
-			return msg
+	for len(fields) > 0 && available > 0 {
+		count := len(fields)
+		if count > slackSectionMaxFields {
+			count = slackSectionMaxFields
 		}
 
-		// Create Microsoft Teams client
-		mstClient := goteamsnotify.NewTeamsClient()
+		section.Fields = fields[:count]
+		fields = fields[count:]
 
-		// Submit message card using Microsoft Teams client, retry submission
-		// if needed up to specified number of retry attempts.
-		if err := mstClient.SendWithRetry(ctx, webhookURL, msgCard, retries, retriesDelay); err != nil {
-			errMsg := NotifyResult{
-				Err: fmt.Errorf(
-					"%s: ERROR: Failed to submit message to Microsoft Teams at %v: %v",
-					myFuncName,
-					time.Now().Format("15:04:05"),
-					err,
-				),
-				Success: false,
-			}
-			log.Error(errMsg.Err.Error())
-			return errMsg
+		msg.Blocks = append(msg.Blocks, section)
+		available--
+
+		section = slackBlock{Type: "section"}
+	}
+
+	switch {
+	case section.Text != nil:
+		// no fields provided; add the section as-is
+		msg.Blocks = append(msg.Blocks, section)
+	case len(fields) > 0:
+		log.Warnf("Slack message block limit reached, omitting %d fields from %q section", len(fields), title)
+	}
+}
+
+// createSlackMessage receives an event Record along with the summary text
+// for the message and generates a Block Kit message which is used to
+// generate a Slack message.
+func createSlackMessage(record events.Record, summaryText string) slackMessage {
+
+	myFuncName := caller.GetFuncName()
+
+	log.Debugf("%s: alert received: %#v", myFuncName, record)
+
+	msgTitle := getMsgTitle(config.MyAppName+": ", record)
+
+	msg := slackMessage{
+		Text: slackTruncate(slackEscape(msgTitle), slackTextMaxLength),
+		Blocks: []slackBlock{
+			{
+				Type: "header",
+				Text: &slackText{
+					Type: "plain_text",
+					Text: slackTruncate(msgTitle, slackHeaderMaxLength),
+				},
+			},
+			{
+				Type: "section",
+				Text: &slackText{
+					Type: "mrkdwn",
+					Text: slackTruncate(slackEscape(summaryText), slackTextMaxLength),
+				},
+			},
+		},
+	}
+
+	/*
+		Errors Section
+	*/
+
+	switch {
+	case record.Error != nil:
+		addSlackSection(&msg, "Disable User Request Errors", "", []slackText{
+			slackField("Error", record.Error.Error()),
+		})
+	default:
+		addSlackSection(&msg, "Disable User Request Errors", "None", nil)
+	}
+
+	// If event Records were consolidated, create Processing Steps section
+	if len(record.Steps) > 0 {
+
+		fields := make([]slackText, 0, len(record.Steps))
+		for i, step := range record.Steps {
+			fields = append(fields, slackField(fmt.Sprintf("Step %d", i+1), getStepSummary(step)))
 		}
 
-		successMsg := NotifyResult{
-			Val: fmt.Sprintf(
-				"%s: Message successfully sent to Microsoft Teams at %v",
-				myFuncName,
-				time.Now().Format("15:04:05"),
-			),
-			Success: true,
+		addSlackSection(&msg, "Processing Steps", "", fields)
+	}
+
+	// If Session Termination is enabled, create Termination Results section
+	if record.SessionTerminationResults != nil {
+
+		fields := make([]slackText, 0, len(record.SessionTerminationResults))
+		for _, result := range record.SessionTerminationResults {
+
+			// guard against (nil) lack of error in results slice entry
+			errStr := "None"
+			if result.Error != nil {
+				errStr = result.Error.Error()
+			}
+
+			fields = append(fields, slackField(
+				"Session "+result.SessionID,
+				"IP: "+result.IPAddress,
+				"ExitCode: "+strconv.Itoa(result.ExitCode),
+				"StdOut: "+result.StdOut,
+				"StdErr: "+result.StdErr,
+				"Error: "+errStr,
+			))
 		}
 
-		// Note success for potential troubleshooting
-		log.Debug(successMsg.Val)
+		addSlackSection(&msg, "Session Termination Results", "", fields)
+	}
+
+	/*
+		Disable User Request Details Section - Core of alert details
+	*/
+
+	addSlackSection(&msg, "Disable User Request Details", "", []slackText{
+		slackField("Username", record.Alert.Username),
+		slackField("User IP", record.Alert.UserIP),
+		slackField("Alert/Search Name", record.Alert.AlertName),
+		slackField("Alert/Search ID", record.Alert.SearchID),
+	})
+
+	/*
+		Alert Request Summary Section - General client request details
+	*/
+
+	summaryFields := []slackText{
+		slackField("Received at", record.Alert.LocalTime),
+	}
+	if record.Alert.EventTime != "" {
+		summaryFields = append(summaryFields,
+			slackField("Activity at", record.Alert.EventTime),
+			slackField("Latency", record.Alert.Latency.String()),
+		)
+	}
+	summaryFields = append(summaryFields,
+		slackField("Endpoint path", record.Alert.EndpointPath),
+		slackField("HTTP Method", record.Alert.HTTPMethod),
+		slackField("Alert Sender IP", record.Alert.PayloadSenderIP),
+	)
+
+	addSlackSection(&msg, "Alert Request Summary", "", summaryFields)
+
+	/*
+		Alert Request Headers Section
+	*/
 
-		return successMsg
+	// Headers are not available for notifications restored from the outbox
+	// as they are not stored; the section is omitted in that case.
+	if len(record.Alert.Headers) > 0 {
+		headerNames := make([]string, 0, len(record.Alert.Headers))
+		for header := range record.Alert.Headers {
+			headerNames = append(headerNames, header)
+		}
+		sort.Strings(headerNames)
+
+		headerFields := make([]slackText, 0, len(headerNames))
+		for _, header := range headerNames {
+			headerFields = append(headerFields, slackField(header, record.Alert.Headers[header]...))
+		}
 
+		addSlackSection(
+			&msg,
+			"Alert Request Headers",
+			fmt.Sprintf("%d alert request headers provided", len(record.Alert.Headers)),
+			headerFields,
+		)
 	}
 
+	/*
+		Branding/Trailer
+	*/
+
+	msg.Blocks = append(msg.Blocks, slackBlock{
+		Type: "context",
+		Elements: []slackText{
+			{
+				Type: "mrkdwn",
+				Text: config.MessageTrailer(config.BrandingSlackFormat),
+			},
+		},
+	})
+
+	return msg
 }
 
-// emailConfig represents user-provided settings specific to sending email
-// notifications. This is mostly a helper for passing email settings "down"
-// until sufficient work on GH-22 can be applied.
+// emailConfig represents user-provided settings specific to creating and
+// sending email notifications.
 type emailConfig struct {
-	server                 string
-	serverPort             int
-	senderAddress          string
-	recipientAddresses     []string
-	clientIdentity         string
-	timeout                time.Duration
-	notificationRateLimit  time.Duration
-	notificationRetries    int
-	notificationRetryDelay int
-	template               *template.Template
+	server             string
+	serverPort         int
+	senderAddress      string
+	recipientAddresses []string
+	clientIdentity     string
+	templates          *notificationTemplates
+	startTLS           string
+	implicitTLS        bool
+	authMechanism      string
+	username           string
+	passwordFile       string
+	caFile             string
+	attachAlert        bool
 }
 
 // createEmailMessage receives an event record and a collection of settings
-// used to generate a formatted email message.
-func createEmailMessage(record events.Record, emailCfg emailConfig) string {
+// used to generate a formatted email message. The message includes plain
+// text and HTML parts rendered from the same data and, if enabled, the alert
+// payload as an attachment.
+func createEmailMessage(record events.Record, emailCfg emailConfig) (string, error) {
 
 	myFuncName := caller.GetFuncName()
 
-	emailSubjectPrefix := config.MyAppName + ": "
-
-	// getMsgTitle() func is used for both email and Teams messages
-	emailSubject := getMsgTitle(emailSubjectPrefix, record)
-
-	// Use this function to generate the summary instead of directly
-	// referencing the Record.Note field; the Record.Note field may not always
-	// have a value (GH-134).
-	emailSummary := getMsgSummaryText(record)
+	emailSubject := emailCfg.templates.emailSubjectText(record)
 
-	data := struct {
-		Record       events.Record
-		EmailSubject string
-		EmailSummary string
-		Branding     string
-	}{
-		Record:       record,
-		EmailSubject: emailSubject,
-		EmailSummary: emailSummary,
-		Branding:     config.MessageTrailer(config.BrandingTextileFormat),
-	}
+	data := newNotificationTemplateData(record, emailSubject)
 
 	var renderedTmpl bytes.Buffer
 	var emailBody string
 
-	tmplErr := emailCfg.template.Execute(&renderedTmpl, data)
+	tmplErr := emailCfg.templates.emailBody.Execute(&renderedTmpl, data)
 	switch {
 	case tmplErr != nil:
 		errMsg := fmt.Sprintf(
@@ -555,25 +905,52 @@ func createEmailMessage(record events.Record, emailCfg emailConfig) string {
 		emailBody = renderedTmpl.String()
 	}
 
-	email := fmt.Sprintf(
-		"To: %s\r\n"+
-			"From: %s\r\n"+
-			"Subject: %s\r\n"+
-			"\r\n"+
-			"%s\r\n",
-		strings.Join(emailCfg.recipientAddresses, ", "),
-		emailCfg.senderAddress,
-		emailSubject,
-		emailBody,
-	)
+	var renderedHTMLTmpl bytes.Buffer
+	var emailHTMLBody string
+
+	htmlTmplErr := emailCfg.templates.emailHTMLBody.Execute(&renderedHTMLTmpl, data)
+	switch {
+	case htmlTmplErr != nil:
+		errMsg := fmt.Sprintf(
+			"Error returned from attempt to parse email HTML template: %v",
+			htmlTmplErr,
+		)
+		log.Errorf("%s: %v", myFuncName, errMsg)
+
+		emailHTMLBody = "<p>" + htmltemplate.HTMLEscapeString(errMsg) + "</p>"
+	default:
+		emailHTMLBody = renderedHTMLTmpl.String()
+	}
+
+	content := emailContent{
+		subject:  emailSubject,
+		textBody: emailBody,
+		htmlBody: emailHTMLBody,
+	}
 
-	return email
+	if emailCfg.attachAlert {
+		switch payload := record.Alert.Payload; {
+		case len(payload) == 0 || string(payload) == "null":
+			log.Debugf(
+				"%s: alert payload not available; skipping attachment",
+				myFuncName,
+			)
+		default:
+			content.attachments = append(content.attachments, emailAttachment{
+				filename:    emailAlertAttachmentName,
+				contentType: "application/json",
+				content:     payload,
+			})
+		}
+	}
+
+	return buildEmailMessage(emailCfg, content, time.Now())
 
 }
 
 // sendEmail is an analogue of the abstraction/functionality provided by
-// goteamsnotify.SendMessage(...). The plan is to refactor this function as part
-// of the work for GH-22.
+// goteamsnotify.SendWithContext(...). A single attempt is made to submit the
+// provided email message; retries are handled by the caller.
 func sendEmail(
 	ctx context.Context,
 	emailCfg emailConfig,
@@ -587,64 +964,98 @@ func sendEmail(
 	// FIXME: This function both logs *and* returns the error, which is
 	// duplication that will require fixing at some point. Leaving both in for
 	// the time being until this code proves stable.
-	send := func(_ context.Context, emailCfg emailConfig, emailMsg string, myFuncName string) error {
 
-		// Connect to the remote SMTP server.
-		c, dialErr := smtp.Dial(smtpServer)
-		if dialErr != nil {
+	// Load the TLS configuration and credentials for each attempt so that
+	// updated CA bundles and passwords are picked up without a restart.
+	var tlsCfg *tls.Config
+	if emailCfg.implicitTLS || emailCfg.startTLS != config.EmailStartTLSOff {
+		var tlsCfgErr error
+		tlsCfg, tlsCfgErr = emailTLSConfig(emailCfg)
+		if tlsCfgErr != nil {
 			errMsg := fmt.Errorf(
-				"%s: failed to connect to SMTP server %q on port %v: %w",
+				"%s: failed to prepare TLS configuration for SMTP server %q: %w",
 				myFuncName,
 				emailCfg.server,
-				emailCfg.serverPort,
-				dialErr,
+				tlsCfgErr,
 			)
 			log.Error(errMsg.Error())
 
 			return errMsg
 		}
+	}
 
-		// At this point we have a Client, so we need to ensure that the QUIT
-		// command is sent to the SMTP server to clean up; close connection and
-		// send the QUIT command.
-		defer func() {
-			if err := c.Quit(); err != nil {
+	auth, authErr := emailAuth(emailCfg)
+	if authErr != nil {
+		errMsg := fmt.Errorf(
+			"%s: failed to prepare authentication for SMTP server %q: %w",
+			myFuncName,
+			emailCfg.server,
+			authErr,
+		)
+		log.Error(errMsg.Error())
 
-				fmt.Printf("Error type: %+v", err)
+		return errMsg
+	}
 
-				errMsg := fmt.Errorf(
-					"%s: failure occurred sending QUIT command to %q on port %v: %w",
-					myFuncName,
-					emailCfg.server,
-					emailCfg.serverPort,
-					err,
-				)
-				log.Error(errMsg.Error())
+	// Connect to the remote SMTP server, negotiating TLS immediately if
+	// implicit TLS is enabled.
+	var conn net.Conn
+	var dialErr error
+	switch {
+	case emailCfg.implicitTLS:
+		dialer := tls.Dialer{Config: tlsCfg}
+		conn, dialErr = dialer.DialContext(ctx, "tcp", smtpServer)
+	default:
+		var dialer net.Dialer
+		conn, dialErr = dialer.DialContext(ctx, "tcp", smtpServer)
+	}
+	if dialErr != nil {
+		errMsg := fmt.Errorf(
+			"%s: failed to connect to SMTP server %q on port %v: %w",
+			myFuncName,
+			emailCfg.server,
+			emailCfg.serverPort,
+			dialErr,
+		)
+		log.Error(errMsg.Error())
 
-				return
-			}
+		return errMsg
+	}
 
-			log.Debugf(
-				"%s: Successfully sent QUIT command to %q on port %v",
-				myFuncName,
-				emailCfg.server,
-				emailCfg.serverPort,
-			)
-		}()
+	// Apply the deadline (if any) of the provided context to the connection
+	// so that an unresponsive server does not block this attempt
+	// indefinitely.
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			log.Debugf("%s: failed to set connection deadline: %v", myFuncName, err)
+		}
+	}
 
-		// Use user-specified (or default) client identity in our greeting to the
-		// SMTP server.
-		log.Debugf(
-			"%s: Sending greeting to SMTP server %q on port %v with identity of %q",
+	c, clientErr := smtp.NewClient(conn, emailCfg.server)
+	if clientErr != nil {
+		_ = conn.Close()
+		errMsg := fmt.Errorf(
+			"%s: failed to establish session with SMTP server %q on port %v: %w",
 			myFuncName,
 			emailCfg.server,
 			emailCfg.serverPort,
-			emailCfg.clientIdentity,
+			clientErr,
 		)
-		if err := c.Hello(emailCfg.clientIdentity); err != nil {
+		log.Error(errMsg.Error())
+
+		return errMsg
+	}
+
+	// At this point we have a Client, so we need to ensure that the QUIT
+	// command is sent to the SMTP server to clean up; close connection and
+	// send the QUIT command.
+	defer func() {
+		if err := c.Quit(); err != nil {
+
+			fmt.Printf("Error type: %+v", err)
 
 			errMsg := fmt.Errorf(
-				"%s: failure occurred sending greeting to SMTP server %q on port %v: %w",
+				"%s: failure occurred sending QUIT command to %q on port %v: %w",
 				myFuncName,
 				emailCfg.server,
 				emailCfg.serverPort,
@@ -652,296 +1063,205 @@ func sendEmail(
 			)
 			log.Error(errMsg.Error())
 
-			return errMsg
+			return
 		}
 
-		// Set the sender
-		if err := c.Mail(emailCfg.senderAddress); err != nil {
-			errMsg := fmt.Errorf(
-				"%s: failed to set sender address %q for email: %w",
-				myFuncName,
-				emailCfg.senderAddress,
-				err,
-			)
-			log.Error(errMsg.Error())
+		log.Debugf(
+			"%s: Successfully sent QUIT command to %q on port %v",
+			myFuncName,
+			emailCfg.server,
+			emailCfg.serverPort,
+		)
+	}()
 
-			return errMsg
-		}
+	// Use user-specified (or default) client identity in our greeting to the
+	// SMTP server.
+	log.Debugf(
+		"%s: Sending greeting to SMTP server %q on port %v with identity of %q",
+		myFuncName,
+		emailCfg.server,
+		emailCfg.serverPort,
+		emailCfg.clientIdentity,
+	)
+	if err := c.Hello(emailCfg.clientIdentity); err != nil {
 
-		// Process one or more user-provided destination email addresses
-		for _, emailAddr := range emailCfg.recipientAddresses {
-			if err := c.Rcpt(emailAddr); err != nil {
-				errMsg := fmt.Errorf(
-					"%s: failed to set recipient address %q: %w",
-					myFuncName,
-					emailAddr,
-					err,
-				)
-				log.Error(errMsg.Error())
+		errMsg := fmt.Errorf(
+			"%s: failure occurred sending greeting to SMTP server %q on port %v: %w",
+			myFuncName,
+			emailCfg.server,
+			emailCfg.serverPort,
+			err,
+		)
+		log.Error(errMsg.Error())
 
-				return errMsg
-			}
-		}
+		return errMsg
+	}
 
-		// Send the email body.
-		//
-		// Data issues a DATA command to the server and returns a writer that can
-		// be used to write the mail headers and body. The caller should close the
-		// writer before calling any more methods on c. A call to Data must be
-		// preceded by one or more calls to Rcpt.
-		wc, dataErr := c.Data()
-		if dataErr != nil {
-			errMsg := fmt.Errorf(
-				"%s: failure occurred when sending DATA command to SMTP server %q on port %v: %w",
+	// Upgrade the connection if requested and supported by the server. The
+	// connection is already encrypted if implicit TLS is enabled.
+	if !emailCfg.implicitTLS && emailCfg.startTLS != config.EmailStartTLSOff {
+		supported, _ := c.Extension("STARTTLS")
+		switch {
+		case supported:
+			log.Debugf(
+				"%s: Upgrading connection to SMTP server %q on port %v using STARTTLS",
 				myFuncName,
 				emailCfg.server,
 				emailCfg.serverPort,
-				dataErr,
 			)
-			log.Error(errMsg.Error())
-
-			return dataErr
-		}
-
-		defer func() {
-
-			if err := wc.Close(); err != nil {
-
-				fmt.Printf("Error type: %+v", err)
-
+			if err := c.StartTLS(tlsCfg); err != nil {
 				errMsg := fmt.Errorf(
-					"%s: failure occurred closing mail headers and body writer: %w",
+					"%s: failed to upgrade connection to SMTP server %q on port %v using STARTTLS: %w",
 					myFuncName,
+					emailCfg.server,
+					emailCfg.serverPort,
 					err,
 				)
 				log.Error(errMsg.Error())
 
-				return
+				return errMsg
 			}
 
-			log.Debugf(
-				"%s: Successfully closed mail headers and body writer",
-				myFuncName,
-			)
-		}()
-
-		if _, err := fmt.Fprint(wc, emailMsg); err != nil {
+		case emailCfg.startTLS == config.EmailStartTLSRequired:
 			errMsg := fmt.Errorf(
-				"%s: failure occurred when writing message to connection for SMTP server %q on port %v: %w",
+				"%s: STARTTLS required, but not supported by SMTP server %q on port %v",
 				myFuncName,
 				emailCfg.server,
 				emailCfg.serverPort,
-				dataErr,
 			)
 			log.Error(errMsg.Error())
 
-			return dataErr
+			return errMsg
 
+		default:
+			log.Warnf(
+				"%s: STARTTLS not supported by SMTP server %q on port %v; continuing without encryption",
+				myFuncName,
+				emailCfg.server,
+				emailCfg.serverPort,
+			)
 		}
-
-		return nil
 	}
 
-	var result error
-
-	// initial attempt + number of specified retries
-	attemptsAllowed := 1 + emailCfg.notificationRetries
-
-	ourRetryDelay := time.Duration(emailCfg.notificationRetryDelay) * time.Second
-
-	// attempt to send message to Microsoft Teams, retry specified number of
-	// times before giving up
-	for attempt := 1; attempt <= attemptsAllowed; attempt++ {
-
-		// Check here at the start of the loop iteration (either first or
-		// subsequent) in order to return early in an effort to prevent
-		// undesired message attempts after the context has been cancelled.
-		if ctx.Err() != nil {
+	if auth != nil {
+		if supported, _ := c.Extension("AUTH"); !supported {
 			errMsg := fmt.Errorf(
-				"%s: context cancelled or expired: %v; aborting message submission after %d of %d attempts",
+				"%s: authentication configured, but not supported by SMTP server %q on port %v",
 				myFuncName,
-				ctx.Err().Error(),
-				attempt,
-				attemptsAllowed,
+				emailCfg.server,
+				emailCfg.serverPort,
 			)
-
-			// If this is set, we're looking at the second (incomplete)
-			// iteration. Let's combine our error above with the last result
-			// in an effort to provide a more meaningful error.
-			if result != nil {
-				// TODO: How to properly combine these two errors?
-				errMsg = fmt.Errorf("%w: %v", result, errMsg)
-			}
-
 			log.Error(errMsg.Error())
+
 			return errMsg
 		}
 
-		result = send(ctx, emailCfg, emailMsg, myFuncName)
-		if result != nil {
-
+		log.Debugf(
+			"%s: Authenticating to SMTP server %q on port %v as %q using %s",
+			myFuncName,
+			emailCfg.server,
+			emailCfg.serverPort,
+			emailCfg.username,
+			emailCfg.authMechanism,
+		)
+		if err := c.Auth(auth); err != nil {
 			errMsg := fmt.Errorf(
-				"%s: Attempt %d of %d to send message failed: %v",
+				"%s: failed to authenticate to SMTP server %q on port %v as %q: %w",
 				myFuncName,
-				attempt,
-				attemptsAllowed,
-				result,
+				emailCfg.server,
+				emailCfg.serverPort,
+				emailCfg.username,
+				err,
 			)
-
 			log.Error(errMsg.Error())
 
-			// apply retry delay if our context hasn't been cancelled yet,
-			// otherwise continue with the loop to allow context cancellation
-			// handling logic to be applied at the top of the loop
-			if ctx.Err() == nil {
-				log.Debugf(
-					"%s: Context not cancelled yet, applying retry delay of %v",
-					myFuncName,
-					ourRetryDelay,
-				)
-				time.Sleep(ourRetryDelay)
-			}
-
-			// retry send attempt (if attempts remain)
-			continue
-
+			return errMsg
 		}
+	}
 
-		log.Debugf(
-			"%s: successfully sent message after %d of %d attempts\n",
+	// Set the sender
+	if err := c.Mail(emailCfg.senderAddress); err != nil {
+		errMsg := fmt.Errorf(
+			"%s: failed to set sender address %q for email: %w",
 			myFuncName,
-			attempt,
-			attemptsAllowed,
+			emailCfg.senderAddress,
+			err,
 		)
+		log.Error(errMsg.Error())
 
-		// break out of retry loop: we're done!
-		break
-
+		return errMsg
 	}
 
-	return result
-
-}
-
-// sendEmailMessage wraps and orchestrates another function call to send email
-// messages. This includes honoring the provided schedule in order to comply
-// with remote API rate limits. The plan is to refactor this function as part
-// of the work for GH-22.
-func sendEmailMessage(
-	ctx context.Context,
-	emailCfg emailConfig,
-	emailMsg string,
-	schedule time.Time,
-
-) NotifyResult {
-
-	myFuncName := caller.GetFuncName()
-
-	log.Debugf("%s: Time now is %v", myFuncName, time.Now().Format("15:04:05"))
-	log.Debugf("%s: Notification scheduled for: %v", myFuncName, schedule.Format("15:04:05"))
-
-	// Set delay timer to meet received notification schedule. This helps
-	// ensure that we delay the appropriate amount of time before we make our
-	// first attempt at sending a message to the specified SMTP server.
-	notificationDelay := time.Until(schedule)
-
-	notificationDelayTimer := time.NewTimer(notificationDelay)
-	defer notificationDelayTimer.Stop()
-	log.Debugf("%s: notificationDelayTimer created at %v with duration %v",
-		myFuncName,
-		time.Now().Format("15:04:05"),
-		notificationDelay,
-	)
-
-	log.Debugf(
-		"%s: Waiting for either context or notificationDelayTimer to expire before sending notification",
-		myFuncName,
-	)
-
-	select {
-	case <-ctx.Done():
-		ctxErr := ctx.Err()
-		msg := NotifyResult{
-			Val: fmt.Sprintf("%s: Received Done signal at %v: %v, shutting down",
+	// Process one or more user-provided destination email addresses
+	for _, emailAddr := range emailCfg.recipientAddresses {
+		if err := c.Rcpt(emailAddr); err != nil {
+			errMsg := fmt.Errorf(
+				"%s: failed to set recipient address %q: %w",
 				myFuncName,
-				time.Now().Format("15:04:05"),
-				ctxErr.Error(),
-			),
-			Success: false,
-		}
-		log.Debug(msg.Val)
-		return msg
+				emailAddr,
+				err,
+			)
+			log.Error(errMsg.Error())
 
-	// Delay between message submission attempts; this will *always*
-	// delay, regardless of whether the attempt is the first one or not
-	case <-notificationDelayTimer.C:
+			return errMsg
+		}
+	}
 
-		log.Debugf("%s: Waited %v before notification attempt at %v",
+	// Send the email body.
+	//
+	// Data issues a DATA command to the server and returns a writer that can
+	// be used to write the mail headers and body. The caller should close the
+	// writer before calling any more methods on c. A call to Data must be
+	// preceded by one or more calls to Rcpt.
+	wc, dataErr := c.Data()
+	if dataErr != nil {
+		errMsg := fmt.Errorf(
+			"%s: failure occurred when sending DATA command to SMTP server %q on port %v: %w",
 			myFuncName,
-			notificationDelay,
-			time.Now().Format("15:04:05"),
+			emailCfg.server,
+			emailCfg.serverPort,
+			dataErr,
 		)
+		log.Error(errMsg.Error())
 
-		ctxExpires, ctxExpired := ctx.Deadline()
-		if ctxExpired {
-			log.Debugf(
-				"%s: WaitTimeout context expires at: %v",
-				myFuncName,
-				ctxExpires.Format("15:04:05"),
-			)
-		}
-
-		// check to see if context has expired during our delay
-		if ctx.Err() != nil {
-			msg := NotifyResult{
-				Val: fmt.Sprintf(
-					"%s: context expired or cancelled at %v: %v, attempting to abort message submission",
-					myFuncName,
-					time.Now().Format("15:04:05"),
-					ctx.Err().Error(),
-				),
-				Success: false,
-			}
+		return dataErr
+	}
 
-			log.Debug(msg.Val)
+	defer func() {
 
-			return msg
-		}
+		if err := wc.Close(); err != nil {
 
-		if err := sendEmail(ctx, emailCfg, emailMsg); err != nil {
+			fmt.Printf("Error type: %+v", err)
 
-			errMsg := NotifyResult{
-				Err: fmt.Errorf(
-					"%s: ERROR: Failed to submit message to %s on port %v at %v: %v",
-					myFuncName,
-					emailCfg.server,
-					emailCfg.serverPort,
-					time.Now().Format("15:04:05"),
-					err,
-				),
-				Success: false,
-			}
-			log.Error(errMsg.Err.Error())
+			errMsg := fmt.Errorf(
+				"%s: failure occurred closing mail headers and body writer: %w",
+				myFuncName,
+				err,
+			)
+			log.Error(errMsg.Error())
 
-			return errMsg
+			return
 		}
 
-		successMsg := NotifyResult{
-			Val: fmt.Sprintf(
-				"%s: Message successfully sent to SMTP server %q on port %v at %v",
-				myFuncName,
-				emailCfg.server,
-				emailCfg.serverPort,
-				time.Now().Format("15:04:05"),
-			),
-			Success: true,
-		}
+		log.Debugf(
+			"%s: Successfully closed mail headers and body writer",
+			myFuncName,
+		)
+	}()
 
-		// Note success for potential troubleshooting
-		log.Debug(successMsg.Val)
+	if _, err := fmt.Fprint(wc, emailMsg); err != nil {
+		errMsg := fmt.Errorf(
+			"%s: failure occurred when writing message to connection for SMTP server %q on port %v: %w",
+			myFuncName,
+			emailCfg.server,
+			emailCfg.serverPort,
+			dataErr,
+		)
+		log.Error(errMsg.Error())
 
-		return successMsg
+		return dataErr
 
 	}
 
+	return nil
 }
@@ -0,0 +1,223 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// emailLineLength is the maximum length of base64 encoded lines in email
+// message attachments.
+const emailLineLength int = 76
+
+// emailAttachment is a file attached to an email message.
+type emailAttachment struct {
+	filename    string
+	contentType string
+	content     []byte
+}
+
+// emailContent is the content of an email message prior to encoding.
+type emailContent struct {
+	subject     string
+	textBody    string
+	htmlBody    string
+	attachments []emailAttachment
+}
+
+// buildEmailMessage generates a RFC 5322 email message from the provided
+// content. The plain text and HTML bodies are sent as a multipart/alternative
+// message; if attachments are provided, the bodies and attachments are sent
+// as a multipart/mixed message. Headers which contain non-ASCII characters
+// are encoded as described by RFC 2047.
+func buildEmailMessage(emailCfg emailConfig, content emailContent, now time.Time) (string, error) {
+
+	messageID, err := newMessageID(emailCfg, now)
+	if err != nil {
+		return "", err
+	}
+
+	var body bytes.Buffer
+
+	alternative, err := writeAlternativeParts(content)
+	if err != nil {
+		return "", err
+	}
+
+	var contentType string
+	switch {
+	case len(content.attachments) > 0:
+		mixed := multipart.NewWriter(&body)
+		contentType = "multipart/mixed; boundary=" + mixed.Boundary()
+
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {alternative.contentType},
+		})
+		if err != nil {
+			return "", err
+		}
+		if _, err := part.Write(alternative.body); err != nil {
+			return "", err
+		}
+
+		for _, attachment := range content.attachments {
+			if err := writeAttachmentPart(mixed, attachment); err != nil {
+				return "", err
+			}
+		}
+
+		if err := mixed.Close(); err != nil {
+			return "", err
+		}
+
+	default:
+		contentType = alternative.contentType
+		body.Write(alternative.body)
+	}
+
+	var msg strings.Builder
+	writeHeader := func(name string, value string) {
+		fmt.Fprintf(&msg, "%s: %s\r\n", name, value)
+	}
+
+	writeHeader("From", emailCfg.senderAddress)
+	writeHeader("To", strings.Join(emailCfg.recipientAddresses, ", "))
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", content.subject))
+	writeHeader("Date", now.Format(time.RFC1123Z))
+	writeHeader("Message-ID", messageID)
+	writeHeader("MIME-Version", "1.0")
+	writeHeader("Content-Type", contentType)
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.String(), nil
+}
+
+// multipartBody is an encoded multipart body along with the Content-Type
+// header value (including the boundary) used to reference it.
+type multipartBody struct {
+	contentType string
+	body        []byte
+}
+
+// writeAlternativeParts encodes the plain text and HTML bodies of the
+// provided content as a multipart/alternative body. The plain text part is
+// listed first as the least preferred alternative.
+func writeAlternativeParts(content emailContent) (multipartBody, error) {
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", content.textBody},
+		{"text/html; charset=utf-8", content.htmlBody},
+	}
+
+	for _, p := range parts {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return multipartBody{}, err
+		}
+
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(p.body)); err != nil {
+			return multipartBody{}, err
+		}
+		if err := qp.Close(); err != nil {
+			return multipartBody{}, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return multipartBody{}, err
+	}
+
+	return multipartBody{
+		contentType: "multipart/alternative; boundary=" + writer.Boundary(),
+		body:        buf.Bytes(),
+	}, nil
+}
+
+// writeAttachmentPart adds the provided attachment to a multipart/mixed body
+// using base64 encoding.
+func writeAttachmentPart(writer *multipart.Writer, attachment emailAttachment) error {
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {mime.FormatMediaType(
+			attachment.contentType,
+			map[string]string{"name": attachment.filename},
+		)},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition": {mime.FormatMediaType(
+			"attachment",
+			map[string]string{"filename": attachment.filename},
+		)},
+	})
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(attachment.content)
+	for len(encoded) > emailLineLength {
+		if _, err := fmt.Fprintf(part, "%s\r\n", encoded[:emailLineLength]); err != nil {
+			return err
+		}
+		encoded = encoded[emailLineLength:]
+	}
+	_, err = fmt.Fprintf(part, "%s\r\n", encoded)
+
+	return err
+}
+
+// newMessageID generates a unique Message-ID header value using the domain
+// of the sender address (or the client identity if the domain cannot be
+// determined).
+func newMessageID(emailCfg emailConfig, now time.Time) (string, error) {
+
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate Message-ID: %w", err)
+	}
+
+	domain := emailCfg.clientIdentity
+	if i := strings.LastIndex(emailCfg.senderAddress, "@"); i >= 0 && i < len(emailCfg.senderAddress)-1 {
+		domain = emailCfg.senderAddress[i+1:]
+	}
+
+	return fmt.Sprintf(
+		"<%d.%s@%s>",
+		now.UnixNano(),
+		hex.EncodeToString(random),
+		domain,
+	), nil
+}
@@ -0,0 +1,551 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/atc0005/brick/internal/config"
+	"github.com/atc0005/brick/internal/digest"
+	"github.com/atc0005/brick/internal/events"
+	"github.com/atc0005/brick/internal/metrics"
+	"github.com/atc0005/brick/internal/notify"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+	"github.com/atc0005/go-teams-notify/v2/adaptivecard"
+)
+
+// notifierRegistration describes a notification service available for use
+// by NotifyMgr.
+type notifierRegistration struct {
+
+	// name is the name of the notification service. This is used in log
+	// messages when noting whether the notification service is enabled.
+	name string
+
+	// enabled indicates whether the notification service is enabled by the
+	// user-provided configuration.
+	enabled func(cfg *config.Config) bool
+
+	// newNotifiers creates each Notifier for the notification service along
+	// with the settings used to schedule and retry notification attempts.
+	// Most notification services create a single Notifier; others (e.g.,
+	// generic webhooks) create one for each configured target. The provided
+	// templates are used to generate notifications where supported.
+	newNotifiers func(cfg *config.Config, templates *notificationTemplates) []configuredNotifier
+}
+
+// configuredNotifier pairs a Notifier with the settings used to schedule and
+// retry its notification attempts.
+type configuredNotifier struct {
+	notifier notify.Notifier
+	settings notify.Settings
+}
+
+// singleNotifier adapts a function which creates a single Notifier for use
+// as the newNotifiers field of a notifierRegistration.
+func singleNotifier(
+	newNotifier func(cfg *config.Config, templates *notificationTemplates) (notify.Notifier, notify.Settings),
+) func(cfg *config.Config, templates *notificationTemplates) []configuredNotifier {
+	return func(cfg *config.Config, templates *notificationTemplates) []configuredNotifier {
+		notifier, settings := newNotifier(cfg, templates)
+		return []configuredNotifier{{notifier: notifier, settings: settings}}
+	}
+}
+
+// notifierRegistry lists each notification service supported by this
+// application. New notification services are added by implementing the
+// notify.Notifier interface and adding an entry here.
+var notifierRegistry = []notifierRegistration{
+	{
+		name:         metrics.ServiceTeams,
+		enabled:      (*config.Config).NotifyTeams,
+		newNotifiers: newTeamsNotifiers,
+	},
+	{
+		name:         metrics.ServiceEmail,
+		enabled:      (*config.Config).NotifyEmail,
+		newNotifiers: singleNotifier(newEmailNotifier),
+	},
+	{
+		name:         metrics.ServiceSlack,
+		enabled:      (*config.Config).NotifySlack,
+		newNotifiers: singleNotifier(newSlackNotifier),
+	},
+	{
+		name:         webhookServicePrefix,
+		enabled:      (*config.Config).NotifyWebhooks,
+		newNotifiers: newWebhookNotifiers,
+	},
+}
+
+// teamsNotifier sends notifications to a Microsoft Teams channel.
+type teamsNotifier struct {
+	name          string
+	webhookURL    string
+	messageFormat string
+	client        *goteamsnotify.TeamsClient
+	templates     *notificationTemplates
+}
+
+// newTeamsNotifiers creates a Microsoft Teams Notifier for each Microsoft
+// Teams webhook URL in the provided configuration. Each Notifier uses the
+// same settings and templates.
+func newTeamsNotifiers(cfg *config.Config, templates *notificationTemplates) []configuredNotifier {
+
+	// The rate limit is applied in order to comply with remote API limits.
+	// https://docs.microsoft.com/en-us/microsoftteams/platform/webhooks-and-connectors/how-to/connectors-using
+	settings := notify.Settings{
+		Timeout:    config.NotifyMgrTeamsNotificationTimeout,
+		RateLimit:  cfg.TeamsNotificationRateLimit(),
+		Retries:    cfg.TeamsNotificationRetries(),
+		RetryDelay: time.Duration(cfg.TeamsNotificationRetryDelay()) * time.Second,
+	}
+
+	targets := cfg.TeamsTargets()
+	notifiers := make([]configuredNotifier, 0, len(targets))
+
+	for _, target := range targets {
+
+		client := goteamsnotify.NewTeamsClient()
+		client.AddWebhookURLValidationPatterns(config.TeamsWebhookURLValidationPatterns...)
+
+		notifier := teamsNotifier{
+			name:          target.Name,
+			webhookURL:    target.URL,
+			messageFormat: target.MessageFormat,
+			client:        client,
+			templates:     templates,
+		}
+
+		log.Debugf("%s notifier: using %s message format", notifier.Name(), notifier.messageFormat)
+
+		notifiers = append(notifiers, configuredNotifier{
+			notifier: notifier,
+			settings: settings,
+		})
+	}
+
+	return notifiers
+}
+
+// Name returns the name of the notification service. Additional webhook URLs
+// are named separately so that they are retried and reported independently.
+func (n teamsNotifier) Name() string {
+	if n.name == "" {
+		return metrics.ServiceTeams
+	}
+	return metrics.ServiceTeams + ":" + n.name
+}
+
+// Send creates a Microsoft Teams message using the configured message format
+// from the provided event Record and submits it to the configured webhook
+// URL.
+func (n teamsNotifier) Send(ctx context.Context, record events.Record) error {
+
+	// Note: We already do validation elsewhere, and the library call does
+	// even more validation, but we can handle this obvious empty argument
+	// problem directly
+	if n.webhookURL == "" {
+		return errors.New(
+			"webhookURL not defined, skipping message submission to Microsoft Teams channel",
+		)
+	}
+
+	var err error
+	switch n.messageFormat {
+	case config.TeamsMessageFormatAdaptiveCard:
+		var msg *adaptivecard.Message
+		msg, err = createTeamsAdaptiveCard(record, n.templates.teamsSummaryText(record))
+		if err != nil {
+			return fmt.Errorf("failed to create Adaptive Card: %w", err)
+		}
+		err = n.client.SendWithContext(ctx, n.webhookURL, msg)
+
+	default:
+		err = n.client.SendWithContext(ctx, n.webhookURL, createTeamsMessage(record, n.templates.teamsSummaryText(record)))
+	}
+
+	if err != nil {
+		return fmt.Errorf(
+			"failed to submit message to Microsoft Teams at %v: %w",
+			time.Now().Format("15:04:05"),
+			err,
+		)
+	}
+
+	return nil
+}
+
+// SendDigest creates a Microsoft Teams message using the configured message
+// format from the provided digest report and submits it to the configured
+// webhook URL.
+func (n teamsNotifier) SendDigest(ctx context.Context, report digest.Report) error {
+
+	var err error
+	switch n.messageFormat {
+	case config.TeamsMessageFormatAdaptiveCard:
+		var msg *adaptivecard.Message
+		msg, err = createDigestAdaptiveCard(report)
+		if err != nil {
+			return fmt.Errorf("failed to create Adaptive Card: %w", err)
+		}
+		err = n.client.SendWithContext(ctx, n.webhookURL, msg)
+
+	default:
+		err = n.client.SendWithContext(ctx, n.webhookURL, createDigestTeamsMessage(report))
+	}
+
+	if err != nil {
+		return fmt.Errorf(
+			"failed to submit digest report to Microsoft Teams at %v: %w",
+			time.Now().Format("15:04:05"),
+			err,
+		)
+	}
+
+	return nil
+}
+
+// emailNotifier sends notifications by email.
+type emailNotifier struct {
+	emailCfg emailConfig
+}
+
+// newEmailNotifier creates an email Notifier using the provided
+// configuration and templates.
+func newEmailNotifier(cfg *config.Config, templates *notificationTemplates) (notify.Notifier, notify.Settings) {
+
+	notifier := emailNotifier{
+		emailCfg: emailConfig{
+			server:             cfg.EmailServer(),
+			serverPort:         cfg.EmailServerPort(),
+			senderAddress:      cfg.EmailSenderAddress(),
+			recipientAddresses: cfg.EmailRecipientAddresses(),
+			clientIdentity:     cfg.EmailClientIdentity(),
+			templates:          templates,
+			startTLS:           cfg.EmailStartTLS(),
+			implicitTLS:        cfg.EmailImplicitTLS(),
+			authMechanism:      cfg.EmailAuthMechanism(),
+			username:           cfg.EmailUsername(),
+			passwordFile:       cfg.EmailPasswordFile(),
+			caFile:             cfg.EmailCAFile(),
+			attachAlert:        cfg.EmailAttachAlert(),
+		},
+	}
+
+	// The rate limit is applied in order to comply with any destination
+	// email server limits.
+	settings := notify.Settings{
+		Timeout:    config.NotifyMgrEmailNotificationTimeout,
+		RateLimit:  cfg.EmailNotificationRateLimit(),
+		Retries:    cfg.EmailNotificationRetries(),
+		RetryDelay: time.Duration(cfg.EmailNotificationRetryDelay()) * time.Second,
+	}
+
+	return notifier, settings
+}
+
+// Name returns the name of the notification service.
+func (n emailNotifier) Name() string {
+	return metrics.ServiceEmail
+}
+
+// Send creates an email message from the provided event Record and submits it
+// to the configured SMTP server.
+func (n emailNotifier) Send(ctx context.Context, record events.Record) error {
+
+	emailMsg, err := createEmailMessage(record, n.emailCfg)
+	if err != nil {
+		return fmt.Errorf("failed to create email message: %w", err)
+	}
+
+	if err := sendEmail(ctx, n.emailCfg, emailMsg); err != nil {
+		return fmt.Errorf(
+			"failed to submit message to %s on port %v at %v: %w",
+			n.emailCfg.server,
+			n.emailCfg.serverPort,
+			time.Now().Format("15:04:05"),
+			err,
+		)
+	}
+
+	return nil
+}
+
+// SendDigest creates an email message from the provided digest report and
+// submits it to the configured SMTP server.
+func (n emailNotifier) SendDigest(ctx context.Context, report digest.Report) error {
+
+	emailMsg, err := createDigestEmailMessage(report, n.emailCfg)
+	if err != nil {
+		return fmt.Errorf("failed to create digest report email message: %w", err)
+	}
+
+	if err := sendEmail(ctx, n.emailCfg, emailMsg); err != nil {
+		return fmt.Errorf(
+			"failed to submit digest report to %s on port %v at %v: %w",
+			n.emailCfg.server,
+			n.emailCfg.serverPort,
+			time.Now().Format("15:04:05"),
+			err,
+		)
+	}
+
+	return nil
+}
+
+// SendTo creates an email message from the provided event Record and
+// submits it to the configured SMTP server for delivery to the specified
+// recipients instead of the configured recipients.
+func (n emailNotifier) SendTo(ctx context.Context, record events.Record, recipients []string) error {
+	n.emailCfg.recipientAddresses = recipients
+	return n.Send(ctx, record)
+}
+
+// slackNotifier sends notifications to a Slack channel using an incoming
+// webhook.
+type slackNotifier struct {
+	webhookURL string
+	client     *http.Client
+	templates  *notificationTemplates
+}
+
+// newSlackNotifier creates a Slack Notifier using the provided configuration
+// and templates.
+func newSlackNotifier(cfg *config.Config, templates *notificationTemplates) (notify.Notifier, notify.Settings) {
+
+	notifier := slackNotifier{
+		webhookURL: cfg.SlackWebhookURL(),
+		client:     &http.Client{},
+		templates:  templates,
+	}
+
+	// The rate limit is applied in order to comply with remote API limits.
+	// https://api.slack.com/apis/rate-limits
+	settings := notify.Settings{
+		Timeout:    config.NotifyMgrSlackNotificationTimeout,
+		RateLimit:  cfg.SlackNotificationRateLimit(),
+		Retries:    cfg.SlackNotificationRetries(),
+		RetryDelay: time.Duration(cfg.SlackNotificationRetryDelay()) * time.Second,
+	}
+
+	return notifier, settings
+}
+
+// Name returns the name of the notification service.
+func (n slackNotifier) Name() string {
+	return metrics.ServiceSlack
+}
+
+// Send creates a Slack message from the provided event Record and submits it
+// to the configured webhook URL.
+func (n slackNotifier) Send(ctx context.Context, record events.Record) error {
+
+	payload, err := json.Marshal(createSlackMessage(record, n.templates.slackSummaryText(record)))
+	if err != nil {
+		return fmt.Errorf("failed to encode Slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to prepare Slack webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", config.MyAppName+"/"+config.Version())
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf(
+			"failed to submit message to Slack at %v: %w",
+			time.Now().Format("15:04:05"),
+			err,
+		)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Errorf("failed to close Slack webhook response body: %v", err)
+		}
+	}()
+
+	// Slack responds with a short plain text explanation of any problems
+	// (e.g., "invalid_blocks"); limit how much is read just in case.
+	body, readErr := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode != http.StatusOK {
+		if readErr != nil {
+			log.Debugf("failed to read Slack webhook response body: %v", readErr)
+		}
+
+		return fmt.Errorf(
+			"failed to submit message to Slack at %v: %s: %s",
+			time.Now().Format("15:04:05"),
+			resp.Status,
+			strings.TrimSpace(string(body)),
+		)
+	}
+
+	return nil
+}
+
+// webhookNotifier sends notifications to a generic webhook receiver as
+// CloudEvents.
+type webhookNotifier struct {
+	target config.WebhookTarget
+	client *http.Client
+}
+
+// newWebhookNotifiers creates a generic webhook Notifier for each webhook
+// target in the provided configuration.
+func newWebhookNotifiers(cfg *config.Config, _ *notificationTemplates) []configuredNotifier {
+
+	targets := cfg.WebhookTargets()
+	notifiers := make([]configuredNotifier, 0, len(targets))
+
+	for _, target := range targets {
+		notifiers = append(notifiers, configuredNotifier{
+			notifier: webhookNotifier{
+				target: target,
+				client: &http.Client{},
+			},
+			settings: notify.Settings{
+				Timeout:    config.NotifyMgrWebhookNotificationTimeout,
+				RateLimit:  time.Duration(target.RateLimit) * time.Second,
+				Retries:    target.Retries,
+				RetryDelay: time.Duration(target.RetryDelay) * time.Second,
+			},
+		})
+	}
+
+	return notifiers
+}
+
+// Name returns the name of the notification service. Each webhook target is
+// named separately so that targets are retried and reported independently.
+func (n webhookNotifier) Name() string {
+	return webhookServicePrefix + ":" + n.target.Name
+}
+
+// Accepts indicates whether the CloudEvents event type for the provided
+// event Record (or any step of a consolidated event Record) matches one of
+// the event types configured for the webhook target. All event types are
+// accepted if none are configured.
+func (n webhookNotifier) Accepts(record events.Record) bool {
+
+	if len(n.target.EventTypes) == 0 {
+		return true
+	}
+
+	for _, action := range record.Actions() {
+		eventType := cloudEventType(action)
+		for _, pattern := range n.target.EventTypes {
+			// patterns are checked during config validation
+			if ok, _ := path.Match(pattern, eventType); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Send creates a CloudEvent from the provided event Record and submits it to
+// the webhook target.
+func (n webhookNotifier) Send(ctx context.Context, record events.Record) error {
+
+	event, err := createCloudEvent(record, n.target.Source)
+	if err != nil {
+		return fmt.Errorf("failed to create CloudEvent: %w", err)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode CloudEvent: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.target.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to prepare webhook request: %w", err)
+	}
+
+	// User-specified headers are applied first so that the headers required
+	// by the CloudEvents structured content mode are not replaced.
+	for header, value := range n.target.Headers {
+		req.Header.Set(header, value)
+	}
+	req.Header.Set("Content-Type", cloudEventContentType)
+	req.Header.Set("User-Agent", config.MyAppName+"/"+config.Version())
+
+	if n.target.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+n.target.BearerToken)
+	}
+
+	if n.target.HMACSecret != "" {
+		mac := hmac.New(sha256.New, []byte(n.target.HMACSecret))
+		mac.Write(payload)
+		req.Header.Set(webhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf(
+			"failed to submit CloudEvent to %s at %v: %w",
+			n.target.Name,
+			time.Now().Format("15:04:05"),
+			err,
+		)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Errorf("failed to close %s webhook response body: %v", n.target.Name, err)
+		}
+	}()
+
+	// Receivers may respond with an explanation of any problems; limit how
+	// much is read just in case.
+	body, readErr := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	// Any 2xx status code is accepted as not all receivers respond with 200.
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if readErr != nil {
+			log.Debugf("failed to read %s webhook response body: %v", n.target.Name, readErr)
+		}
+
+		return fmt.Errorf(
+			"failed to submit CloudEvent to %s at %v: %s: %s",
+			n.target.Name,
+			time.Now().Format("15:04:05"),
+			resp.Status,
+			strings.TrimSpace(string(body)),
+		)
+	}
+
+	return nil
+}
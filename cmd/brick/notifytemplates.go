@@ -0,0 +1,390 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/apex/log"
+	"github.com/atc0005/go-ezproxy"
+
+	"github.com/atc0005/brick/internal/caller"
+	"github.com/atc0005/brick/internal/config"
+	"github.com/atc0005/brick/internal/events"
+	"github.com/atc0005/brick/internal/tmplutils"
+)
+
+// notificationTemplates is the collection of templates used to generate
+// notifications. Optional templates are nil if the built-in content is used
+// instead.
+type notificationTemplates struct {
+
+	// emailSubject is the optional template used to generate the subject of
+	// email notifications.
+	emailSubject *template.Template
+
+	// emailBody is the template used to generate the plain text part of email
+	// notifications.
+	emailBody *template.Template
+
+	// emailHTMLBody is the template used to generate the HTML part of email
+	// notifications.
+	emailHTMLBody *htmltemplate.Template
+
+	// teamsText is the optional template used to generate the summary text
+	// of Microsoft Teams notifications.
+	teamsText *template.Template
+
+	// slackText is the optional template used to generate the summary text
+	// of Slack notifications.
+	slackText *template.Template
+}
+
+// notificationTemplateData is the data used to render notification
+// templates. The same data is provided to the built-in templates and to any
+// user-provided template files.
+type notificationTemplateData struct {
+
+	// Record is the event Record the notification is generated for.
+	Record events.Record
+
+	// EmailSubject is the subject (or title) of the notification.
+	EmailSubject string
+
+	// EmailSummary is the built-in summary text of the notification.
+	EmailSummary string
+
+	// Branding is the message trailer used by text templates.
+	Branding string
+
+	// BrandingHTML is the message trailer used by HTML templates.
+	BrandingHTML htmltemplate.HTML
+}
+
+// newNotificationTemplateData returns the data used to render notification
+// templates for the provided event Record using the provided subject.
+func newNotificationTemplateData(record events.Record, subject string) notificationTemplateData {
+	return notificationTemplateData{
+		Record:       record,
+		EmailSubject: subject,
+
+		// Use this function to generate the summary instead of directly
+		// referencing the Record.Note field; the Record.Note field may not
+		// always have a value (GH-134).
+		EmailSummary: getMsgSummaryText(record),
+		Branding:     config.MessageTrailer(config.BrandingTextileFormat),
+
+		// The branding text is generated by this application from constant
+		// values and is safe to include as-is.
+		BrandingHTML: htmltemplate.HTML(config.MessageTrailer(config.BrandingHTMLFormat)),
+	}
+}
+
+// sampleNotificationRecord returns an event Record with all fields
+// populated. This is used to validate notification templates at startup so
+// that errors are reported then instead of when the first notification is
+// sent.
+func sampleNotificationRecord() events.Record {
+	alert := tmplutils.SampleAlert()
+
+	sampleErr := errors.New("sample error")
+
+	return events.Record{
+		Alert:  alert,
+		Error:  sampleErr,
+		Note:   "sample note",
+		Action: events.ActionFailureTerminatedUserSession,
+		SessionTerminationResults: []ezproxy.TerminateUserSessionResult{
+			{
+				UserSession: tmplutils.SampleUserSession(),
+				ExitCode:    1,
+				StdOut:      "sample output",
+				StdErr:      "sample error output",
+				Error:       sampleErr,
+			},
+		},
+		Steps: []events.Step{
+			{
+				Action: events.ActionSuccessDisabledUsername,
+				Note:   "sample note",
+			},
+			{
+				Action: events.ActionFailureTerminatedUserSession,
+				Note:   "sample note",
+				Error:  sampleErr,
+			},
+		},
+	}
+}
+
+// executableTemplate is satisfied by both text/template and html/template
+// templates.
+type executableTemplate interface {
+	Execute(w io.Writer, data any) error
+}
+
+// readNotificationTemplate returns the text of a template used to generate
+// notifications. If templateFile is specified its contents are returned,
+// otherwise the provided built-in template text is returned.
+func readNotificationTemplate(name string, builtinText string, templateFile string) (string, error) {
+
+	myFuncName := caller.GetFuncName()
+
+	if templateFile == "" {
+		return builtinText, nil
+	}
+
+	log.Debugf("%s: Loading %s from %q", myFuncName, name, templateFile)
+
+	// #nosec G304
+	content, err := os.ReadFile(filepath.Clean(templateFile))
+	if err != nil {
+		return "", fmt.Errorf(
+			"%s: failed to read template file %q for %s: %w",
+			myFuncName,
+			templateFile,
+			name,
+			err,
+		)
+	}
+
+	return string(content), nil
+}
+
+// validateNotificationTemplate executes the provided template against a
+// sample Record to catch errors (e.g., references to fields which do not
+// exist) early.
+func validateNotificationTemplate(name string, tmpl executableTemplate) error {
+
+	myFuncName := caller.GetFuncName()
+
+	sample := sampleNotificationRecord()
+	data := newNotificationTemplateData(sample, getMsgTitle(config.MyAppName+": ", sample))
+	if err := tmpl.Execute(io.Discard, data); err != nil {
+		return fmt.Errorf(
+			"%s: failed to validate %s using sample record: %w",
+			myFuncName,
+			name,
+			err,
+		)
+	}
+
+	return nil
+}
+
+// loadNotificationTemplate parses and validates a template used to generate
+// notifications. If templateFile is specified its contents are used,
+// otherwise the provided built-in template text is used. If neither is
+// provided nil is returned. The parsed template is executed against a sample
+// Record to catch errors (e.g., references to fields which do not exist)
+// early.
+func loadNotificationTemplate(name string, builtinText string, templateFile string) (*template.Template, error) {
+
+	myFuncName := caller.GetFuncName()
+
+	tmplText, err := readNotificationTemplate(name, builtinText, templateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if tmplText == "" {
+		return nil, nil
+	}
+
+	tmpl, err := template.New(name).Funcs(tmplutils.Funcs()).Parse(tmplText)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%s: failed to parse %s: %w",
+			myFuncName,
+			name,
+			err,
+		)
+	}
+
+	if err := validateNotificationTemplate(name, tmpl); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+// loadHTMLNotificationTemplate is the html/template counterpart of
+// loadNotificationTemplate. Values are escaped when the template is
+// executed.
+func loadHTMLNotificationTemplate(name string, builtinText string, templateFile string) (*htmltemplate.Template, error) {
+
+	myFuncName := caller.GetFuncName()
+
+	tmplText, err := readNotificationTemplate(name, builtinText, templateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := htmltemplate.New(name).
+		Funcs(htmltemplate.FuncMap(tmplutils.Funcs())).
+		Parse(tmplText)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%s: failed to parse %s: %w",
+			myFuncName,
+			name,
+			err,
+		)
+	}
+
+	if err := validateNotificationTemplate(name, tmpl); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+// newNotificationTemplates loads and validates the templates used to
+// generate notifications using the provided configuration.
+func newNotificationTemplates(cfg *config.Config) (*notificationTemplates, error) {
+
+	var templates notificationTemplates
+	var err error
+
+	templates.emailSubject, err = loadNotificationTemplate(
+		"email subject template",
+		"",
+		cfg.EmailSubjectTemplateFile(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	emailBodyTemplate := textileEmailTemplate
+	if cfg.EmailBodyTemplate() == config.EmailBodyTemplateMarkdown {
+		emailBodyTemplate = defaultEmailTemplate
+	}
+
+	templates.emailBody, err = loadNotificationTemplate(
+		"email body template",
+		emailBodyTemplate,
+		cfg.EmailBodyTemplateFile(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The HTML part of email messages is rendered from the same data using
+	// the html/template package so that values are escaped.
+	templates.emailHTMLBody, err = loadHTMLNotificationTemplate(
+		"email HTML body template",
+		htmlEmailTemplate,
+		cfg.EmailHTMLBodyTemplateFile(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	templates.teamsText, err = loadNotificationTemplate(
+		"Microsoft Teams text template",
+		"",
+		cfg.TeamsTextTemplateFile(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	templates.slackText, err = loadNotificationTemplate(
+		"Slack text template",
+		"",
+		cfg.SlackTextTemplateFile(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &templates, nil
+}
+
+// renderText renders the provided optional template for the provided event
+// Record. The provided fallback text is returned if the template is nil,
+// fails to render or renders only whitespace.
+func renderText(tmpl *template.Template, record events.Record, subject string, fallback string) string {
+
+	if tmpl == nil {
+		return fallback
+	}
+
+	myFuncName := caller.GetFuncName()
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, newNotificationTemplateData(record, subject)); err != nil {
+		log.Errorf(
+			"%s: failed to render %s; using built-in text: %v",
+			myFuncName,
+			tmpl.Name(),
+			err,
+		)
+		return fallback
+	}
+
+	text := strings.TrimSpace(rendered.String())
+	if text == "" {
+		log.Warnf(
+			"%s: %s rendered empty text; using built-in text",
+			myFuncName,
+			tmpl.Name(),
+		)
+		return fallback
+	}
+
+	return text
+}
+
+// emailSubjectText returns the subject for email notifications generated for the
+// provided event Record. The subject is collapsed to a single line.
+func (t *notificationTemplates) emailSubjectText(record events.Record) string {
+	title := getMsgTitle(config.MyAppName+": ", record)
+	subject := renderText(t.emailSubject, record, title, title)
+
+	return strings.Join(strings.Fields(subject), " ")
+}
+
+// teamsSummaryText returns the summary text for Microsoft Teams notifications
+// generated for the provided event Record.
+func (t *notificationTemplates) teamsSummaryText(record events.Record) string {
+	return renderText(
+		t.teamsText,
+		record,
+		getMsgTitle(config.MyAppName+": ", record),
+		getMsgSummaryText(record),
+	)
+}
+
+// slackSummaryText returns the summary text for Slack notifications generated
+// for the provided event Record.
+func (t *notificationTemplates) slackSummaryText(record events.Record) string {
+	return renderText(
+		t.slackText,
+		record,
+		getMsgTitle(config.MyAppName+": ", record),
+		getMsgSummaryText(record),
+	)
+}
@@ -0,0 +1,190 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/apex/log"
+
+	"github.com/atc0005/brick/internal/config"
+	"github.com/atc0005/brick/internal/files"
+	"github.com/atc0005/brick/internal/health"
+)
+
+// preflightChecks returns the checks performed once at startup, before any
+// requests are accepted. Output files are created if missing (along with any
+// parent directories) and configured ownership is applied. Input files are
+// confirmed to be readable. As with the readiness checks, failed lookups
+// against the ignore files are reported as warnings if the sysadmin opted to
+// disregard lookup errors.
+func preflightChecks(
+	appConfig *config.Config,
+	disabledUsers *files.DisabledUsers,
+	reportedUserEventsLog *files.ReportedUserEventsLog,
+) []health.Check {
+
+	checks := []health.Check{
+		{
+			Name: "disabled_users_file",
+			Run:  disabledUsers.Ensure,
+		},
+	}
+
+	if appConfig.EZproxyUser() != "" {
+		checks = append(checks, health.Check{
+			Name: "disabled_users_file_ezproxy_access",
+			Run: func() error {
+				return disabledUsers.CheckReadableBy(appConfig.EZproxyUser())
+			},
+		})
+	}
+
+	checks = append(checks, health.Check{
+		Name: "reported_users_log_file",
+		Run:  reportedUserEventsLog.Ensure,
+	})
+
+	if appConfig.JournalFile() != "" {
+		journalFile := files.FlatFile{
+			FilePath:        appConfig.JournalFile(),
+			FilePermissions: appConfig.JournalFilePermissions(),
+		}
+		checks = append(checks, health.Check{
+			Name: "journal_file",
+			Run:  journalFile.Ensure,
+		})
+	}
+
+	if appConfig.OutboxFile() != "" {
+		outboxFile := files.FlatFile{
+			FilePath:        appConfig.OutboxFile(),
+			FilePermissions: appConfig.OutboxFilePermissions(),
+		}
+		checks = append(checks, health.Check{
+			Name: "outbox_file",
+			Run:  outboxFile.Ensure,
+		})
+	}
+
+	if appConfig.DedupTTL() > 0 && appConfig.DedupFile() != "" {
+		dedupFile := files.FlatFile{
+			FilePath:        appConfig.DedupFile(),
+			FilePermissions: appConfig.DedupFilePermissions(),
+		}
+		checks = append(checks, health.Check{
+			Name: "dedup_file",
+			Run:  dedupFile.Ensure,
+		})
+	}
+
+	if appConfig.JSONEventsLogFile() != "" {
+		jsonEventsLog := files.FlatFile{
+			FilePath:        appConfig.JSONEventsLogFile(),
+			FilePermissions: appConfig.JSONEventsLogFilePermissions(),
+		}
+		checks = append(checks, health.Check{
+			Name: "json_events_log_file",
+			Run:  jsonEventsLog.Ensure,
+		})
+	}
+
+	if appConfig.SIEMFile() != "" {
+		siemFile := files.FlatFile{
+			FilePath:        appConfig.SIEMFile(),
+			FilePermissions: appConfig.SIEMFilePermissions(),
+		}
+		checks = append(checks, health.Check{
+			Name: "siem_file",
+			Run:  siemFile.Ensure,
+		})
+	}
+
+	checks = append(checks,
+		health.Check{
+			Name:     "ignored_users_file",
+			Optional: appConfig.IgnoreLookupErrors(),
+			Run:      func() error { return health.FileReadable(appConfig.IgnoredUsersFile()) },
+		},
+		health.Check{
+			Name:     "ignored_ip_addresses_file",
+			Optional: appConfig.IgnoreLookupErrors(),
+			Run:      func() error { return health.FileReadable(appConfig.IgnoredIPAddressesFile()) },
+		},
+	)
+
+	if appConfig.UsernameAliasFile() != "" {
+		checks = append(checks, health.Check{
+			Name: "username_alias_file",
+			Run:  func() error { return health.FileReadable(appConfig.UsernameAliasFile()) },
+		})
+	}
+
+	if appConfig.NotifyEmail() {
+		if appConfig.EmailAuthMechanism() != config.EmailAuthNone {
+			checks = append(checks, health.Check{
+				Name: "email_password_file",
+				Run: func() error {
+					_, err := readPasswordFile(appConfig.EmailPasswordFile())
+					return err
+				},
+			})
+		}
+
+		if appConfig.EmailCAFile() != "" {
+			checks = append(checks, health.Check{
+				Name: "email_ca_file",
+				Run: func() error {
+					_, err := loadCertPool(appConfig.EmailCAFile())
+					return err
+				},
+			})
+		}
+	}
+
+	checks = append(checks, health.Check{
+		Name:     "ezproxy_active_file",
+		Optional: !appConfig.EZproxyTerminateSessions(),
+		Run:      func() error { return health.ActiveFile(appConfig.EZproxyActiveFilePath()) },
+	})
+
+	if appConfig.EZproxyTerminateSessions() {
+		checks = append(checks, health.Check{
+			Name: "ezproxy_executable",
+			Run:  func() error { return health.Executable(appConfig.EZproxyExecutablePath()) },
+		})
+	}
+
+	return checks
+}
+
+// logPreflightReport logs the result of each startup preflight check. All
+// failed checks are logged so that each problem can be corrected before the
+// application is started again.
+func logPreflightReport(report health.Report) {
+
+	for _, result := range report.Checks {
+		ctxLog := log.WithField("check", result.Name)
+
+		switch result.Status {
+		case health.StatusOK:
+			ctxLog.Debug("preflight check passed")
+		case health.StatusWarn:
+			ctxLog.Warnf("optional preflight check failed: %s", result.Error)
+		default:
+			ctxLog.Errorf("preflight check failed: %s", result.Error)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/atc0005/go-ezproxy"
+
+	"github.com/atc0005/brick/internal/config"
+	"github.com/atc0005/brick/internal/events"
+	"github.com/atc0005/brick/internal/files"
+	"github.com/atc0005/brick/internal/usernames"
+)
+
+// Placeholder values used by the preview subcommand in place of details
+// which are only known when an alert is received or which have not been
+// configured.
+const (
+	previewPayloadSenderIP  string = "127.0.0.1"
+	previewUsername         string = "preview-user"
+	previewUserIP           string = "192.0.2.10"
+	previewAlertName        string = "preview alert"
+	previewSenderAddress    string = "brick@example.com"
+	previewRecipientAddress string = "recipient@example.com"
+	previewSessionID        string = "preview-session-id"
+)
+
+// Names of the files written by the preview subcommand if an output
+// directory is specified.
+const (
+	previewEmailFilename string = "email.eml"
+	previewTeamsFilename string = "teams.json"
+	previewSlackFilename string = "slack.json"
+)
+
+// errPreviewFailure is the error noted by event Records created by the
+// preview subcommand for simulated failure actions.
+var errPreviewFailure = errors.New("simulated failure")
+
+// previewValidator is a notification message which provides validation of
+// its format. Messages are validated in the same way before they are sent.
+type previewValidator interface {
+	Validate() error
+}
+
+// previewAlert reads the sample alert payload from the provided file and
+// creates an alert in the same way as the disable user handler does for a
+// received payload. Details of the request, along with any username, source
+// IP or alert name missing from the payload, are replaced by placeholder
+// values.
+func previewAlert(appConfig *config.Config, payloadFile string) (events.SplunkAlertEvent, error) {
+
+	// #nosec G304
+	requestBody, err := os.ReadFile(filepath.Clean(payloadFile))
+	if err != nil {
+		return events.SplunkAlertEvent{}, fmt.Errorf(
+			"failed to read sample alert payload file %q: %w",
+			payloadFile,
+			err,
+		)
+	}
+
+	var payloadV2 events.SplunkAlertPayloadV2
+	if err := json.Unmarshal(requestBody, &payloadV2); err != nil {
+		return events.SplunkAlertEvent{}, fmt.Errorf(
+			"failed to decode sample alert payload file %q: %w",
+			payloadFile,
+			err,
+		)
+	}
+
+	// Payloads which would be rejected are still rendered so that sample
+	// payloads from other sources (e.g., the Splunk webhook documentation)
+	// may be used. Placeholder values are used for the fields included in
+	// notifications.
+	if err := events.ValidatePayload(payloadV2); err != nil {
+		log.Warnf("sample alert payload would be rejected: %v", err)
+	}
+
+	if payloadV2.Result.Username == "" {
+		payloadV2.Result.Username = previewUsername
+	}
+	if payloadV2.Result.SourceIP == "" {
+		payloadV2.Result.SourceIP = previewUserIP
+	}
+	if payloadV2.SearchName == "" {
+		payloadV2.SearchName = previewAlertName
+	}
+
+	usernameNormalizer, err := usernames.NewNormalizer(
+		appConfig.UsernameLowercase(),
+		appConfig.UsernameStripRealm(),
+		appConfig.UsernameStripDomain(),
+		appConfig.UsernameAliasFile(),
+	)
+	if err != nil {
+		return events.SplunkAlertEvent{}, fmt.Errorf(
+			"failed to initialize username normalization: %w",
+			err,
+		)
+	}
+
+	return newAlertEvent(payloadV2, usernameNormalizer, alertRequest{
+		received: time.Now(),
+		senderIP: previewPayloadSenderIP,
+		path:     apiV1DisableUserEndpointPattern,
+		method:   http.MethodPost,
+		headers:  http.Header{"Content-Type": {"application/json"}},
+		body:     requestBody,
+	})
+}
+
+// previewRecord creates an event Record for the provided alert and simulated
+// Action. The note and error of the event Record match those recorded when
+// the same Action is taken while processing a received alert.
+func previewRecord(appConfig *config.Config, alert events.SplunkAlertEvent, action string) events.Record {
+
+	// Errors noted while updating the reported user events log are the most
+	// common failures for the actions which log an event.
+	logFileErr := files.EventsLogError(
+		appConfig.ReportedUsersLogFile(),
+		errPreviewFailure,
+	)
+
+	var recordErr error
+	var note string
+	var terminationResults []ezproxy.TerminateUserSessionResult
+
+	switch action {
+	case events.ActionSuccessDisableRequestReceived,
+		events.ActionFailureDisableRequestReceived:
+		note = files.DisableRequestReceivedNote(alert)
+
+	case events.ActionSuccessDisabledUsername,
+		events.ActionFailureDisabledUsername:
+		note = files.DisabledUsernameNote(alert)
+
+	case events.ActionSuccessDuplicatedUsername,
+		events.ActionFailureDuplicatedUsername:
+		note = files.UsernameAlreadyDisabledNote(alert)
+
+	case events.ActionSuccessIgnoredUsername,
+		events.ActionFailureIgnoredUsername:
+		note = files.IgnoredNote(alert, appConfig.IgnoredUsersFile())
+
+	case events.ActionSuccessIgnoredIPAddress,
+		events.ActionFailureIgnoredIPAddress:
+		note = files.IgnoredNote(alert, appConfig.IgnoredIPAddressesFile())
+
+	case events.ActionSkippedStaleAlert:
+		alert.StaleReason = staleAlertReason(
+			alert.Latency,
+			time.Duration(appConfig.StaleAlertMaxAge())*time.Minute,
+		)
+		note = files.StaleAlertNote(alert)
+
+	case events.ActionSkippedTerminateUserSessions:
+		note = files.SkippedSessionsNote([]string{previewSessionID})
+
+	case events.ActionFailureUserSessionLookupFailure:
+		recordErr = files.SessionLookupError(alert, errPreviewFailure)
+
+	case events.ActionSuccessTerminatedUserSession:
+		terminationResults = previewTerminationResults(alert, nil)
+		note = files.TerminationSuccessNote(alert, len(terminationResults))
+
+	case events.ActionFailureTerminatedUserSession:
+		terminationResults = previewTerminationResults(alert, errPreviewFailure)
+		note = files.TerminationFailureNote(
+			alert,
+			len(terminationResults),
+			len(terminationResults),
+		)
+		recordErr = files.TerminationFailureError([]string{previewSessionID})
+	}
+
+	if recordErr == nil && (events.Record{Action: action}).Failed() {
+		recordErr = logFileErr
+	}
+
+	return events.NewRecord(alert, recordErr, note, action, terminationResults)
+}
+
+// previewTerminationResults returns the session termination results used by
+// the preview subcommand for the provided alert. The provided error is noted
+// by each result.
+func previewTerminationResults(alert events.SplunkAlertEvent, err error) []ezproxy.TerminateUserSessionResult {
+
+	result := ezproxy.TerminateUserSessionResult{
+		UserSession: ezproxy.UserSession{
+			SessionID: previewSessionID,
+			IPAddress: alert.UserIP,
+			Username:  alert.Username,
+		},
+		Error: err,
+	}
+
+	if err != nil {
+		result.ExitCode = 1
+		result.StdErr = err.Error()
+	}
+
+	return []ezproxy.TerminateUserSessionResult{result}
+}
+
+// previewEmailMessage renders the email message generated for the provided
+// event Record using the configured email settings. Placeholder addresses
+// are used if sender or recipient addresses are not configured.
+func previewEmailMessage(appConfig *config.Config, templates *notificationTemplates, record events.Record) (string, error) {
+
+	notifier, _ := newEmailNotifier(appConfig, templates)
+
+	emailCfg := notifier.(emailNotifier).emailCfg
+	if emailCfg.senderAddress == "" {
+		emailCfg.senderAddress = previewSenderAddress
+	}
+	if len(emailCfg.recipientAddresses) == 0 {
+		emailCfg.recipientAddresses = []string{previewRecipientAddress}
+	}
+
+	return createEmailMessage(record, emailCfg)
+}
+
+// previewTeamsMessage renders the Microsoft Teams message generated for the
+// provided event Record as JSON. The message format of the first configured
+// Microsoft Teams webhook URL is used or, if none are configured, the
+// configured message format with MessageCards used for the auto format.
+func previewTeamsMessage(appConfig *config.Config, templates *notificationTemplates, record events.Record) ([]byte, error) {
+
+	messageFormat := appConfig.TeamsMessageFormat()
+	if targets := appConfig.TeamsTargets(); len(targets) > 0 {
+		messageFormat = targets[0].MessageFormat
+	}
+
+	var msg previewValidator
+	switch messageFormat {
+	case config.TeamsMessageFormatAdaptiveCard:
+		card, err := createTeamsAdaptiveCard(record, templates.teamsSummaryText(record))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Adaptive Card: %w", err)
+		}
+		msg = card
+
+	default:
+		msg = createTeamsMessage(record, templates.teamsSummaryText(record))
+	}
+
+	if err := msg.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to validate Microsoft Teams message: %w", err)
+	}
+
+	return json.MarshalIndent(msg, "", "\t")
+}
+
+// previewSlackMessage renders the Slack message generated for the provided
+// event Record as JSON.
+func previewSlackMessage(templates *notificationTemplates, record events.Record) ([]byte, error) {
+	return json.MarshalIndent(
+		createSlackMessage(record, templates.slackSummaryText(record)),
+		"",
+		"\t",
+	)
+}
+
+// writePreview writes the provided rendered notification to the named file
+// within the provided output directory or, if not specified, to the provided
+// io.Writer preceded by a header noting the notification type.
+func writePreview(w io.Writer, outputDir string, filename string, content []byte) error {
+
+	if outputDir == "" {
+		_, err := fmt.Fprintf(w, "==> %s <==\n%s\n\n", filename, strings.TrimRight(string(content), "\n"))
+		return err
+	}
+
+	outputFile := filepath.Join(outputDir, filename)
+	if err := os.WriteFile(outputFile, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write %q: %w", outputFile, err)
+	}
+
+	_, err := fmt.Fprintf(w, "Wrote %s\n", outputFile)
+	return err
+}
+
+// runPreviewCommand renders the notifications generated for the sample alert
+// payload and simulated action specified for the preview subcommand and
+// writes them to the provided io.Writer or to the specified output
+// directory. Nothing is sent.
+func runPreviewCommand(appConfig *config.Config, w io.Writer) error {
+
+	action, _ := events.ActionByName(appConfig.PreviewCommandAction())
+
+	alert, err := previewAlert(appConfig, appConfig.PreviewCommandPayloadFile())
+	if err != nil {
+		return err
+	}
+
+	templates, err := newNotificationTemplates(appConfig)
+	if err != nil {
+		return fmt.Errorf("failed to initialize notification templates: %w", err)
+	}
+
+	record := previewRecord(appConfig, alert, action)
+
+	outputDir := appConfig.PreviewCommandOutputDir()
+	if outputDir != "" {
+		if err := os.MkdirAll(outputDir, 0o750); err != nil {
+			return fmt.Errorf("failed to create output directory %q: %w", outputDir, err)
+		}
+	}
+
+	notifier := appConfig.PreviewCommandNotifier()
+
+	if notifier == config.PreviewNotifierAll || notifier == config.PreviewNotifierEmail {
+		emailMsg, err := previewEmailMessage(appConfig, templates, record)
+		if err != nil {
+			return fmt.Errorf("failed to create email message: %w", err)
+		}
+
+		if err := writePreview(w, outputDir, previewEmailFilename, []byte(emailMsg)); err != nil {
+			return err
+		}
+	}
+
+	if notifier == config.PreviewNotifierAll || notifier == config.PreviewNotifierTeams {
+		teamsMsg, err := previewTeamsMessage(appConfig, templates, record)
+		if err != nil {
+			return err
+		}
+
+		if err := writePreview(w, outputDir, previewTeamsFilename, teamsMsg); err != nil {
+			return err
+		}
+	}
+
+	if notifier == config.PreviewNotifierAll || notifier == config.PreviewNotifierSlack {
+		slackMsg, err := previewSlackMessage(templates, record)
+		if err != nil {
+			return fmt.Errorf("failed to create Slack message: %w", err)
+		}
+
+		if err := writePreview(w, outputDir, previewSlackFilename, slackMsg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
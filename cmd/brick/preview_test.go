@@ -0,0 +1,155 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/atc0005/brick/internal/config"
+	"github.com/atc0005/brick/internal/events"
+)
+
+// emailParts returns the decoded content of each non-multipart part of the
+// provided email message, keyed by media type.
+func emailParts(t *testing.T, message string) map[string]string {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(strings.NewReader(message))
+	if err != nil {
+		t.Fatalf("failed to parse email message: %v", err)
+	}
+
+	parts := make(map[string]string)
+
+	var walk func(contentType string, body io.Reader)
+	walk = func(contentType string, body io.Reader) {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			t.Fatalf("failed to parse content type %q: %v", contentType, err)
+		}
+
+		if !strings.HasPrefix(mediaType, "multipart/") {
+			content, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("failed to read %s part: %v", mediaType, err)
+			}
+			parts[mediaType] = string(content)
+			return
+		}
+
+		// Quoted-printable parts are decoded by the multipart reader.
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to read %s message part: %v", mediaType, err)
+			}
+			walk(part.Header.Get("Content-Type"), part)
+		}
+	}
+	walk(msg.Header.Get("Content-Type"), msg.Body)
+
+	return parts
+}
+
+func TestPreviewEmailMessage(t *testing.T) {
+	appConfig := &config.Config{}
+
+	alert, err := previewAlert(appConfig, "../../contrib/tests/splunk-test-submission.json")
+	if err != nil {
+		t.Fatalf("previewAlert failed: %v", err)
+	}
+
+	// The alert is created in the same way as for a received payload, with
+	// placeholder values for the details of the request and for the values
+	// missing from the sample payload.
+	for _, field := range []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"Username", alert.Username, previewUsername},
+		{"UserIP", alert.UserIP, previewUserIP},
+		{"PayloadSenderIP", alert.PayloadSenderIP, previewPayloadSenderIP},
+		{"AlertName", alert.AlertName, previewAlertName},
+		{"SearchID", alert.SearchID, "scheduler_admin_search_W2_at_14232356_132"},
+		{"EndpointPath", alert.EndpointPath, apiV1DisableUserEndpointPattern},
+		{"HTTPMethod", alert.HTTPMethod, "POST"},
+	} {
+		if field.got != field.want {
+			t.Errorf("got alert %s %q, want %q", field.name, field.got, field.want)
+		}
+	}
+
+	templates, err := newNotificationTemplates(appConfig)
+	if err != nil {
+		t.Fatalf("failed to load notification templates: %v", err)
+	}
+
+	record := previewRecord(appConfig, alert, events.ActionSuccessDisabledUsername)
+
+	message, err := previewEmailMessage(appConfig, templates, record)
+	if err != nil {
+		t.Fatalf("previewEmailMessage failed: %v", err)
+	}
+
+	parts := emailParts(t, message)
+
+	tests := []struct {
+		mediaType string
+		want      []string
+	}{
+		{
+			mediaType: "text/plain",
+			want: []string{
+				"**Alert Request Headers**",
+				"| Content-Type | application/json |",
+				"| Username          | " + previewUsername + " |",
+			},
+		},
+		{
+			mediaType: "text/html",
+			want: []string{
+				"<h3>Alert Request Headers</h3>",
+				`<tr><th align="left">Content-Type</th><td>application/json</td></tr>`,
+				`<tr><th align="left">Username</th><td>` + previewUsername + `</td></tr>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		content, ok := parts[tt.mediaType]
+		if !ok {
+			t.Errorf("email message has no %s part", tt.mediaType)
+			continue
+		}
+
+		for _, want := range tt.want {
+			if !strings.Contains(content, want) {
+				t.Errorf("%s part does not include %q:\n%s", tt.mediaType, want, content)
+			}
+		}
+	}
+}
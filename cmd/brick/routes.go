@@ -0,0 +1,200 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"path"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/atc0005/brick/internal/config"
+	"github.com/atc0005/brick/internal/events"
+	"github.com/atc0005/brick/internal/metrics"
+)
+
+// routeTarget is a notification service selected to receive an event Record
+// along with any recipients used in place of the default recipients for the
+// notification service.
+type routeTarget struct {
+	service    string
+	recipients []string
+}
+
+// matchAny indicates whether the provided value matches any of the provided
+// path.Match patterns. An empty list of patterns matches any value.
+func matchAny(patterns []string, value string) bool {
+
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		// patterns are checked during config validation
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchActions indicates whether the short name of any Action noted by the
+// provided event Record, including the steps of a consolidated event Record,
+// matches any of the provided path.Match patterns.
+func matchActions(patterns []string, record events.Record) bool {
+
+	for _, action := range record.Actions() {
+		if matchAny(patterns, events.ActionName(action)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchRoute indicates whether the provided event Record matches all
+// criteria of the provided routing rule.
+func matchRoute(route config.NotificationRoute, record events.Record) bool {
+
+	switch {
+	case route.Outcome == config.RouteOutcomeSuccess && record.Failed():
+		return false
+	case route.Outcome == config.RouteOutcomeFailure && !record.Failed():
+		return false
+	}
+
+	return matchActions(route.Actions, record) &&
+		matchAny(route.AlertNames, record.Alert.AlertName) &&
+		matchAny(route.Usernames, record.Alert.Username)
+}
+
+// routeRecord evaluates the provided routing rules and returns the
+// notification services (from those provided) which should receive the
+// event Record. If no routing rules are provided, all notification services
+// are returned. Email recipients listed by matching routing rules are
+// combined; the default recipients are included if any matching routing rule
+// selecting email notifications does not list recipients.
+func routeRecord(
+	routes []config.NotificationRoute,
+	serviceNames []string,
+	defaultRecipients []string,
+	record events.Record,
+) []routeTarget {
+
+	targets := make([]routeTarget, 0, len(serviceNames))
+
+	if len(routes) == 0 {
+		for _, service := range serviceNames {
+			targets = append(targets, routeTarget{service: service})
+		}
+		return targets
+	}
+
+	var matched []config.NotificationRoute
+	for _, route := range routes {
+		if matchRoute(route, record) {
+			log.Debugf("routeRecord: %q event matches routing rule %q", record.Action, route.Name)
+			matched = append(matched, route)
+		}
+	}
+
+	for _, service := range serviceNames {
+
+		var selected, useDefault bool
+		var overrides []string
+
+		for _, route := range matched {
+			if !matchAny(route.Notifiers, service) {
+				continue
+			}
+
+			selected = true
+
+			if service != metrics.ServiceEmail {
+				continue
+			}
+
+			switch {
+			case len(route.EmailRecipients) == 0:
+				useDefault = true
+			default:
+				overrides = append(overrides, route.EmailRecipients...)
+			}
+		}
+
+		if !selected {
+			continue
+		}
+
+		target := routeTarget{service: service}
+
+		if len(overrides) > 0 {
+			if useDefault {
+				overrides = append(overrides, defaultRecipients...)
+			}
+			target.recipients = uniqueAddresses(overrides)
+		}
+
+		targets = append(targets, target)
+	}
+
+	return targets
+}
+
+// uniqueAddresses returns the provided email addresses in their original
+// order with duplicates (ignoring case) removed.
+func uniqueAddresses(addresses []string) []string {
+
+	seen := make(map[string]struct{}, len(addresses))
+	unique := make([]string, 0, len(addresses))
+
+	for _, address := range addresses {
+		key := strings.ToLower(address)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		unique = append(unique, address)
+	}
+
+	return unique
+}
+
+// checkRoutes logs a warning for each notifier listed by the provided
+// routing rules which does not match any of the provided notification
+// services. This is usually the result of a typo or a notifier which is not
+// enabled.
+func checkRoutes(routes []config.NotificationRoute, serviceNames []string) {
+	for _, route := range routes {
+		for _, pattern := range route.Notifiers {
+			var matched bool
+			for _, service := range serviceNames {
+				if ok, _ := path.Match(pattern, service); ok {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				log.Warnf(
+					"NotifyMgr: notifier %q listed by routing rule %q does not match any enabled notifier",
+					pattern,
+					route.Name,
+				)
+			}
+		}
+	}
+}
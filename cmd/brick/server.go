@@ -47,6 +47,36 @@ func shutdownListener(_ context.Context, quit <-chan os.Signal, parentContextCan
 
 }
 
+// reopenListener listens for a reopen signal (SIGHUP) and calls the provided
+// function to reopen log files. The application log file is the only log
+// file held open; the other log files written to by this application are
+// opened for each write and require no action. The journal, duplicate alert
+// cache and outbox files are also held open, but are not log files: they are
+// compacted by this application and are NOT reopened on SIGHUP, so they must
+// not be rotated (e.g., by logrotate). This function is intended to be run as
+// a goroutine and returns once the provided context is cancelled.
+func reopenListener(ctx context.Context, reopen <-chan os.Signal, reopenLogFiles func() error) {
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debug("reopenListener: context is done, returning")
+			return
+
+		case osSignal := <-reopen:
+			log.Debugf("reopenListener: Received reopen signal: %v", osSignal)
+
+			if err := reopenLogFiles(); err != nil {
+				log.Errorf("reopenListener: failed to reopen log files: %s", err)
+				continue
+			}
+
+			log.Info("Reopened log files")
+		}
+	}
+
+}
+
 // gracefullShutdown listens for a context cancellation and then shuts down
 // the running http server. Once the http server is shutdown, this function
 // signals back that work is complete by closing the provided done channel.
@@ -0,0 +1,260 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/atc0005/go-ezproxy"
+)
+
+// slackWebhookStub starts a Slack incoming webhook stub which records the
+// last request and responds using the provided status code and body. The
+// stub is stopped when the test completes.
+func slackWebhookStub(t *testing.T, status int, body string) (*httptest.Server, *http.Request, *[]byte) {
+	t.Helper()
+
+	var lastRequest http.Request
+	var lastBody []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request body: %v", err)
+		}
+		lastRequest = *r
+		lastBody = content
+
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &lastRequest, &lastBody
+}
+
+// checkSlackBlocks confirms that the provided message respects the Block Kit
+// limits enforced by Slack.
+func checkSlackBlocks(t *testing.T, msg slackMessage) {
+	t.Helper()
+
+	if got := len(msg.Blocks); got > slackMaxBlocks {
+		t.Errorf("got %d blocks, want at most %d", got, slackMaxBlocks)
+	}
+
+	for i, block := range msg.Blocks {
+		switch block.Type {
+		case "header":
+			if block.Text == nil || block.Text.Type != "plain_text" {
+				t.Errorf("block %d: header text must be plain_text: %+v", i, block.Text)
+			}
+			if block.Text != nil && utf8.RuneCountInString(block.Text.Text) > slackHeaderMaxLength {
+				t.Errorf("block %d: header text exceeds %d characters", i, slackHeaderMaxLength)
+			}
+
+		case "section":
+			if block.Text == nil && len(block.Fields) == 0 {
+				t.Errorf("block %d: section has neither text nor fields", i)
+			}
+			if block.Text != nil && utf8.RuneCountInString(block.Text.Text) > slackTextMaxLength {
+				t.Errorf("block %d: section text exceeds %d characters", i, slackTextMaxLength)
+			}
+			if len(block.Fields) > slackSectionMaxFields {
+				t.Errorf("block %d: got %d fields, want at most %d", i, len(block.Fields), slackSectionMaxFields)
+			}
+			for j, field := range block.Fields {
+				if field.Type != "mrkdwn" {
+					t.Errorf("block %d field %d: got type %q, want mrkdwn", i, j, field.Type)
+				}
+				if utf8.RuneCountInString(field.Text) > slackFieldMaxLength {
+					t.Errorf("block %d field %d: text exceeds %d characters", i, j, slackFieldMaxLength)
+				}
+			}
+
+		case "divider":
+			if block.Text != nil || len(block.Fields) > 0 || len(block.Elements) > 0 {
+				t.Errorf("block %d: divider has content", i)
+			}
+
+		case "context":
+			if len(block.Elements) == 0 {
+				t.Errorf("block %d: context has no elements", i)
+			}
+
+		default:
+			t.Errorf("block %d: unexpected block type %q", i, block.Type)
+		}
+	}
+
+	if len(msg.Blocks) < 2 {
+		t.Fatalf("got %d blocks, want at least 2", len(msg.Blocks))
+	}
+	if msg.Blocks[0].Type != "header" {
+		t.Errorf("first block: got type %q, want header", msg.Blocks[0].Type)
+	}
+	if last := msg.Blocks[len(msg.Blocks)-1]; last.Type != "context" {
+		t.Errorf("last block: got type %q, want context (branding)", last.Type)
+	}
+}
+
+func TestSlackNotifierSend(t *testing.T) {
+	server, req, body := slackWebhookStub(t, http.StatusOK, "ok")
+
+	notifier := slackNotifier{
+		webhookURL: server.URL,
+		client:     server.Client(),
+		templates:  &notificationTemplates{},
+	}
+
+	record := sampleNotificationRecord()
+	if err := notifier.Send(context.Background(), record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if req.Method != http.MethodPost {
+		t.Errorf("got method %q, want %q", req.Method, http.MethodPost)
+	}
+	if got := req.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("got Content-Type %q, want application/json", got)
+	}
+
+	// Confirm the wire format using generic JSON values so that field names
+	// are checked independently of the struct tags.
+	var payload struct {
+		Text   string                   `json:"text"`
+		Blocks []map[string]interface{} `json:"blocks"`
+	}
+	if err := json.Unmarshal(*body, &payload); err != nil {
+		t.Fatalf("failed to decode payload %s: %v", *body, err)
+	}
+	if payload.Text == "" {
+		t.Error("fallback text is empty")
+	}
+	if len(payload.Blocks) == 0 {
+		t.Fatal("payload has no blocks")
+	}
+	for i, block := range payload.Blocks {
+		if _, ok := block["type"].(string); !ok {
+			t.Errorf("block %d has no type: %v", i, block)
+		}
+	}
+
+	var msg slackMessage
+	if err := json.Unmarshal(*body, &msg); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	checkSlackBlocks(t, msg)
+
+	summary := msg.Blocks[1]
+	if summary.Type != "section" || summary.Text == nil || summary.Text.Type != "mrkdwn" {
+		t.Fatalf("second block: got %+v, want mrkdwn summary section", summary)
+	}
+	if want := getMsgSummaryText(record); summary.Text.Text != slackEscape(want) {
+		t.Errorf("summary: got %q, want %q", summary.Text.Text, want)
+	}
+}
+
+func TestSlackNotifierSendError(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+	}{
+		{status: http.StatusBadRequest, body: "invalid_blocks\n"},
+		{status: http.StatusForbidden, body: "invalid_token"},
+		{status: http.StatusNotFound, body: "no_service"},
+		{status: http.StatusInternalServerError, body: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server, _, _ := slackWebhookStub(t, tt.status, tt.body)
+
+			notifier := slackNotifier{
+				webhookURL: server.URL,
+				client:     server.Client(),
+				templates:  &notificationTemplates{},
+			}
+
+			err := notifier.Send(context.Background(), sampleNotificationRecord())
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+
+			wantStatus := fmt.Sprintf("%d %s", tt.status, http.StatusText(tt.status))
+			if !strings.Contains(err.Error(), wantStatus) {
+				t.Errorf("error %q does not include status %q", err, wantStatus)
+			}
+			if want := strings.TrimSpace(tt.body); !strings.HasSuffix(err.Error(), ": "+want) {
+				t.Errorf("error %q does not end with response body %q", err, want)
+			}
+		})
+	}
+}
+
+func TestCreateSlackMessageEscaping(t *testing.T) {
+	record := sampleNotificationRecord()
+	record.Alert.Username = "<@U123> & co"
+
+	msg := createSlackMessage(record, "summary <!channel> & more")
+	checkSlackBlocks(t, msg)
+
+	if got, want := msg.Blocks[1].Text.Text, "summary &lt;!channel&gt; &amp; more"; got != want {
+		t.Errorf("summary: got %q, want %q", got, want)
+	}
+
+	var found bool
+	for _, block := range msg.Blocks {
+		for _, field := range block.Fields {
+			if strings.Contains(field.Text, "<@U123>") {
+				t.Errorf("unescaped username found in field %q", field.Text)
+			}
+			if field.Text == "*Username*\n`&lt;@U123&gt; &amp; co`" {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Error("escaped username field not found in message")
+	}
+}
+
+func TestCreateSlackMessageLimits(t *testing.T) {
+	record := sampleNotificationRecord()
+	record.Note = strings.Repeat("n", 2*slackTextMaxLength)
+
+	for i := 0; i < 300; i++ {
+		record.Alert.Headers.Add(fmt.Sprintf("X-Header-%03d", i), strings.Repeat("v", slackFieldMaxLength))
+		record.SessionTerminationResults = append(record.SessionTerminationResults, ezproxy.TerminateUserSessionResult{
+			UserSession: ezproxy.UserSession{SessionID: fmt.Sprintf("session-%03d", i)},
+		})
+	}
+
+	msg := createSlackMessage(record, record.Note)
+	checkSlackBlocks(t, msg)
+
+	if len(msg.Blocks) != slackMaxBlocks {
+		t.Errorf("got %d blocks, want %d", len(msg.Blocks), slackMaxBlocks)
+	}
+}
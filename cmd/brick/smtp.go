@@ -0,0 +1,165 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"os"
+	"strings"
+
+	"github.com/atc0005/brick/internal/config"
+)
+
+// loginAuth implements the (non-standard, but widely supported) LOGIN
+// authentication mechanism. The standard library provides PLAIN and CRAM-MD5
+// implementations only.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+// Start begins an authentication with a server. As with the PLAIN
+// implementation from the standard library, credentials are only sent over
+// encrypted connections or to the local host.
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+
+	return "LOGIN", nil, nil
+}
+
+// Next continues the authentication by answering the username and password
+// challenges from the server.
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	challenge := strings.ToLower(strings.TrimSpace(string(fromServer)))
+	switch {
+	case strings.HasPrefix(challenge, "username"):
+		return []byte(a.username), nil
+	case strings.HasPrefix(challenge, "password"):
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %q", fromServer)
+	}
+}
+
+// isLocalhost indicates whether the given SMTP server name refers to the
+// local host.
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
+
+// readPasswordFile returns the password stored in the given file. Trailing
+// newlines (commonly added by editors) are removed. An error is returned if
+// the file cannot be read or is empty.
+func readPasswordFile(filename string) (string, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password file %q: %w", filename, err)
+	}
+
+	password := strings.TrimRight(string(content), "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("password file %q is empty", filename)
+	}
+
+	return password, nil
+}
+
+// loadCertPool returns a certificate pool containing the PEM encoded
+// certificates from the given bundle file. An error is returned if the file
+// cannot be read or does not contain any certificates.
+func loadCertPool(filename string) (*x509.CertPool, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle %q: %w", filename, err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bytes.TrimSpace(content)) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %q", filename)
+	}
+
+	return pool, nil
+}
+
+// emailTLSConfig returns the TLS configuration used for implicit TLS or
+// STARTTLS connections to the SMTP server. The system certificate pool is
+// used unless a CA bundle is specified.
+func emailTLSConfig(emailCfg emailConfig) (*tls.Config, error) {
+	tlsCfg := tls.Config{
+		ServerName: emailCfg.server,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if emailCfg.caFile != "" {
+		pool, err := loadCertPool(emailCfg.caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	return &tlsCfg, nil
+}
+
+// emailAuth returns the SMTP authentication implementation for the
+// configured mechanism or nil if authentication is disabled. The password
+// file is read for each call so that the password can be rotated without
+// restarting this application.
+func emailAuth(emailCfg emailConfig) (smtp.Auth, error) {
+	if emailCfg.authMechanism == "" || emailCfg.authMechanism == config.EmailAuthNone {
+		return nil, nil
+	}
+
+	password, err := readPasswordFile(emailCfg.passwordFile)
+	if err != nil {
+		return nil, err
+	}
+
+	switch emailCfg.authMechanism {
+	case config.EmailAuthPlain:
+		return smtp.PlainAuth("", emailCfg.username, password, emailCfg.server), nil
+	case config.EmailAuthLogin:
+		return &loginAuth{
+			username: emailCfg.username,
+			password: password,
+			host:     emailCfg.server,
+		}, nil
+	case config.EmailAuthCRAMMD5:
+		return smtp.CRAMMD5Auth(emailCfg.username, password), nil
+	default:
+		return nil, fmt.Errorf(
+			"unsupported email authentication mechanism: %q",
+			emailCfg.authMechanism,
+		)
+	}
+}
@@ -0,0 +1,687 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/atc0005/brick/internal/config"
+)
+
+const (
+	testSMTPUsername string = "brick"
+	testSMTPPassword string = "s3cret"
+	testSMTPMessage  string = "Subject: test\r\n\r\ntest message\r\n"
+)
+
+// smtpStub is a minimal in-process SMTP server used to exercise the
+// connection, TLS and authentication handling of sendEmail.
+type smtpStub struct {
+	listener net.Listener
+	tlsCfg   *tls.Config
+
+	// startTLS controls whether the STARTTLS extension is advertised.
+	startTLS bool
+
+	// implicitTLS controls whether TLS is negotiated on connect.
+	implicitTLS bool
+
+	// auth controls whether the AUTH extension is advertised.
+	auth bool
+
+	mu       sync.Mutex
+	tlsUsed  bool
+	authUsed string
+	messages []string
+}
+
+// newTestCertificate returns a self-signed certificate for the local host
+// and the path to a CA bundle file containing it.
+func newTestCertificate(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	tmpl := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "brick test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(caFile, certPEM, 0o600); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, caFile
+}
+
+// newSMTPStub starts an SMTP stub using the provided certificate. The stub is
+// stopped when the test completes.
+func newSMTPStub(t *testing.T, cert tls.Certificate, startTLS bool, implicitTLS bool, auth bool) *smtpStub {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start SMTP stub: %v", err)
+	}
+
+	stub := smtpStub{
+		listener:    listener,
+		tlsCfg:      &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+		startTLS:    startTLS,
+		implicitTLS: implicitTLS,
+		auth:        auth,
+	}
+
+	go stub.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+
+	return &stub
+}
+
+// port returns the port the stub is listening on.
+func (s *smtpStub) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStub) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStub) handle(conn net.Conn) {
+	defer conn.Close()
+
+	encrypted := false
+	if s.implicitTLS {
+		conn = tls.Server(conn, s.tlsCfg)
+		encrypted = true
+		s.mu.Lock()
+		s.tlsUsed = true
+		s.mu.Unlock()
+	}
+
+	tp := textproto.NewConn(conn)
+	reply := func(format string, args ...interface{}) {
+		_ = tp.PrintfLine(format, args...)
+	}
+
+	reply("220 brick-test ESMTP")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			lines := []string{"brick-test"}
+			if s.startTLS && !encrypted {
+				lines = append(lines, "STARTTLS")
+			}
+			if s.auth {
+				lines = append(lines, "AUTH PLAIN LOGIN CRAM-MD5")
+			}
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				reply("250%s%s", sep, l)
+			}
+
+		case "STARTTLS":
+			reply("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsCfg)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			encrypted = true
+			s.mu.Lock()
+			s.tlsUsed = true
+			s.mu.Unlock()
+
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			if s.authenticate(tp, strings.ToUpper(mechanism), initial) {
+				s.mu.Lock()
+				s.authUsed = strings.ToUpper(mechanism)
+				s.mu.Unlock()
+				reply("235 authenticated")
+			} else {
+				reply("535 authentication failed")
+			}
+
+		case "MAIL", "RCPT":
+			reply("250 ok")
+
+		case "DATA":
+			reply("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, string(data))
+			s.mu.Unlock()
+			reply("250 queued")
+
+		case "QUIT":
+			reply("221 bye")
+			return
+
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// authenticate performs the server side of the provided authentication
+// mechanism and reports whether the expected credentials were provided.
+func (s *smtpStub) authenticate(tp *textproto.Conn, mechanism string, initial string) bool {
+	challenge := func(text string) (string, bool) {
+		_ = tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(text)))
+		line, err := tp.ReadLine()
+		if err != nil || line == "*" {
+			return "", false
+		}
+		decoded, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return "", false
+		}
+		return string(decoded), true
+	}
+
+	switch mechanism {
+	case "PLAIN":
+		decoded, err := base64.StdEncoding.DecodeString(initial)
+		if err != nil {
+			return false
+		}
+		return string(decoded) == "\x00"+testSMTPUsername+"\x00"+testSMTPPassword
+
+	case "LOGIN":
+		username, ok := challenge("Username:")
+		if !ok {
+			return false
+		}
+		password, ok := challenge("Password:")
+		if !ok {
+			return false
+		}
+		return username == testSMTPUsername && password == testSMTPPassword
+
+	case "CRAM-MD5":
+		const serverChallenge = "<1896.697170952@brick-test>"
+		response, ok := challenge(serverChallenge)
+		if !ok {
+			return false
+		}
+		mac := hmac.New(md5.New, []byte(testSMTPPassword))
+		mac.Write([]byte(serverChallenge))
+		return response == testSMTPUsername+" "+hex.EncodeToString(mac.Sum(nil))
+
+	default:
+		return false
+	}
+}
+
+// writePasswordFile writes the provided content to a password file and
+// returns the path to it.
+func writePasswordFile(t *testing.T, content string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write password file: %v", err)
+	}
+
+	return filename
+}
+
+func TestLoginAuthStart(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  smtp.ServerInfo
+		wantErr bool
+	}{
+		{
+			name:   "encrypted connection",
+			server: smtp.ServerInfo{Name: "smtp.example.com", TLS: true},
+		},
+		{
+			name:   "unencrypted connection to local host",
+			server: smtp.ServerInfo{Name: "localhost"},
+		},
+		{
+			name:    "unencrypted connection to remote host",
+			server:  smtp.ServerInfo{Name: "smtp.example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "wrong host name",
+			server:  smtp.ServerInfo{Name: "other.example.com", TLS: true},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := loginAuth{username: "user", password: "pass", host: "smtp.example.com"}
+			if tt.server.Name == "localhost" {
+				auth.host = "localhost"
+			}
+
+			mechanism, resp, err := auth.Start(&tt.server)
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatal("expected error, got nil")
+			case !tt.wantErr && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case !tt.wantErr && (mechanism != "LOGIN" || resp != nil):
+				t.Fatalf("got (%q, %q), want (\"LOGIN\", nil)", mechanism, resp)
+			}
+		})
+	}
+}
+
+func TestLoginAuthNext(t *testing.T) {
+	auth := loginAuth{username: "user", password: "pass", host: "localhost"}
+
+	tests := []struct {
+		challenge string
+		more      bool
+		want      string
+		wantErr   bool
+	}{
+		{challenge: "Username:", more: true, want: "user"},
+		{challenge: "username", more: true, want: "user"},
+		{challenge: " USERNAME: ", more: true, want: "user"},
+		{challenge: "Password:", more: true, want: "pass"},
+		{challenge: "password", more: true, want: "pass"},
+		{challenge: "Account:", more: true, wantErr: true},
+		{challenge: "", more: true, wantErr: true},
+		{challenge: "Authentication successful", more: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.challenge), func(t *testing.T) {
+			got, err := auth.Next([]byte(tt.challenge), tt.more)
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatalf("expected error, got response %q", got)
+			case !tt.wantErr && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case string(got) != tt.want:
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPasswordFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{name: "no trailing newline", content: "pass", want: "pass"},
+		{name: "trailing newline", content: "pass\n", want: "pass"},
+		{name: "trailing CRLF", content: "pass\r\n", want: "pass"},
+		{name: "surrounding spaces kept", content: " pass \n", want: " pass "},
+		{name: "empty", content: "\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readPasswordFile(writePasswordFile(t, tt.content))
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatalf("expected error, got %q", got)
+			case !tt.wantErr && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case got != tt.want:
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		if _, err := readPasswordFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+}
+
+func TestLoadCertPool(t *testing.T) {
+	_, caFile := newTestCertificate(t)
+	if _, err := loadCertPool(caFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	invalid := filepath.Join(t.TempDir(), "invalid.pem")
+	if err := os.WriteFile(invalid, []byte("not a certificate\n"), 0o600); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+	if _, err := loadCertPool(invalid); err == nil {
+		t.Fatal("expected error for bundle without certificates, got nil")
+	}
+
+	if _, err := loadCertPool(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Fatal("expected error for missing bundle, got nil")
+	}
+}
+
+func TestSendEmail(t *testing.T) {
+	cert, caFile := newTestCertificate(t)
+	passwordFile := writePasswordFile(t, testSMTPPassword+"\n")
+	wrongPasswordFile := writePasswordFile(t, "wrong")
+
+	tests := []struct {
+		name string
+
+		// server settings
+		serverStartTLS    bool
+		serverImplicitTLS bool
+		serverAuth        bool
+
+		// client settings
+		startTLS      string
+		implicitTLS   bool
+		authMechanism string
+		passwordFile  string
+		caFile        string
+
+		wantErr      string
+		wantTLS      bool
+		wantAuthUsed string
+	}{
+		{
+			name:           "STARTTLS required and supported",
+			serverStartTLS: true,
+			startTLS:       config.EmailStartTLSRequired,
+			caFile:         caFile,
+			wantTLS:        true,
+		},
+		{
+			name:     "STARTTLS required but not supported",
+			startTLS: config.EmailStartTLSRequired,
+			caFile:   caFile,
+			wantErr:  "STARTTLS required, but not supported",
+		},
+		{
+			name:           "STARTTLS opportunistic and supported",
+			serverStartTLS: true,
+			startTLS:       config.EmailStartTLSOpportunistic,
+			caFile:         caFile,
+			wantTLS:        true,
+		},
+		{
+			name:     "STARTTLS opportunistic but not supported",
+			startTLS: config.EmailStartTLSOpportunistic,
+			caFile:   caFile,
+		},
+		{
+			name:           "STARTTLS off",
+			serverStartTLS: true,
+			startTLS:       config.EmailStartTLSOff,
+		},
+		{
+			name:           "STARTTLS without CA bundle for untrusted certificate",
+			serverStartTLS: true,
+			startTLS:       config.EmailStartTLSRequired,
+			wantErr:        "certificate",
+		},
+		{
+			name:              "implicit TLS",
+			serverImplicitTLS: true,
+			startTLS:          config.EmailStartTLSRequired,
+			implicitTLS:       true,
+			caFile:            caFile,
+			wantTLS:           true,
+		},
+		{
+			name:           "PLAIN authentication",
+			serverStartTLS: true,
+			serverAuth:     true,
+			startTLS:       config.EmailStartTLSRequired,
+			caFile:         caFile,
+			authMechanism:  config.EmailAuthPlain,
+			passwordFile:   passwordFile,
+			wantTLS:        true,
+			wantAuthUsed:   "PLAIN",
+		},
+		{
+			name:           "LOGIN authentication",
+			serverStartTLS: true,
+			serverAuth:     true,
+			startTLS:       config.EmailStartTLSRequired,
+			caFile:         caFile,
+			authMechanism:  config.EmailAuthLogin,
+			passwordFile:   passwordFile,
+			wantTLS:        true,
+			wantAuthUsed:   "LOGIN",
+		},
+		{
+			name:           "CRAM-MD5 authentication",
+			serverStartTLS: true,
+			serverAuth:     true,
+			startTLS:       config.EmailStartTLSRequired,
+			caFile:         caFile,
+			authMechanism:  config.EmailAuthCRAMMD5,
+			passwordFile:   passwordFile,
+			wantTLS:        true,
+			wantAuthUsed:   "CRAM-MD5",
+		},
+		{
+			name:           "wrong password",
+			serverStartTLS: true,
+			serverAuth:     true,
+			startTLS:       config.EmailStartTLSRequired,
+			caFile:         caFile,
+			authMechanism:  config.EmailAuthLogin,
+			passwordFile:   wrongPasswordFile,
+			wantErr:        "failed to authenticate",
+		},
+		{
+			name:          "authentication not supported by server",
+			startTLS:      config.EmailStartTLSOff,
+			authMechanism: config.EmailAuthPlain,
+			passwordFile:  passwordFile,
+			wantErr:       "authentication configured, but not supported",
+		},
+		{
+			name:          "missing password file",
+			serverAuth:    true,
+			startTLS:      config.EmailStartTLSOff,
+			authMechanism: config.EmailAuthPlain,
+			passwordFile:  filepath.Join(t.TempDir(), "missing"),
+			wantErr:       "failed to read password file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newSMTPStub(t, cert, tt.serverStartTLS, tt.serverImplicitTLS, tt.serverAuth)
+
+			emailCfg := emailConfig{
+				server:             "127.0.0.1",
+				serverPort:         stub.port(),
+				senderAddress:      "brick@example.com",
+				recipientAddresses: []string{"recipient@example.com"},
+				clientIdentity:     "brick.example.com",
+				startTLS:           tt.startTLS,
+				implicitTLS:        tt.implicitTLS,
+				authMechanism:      tt.authMechanism,
+				username:           testSMTPUsername,
+				passwordFile:       tt.passwordFile,
+				caFile:             tt.caFile,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			err := sendEmail(ctx, emailCfg, testSMTPMessage)
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			stub.mu.Lock()
+			defer stub.mu.Unlock()
+
+			if stub.tlsUsed != tt.wantTLS {
+				t.Errorf("TLS used: got %t, want %t", stub.tlsUsed, tt.wantTLS)
+			}
+			if stub.authUsed != tt.wantAuthUsed {
+				t.Errorf("authentication mechanism: got %q, want %q", stub.authUsed, tt.wantAuthUsed)
+			}
+			if len(stub.messages) != 1 {
+				t.Fatalf("got %d messages, want 1", len(stub.messages))
+			}
+			if got := stub.messages[0]; got != strings.ReplaceAll(testSMTPMessage, "\r\n", "\n") {
+				t.Errorf("message: got %q", got)
+			}
+		})
+	}
+}
+
+// TestSendEmailUnexpectedLoginChallenge confirms that an unexpected LOGIN
+// challenge from the server fails the attempt instead of sending the
+// password.
+func TestSendEmailUnexpectedLoginChallenge(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start SMTP stub: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		write := func(line string) { _, _ = fmt.Fprintf(conn, "%s\r\n", line) }
+		read := func() string {
+			line, _ := r.ReadString('\n')
+			return strings.TrimRight(line, "\r\n")
+		}
+
+		write("220 brick-test ESMTP")
+		_ = read() // EHLO
+		write("250-brick-test")
+		write("250 AUTH LOGIN")
+		_ = read() // AUTH LOGIN
+		write("334 " + base64.StdEncoding.EncodeToString([]byte("Account:")))
+		received <- read()
+		write("501 cancelled")
+		_ = read() // QUIT
+		write("221 bye")
+	}()
+
+	emailCfg := emailConfig{
+		server:        "127.0.0.1",
+		serverPort:    listener.Addr().(*net.TCPAddr).Port,
+		startTLS:      config.EmailStartTLSOff,
+		authMechanism: config.EmailAuthLogin,
+		username:      testSMTPUsername,
+		passwordFile:  writePasswordFile(t, testSMTPPassword),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = sendEmail(ctx, emailCfg, testSMTPMessage)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "unexpected server challenge") {
+		t.Fatalf("expected unexpected server challenge error, got %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if got != "*" {
+			t.Fatalf("expected authentication to be cancelled, got %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for client response")
+	}
+}
@@ -26,11 +26,15 @@ package main
 
 		Errors Section
 
+		Processing Steps Section (consolidated notifications only)
+
+		Session Termination Results Section (if enabled)
+
 		Disable User Request Details Section - Core of alert details
 
 		Alert Request Summary Section - General client request details
 
-		Alert Request Headers Section
+		Alert Request Headers Section (if headers are available)
 
 		Branding / Trailer Section
 
@@ -58,6 +62,14 @@ None
 {{- end }}
 
 
+{{ if .Record.Steps -}}
+**Processing Steps**
+
+{{ range $index, $element := .Record.Steps -}}
+{{ inc $index }}. {{ .Action }}{{ if .Note }}: {{ .Note }}{{ end }}{{ if .Error }} (Error: {{ .Error }}){{ end }}
+{{ end }}
+
+{{ end -}}
 {{ if .Record.SessionTerminationResults -}}
 **Session Termination Results**
 
@@ -86,16 +98,19 @@ Session {{ inc $index }}:
 **Alert Request Summary**
 
 * Received at: {{ .Record.Alert.LocalTime }}
+{{ if .Record.Alert.EventTime }}* Activity at: {{ .Record.Alert.EventTime }}
+* Latency: {{ .Record.Alert.Latency }}
+{{ end -}}
 * Endpoint path: {{ .Record.Alert.EndpointPath }}
 * HTTP Method: {{ .Record.Alert.HTTPMethod }}
 * Alert Sender IP: {{ .Record.Alert.PayloadSenderIP }}
 
 
+{{ if .Record.Alert.Headers -}}
 **Alert Request Headers**
 {{ range $key, $slice := .Record.Alert.Headers }}
 * {{ $key }}: {{ range $sliceValue := $slice }}{{ . }}{{ end }}
-{{- else }}
-* None
+{{- end }}
 {{ end }}
 
 {{ .Branding }}
@@ -129,6 +144,15 @@ const textileEmailTemplate string = `
 {{- end }}
 
 
+{{ if .Record.Steps -}}
+**Processing Steps**
+
+| Step | Action | Note | Error |
+{{ range $index, $element := .Record.Steps -}}
+| {{ inc $index }} | {{ .Action }} | {{ .Note }} | {{ if .Error }}{{ .Error }}{{ end }} |
+{{ end }}
+
+{{ end -}}
 {{ if .Record.SessionTerminationResults -}}
 **Session Termination Results**
 
@@ -151,18 +175,98 @@ const textileEmailTemplate string = `
 **Alert Request Summary**
 
 | Received at     | {{ .Record.Alert.LocalTime }} |
+{{ if .Record.Alert.EventTime }}| Activity at     | {{ .Record.Alert.EventTime }} |
+| Latency         | {{ .Record.Alert.Latency }} |
+{{ end -}}
 | Endpoint path   | {{ .Record.Alert.EndpointPath }} |
 | HTTP Method     | {{ .Record.Alert.HTTPMethod }} |
 | Alert Sender IP | {{ .Record.Alert.PayloadSenderIP }} |
 
 
+{{ if .Record.Alert.Headers -}}
 **Alert Request Headers**
 {{ range $key, $slice := .Record.Alert.Headers }}
 | {{ $key }} | {{ range $sliceValue := $slice }}{{ . }}{{ end }} |
-{{- else }}
-| None | N/A |
+{{- end }}
 {{ end }}
 
 {{ .Branding }}
 
 `
+
+// htmlEmailTemplate is used to generate the HTML part of email
+// notifications. This template is rendered using the html/template package
+// from the same data as the plain text part so that values are escaped.
+const htmlEmailTemplate string = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .EmailSubject }}</title>
+</head>
+<body style="font-family: sans-serif;">
+{{ $missingValue := "MISSING VALUE - Please file a bug report!" }}
+<h2>{{ .EmailSubject }}</h2>
+
+<h3>Summary</h3>
+<p>{{ if ne .EmailSummary "" }}{{ .EmailSummary }}{{ else }}{{ $missingValue }}{{ end }}</p>
+
+<h3>Disable User Request Errors</h3>
+{{ if .Record.Error -}}
+<pre>{{ .Record.Error }}</pre>
+{{- else -}}
+<p>None</p>
+{{- end }}
+
+{{ if .Record.Steps -}}
+<h3>Processing Steps</h3>
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>Step</th><th>Action</th><th>Note</th><th>Error</th></tr>
+{{ range $index, $element := .Record.Steps -}}
+<tr><td>{{ inc $index }}</td><td>{{ .Action }}</td><td>{{ .Note }}</td><td>{{ if .Error }}{{ .Error }}{{ end }}</td></tr>
+{{ end -}}
+</table>
+{{- end }}
+
+{{ if .Record.SessionTerminationResults -}}
+<h3>Session Termination Results</h3>
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>SessionID</th><th>IPAddress</th><th>ExitCode</th><th>StdOut</th><th>StdErr</th><th>Error</th></tr>
+{{ range .Record.SessionTerminationResults -}}
+<tr><td>{{ .SessionID }}</td><td>{{ .IPAddress }}</td><td>{{ .ExitCode }}</td><td>{{ .StdOut }}</td><td>{{ .StdErr }}</td><td>{{ .Error }}</td></tr>
+{{ end -}}
+</table>
+{{- end }}
+
+<h3>Disable User Request Details</h3>
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th align="left">Username</th><td>{{ if .Record.Alert.Username }}{{ .Record.Alert.Username }}{{ else }}{{ $missingValue }}{{ end }}</td></tr>
+<tr><th align="left">User IP</th><td>{{ if .Record.Alert.UserIP }}{{ .Record.Alert.UserIP }}{{ else }}{{ $missingValue }}{{ end }}</td></tr>
+<tr><th align="left">Alert/Search Name</th><td>{{ if .Record.Alert.AlertName }}{{ .Record.Alert.AlertName }}{{ else }}{{ $missingValue }}{{ end }}</td></tr>
+<tr><th align="left">Alert/Search ID</th><td>{{ if .Record.Alert.SearchID }}{{ .Record.Alert.SearchID }}{{ else }}{{ $missingValue }}{{ end }}</td></tr>
+</table>
+
+<h3>Alert Request Summary</h3>
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th align="left">Received at</th><td>{{ .Record.Alert.LocalTime }}</td></tr>
+{{ if .Record.Alert.EventTime -}}
+<tr><th align="left">Activity at</th><td>{{ .Record.Alert.EventTime }}</td></tr>
+<tr><th align="left">Latency</th><td>{{ .Record.Alert.Latency }}</td></tr>
+{{ end -}}
+<tr><th align="left">Endpoint path</th><td>{{ .Record.Alert.EndpointPath }}</td></tr>
+<tr><th align="left">HTTP Method</th><td>{{ .Record.Alert.HTTPMethod }}</td></tr>
+<tr><th align="left">Alert Sender IP</th><td>{{ .Record.Alert.PayloadSenderIP }}</td></tr>
+</table>
+
+{{ if .Record.Alert.Headers -}}
+<h3>Alert Request Headers</h3>
+<table border="1" cellpadding="4" cellspacing="0">
+{{ range $key, $slice := .Record.Alert.Headers -}}
+<tr><th align="left">{{ $key }}</th><td>{{ range $sliceValue := $slice }}{{ . }}{{ end }}</td></tr>
+{{ end -}}
+</table>
+{{ end -}}
+
+<p><small>{{ .BrandingHTML }}</small></p>
+</body>
+</html>
+`
@@ -0,0 +1,173 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// workerPool runs submitted jobs using a fixed number of workers. Jobs wait
+// in a bounded intake queue until a worker is available.
+type workerPool struct {
+	name  string
+	queue chan func(context.Context)
+
+	// ctx is provided to each job and is cancelled only if jobs do not
+	// finish within the drain timeout given to Stop.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	wg sync.WaitGroup
+
+	// mu guards closed; Submit and Stop may be called concurrently if the
+	// http server shutdown times out with handlers still running.
+	mu     sync.RWMutex
+	closed bool
+
+	// stopping is closed by Stop before it takes the write lock so that
+	// SubmitWait calls blocked on a full queue give up their read lock.
+	stopping chan struct{}
+	stopOnce sync.Once
+}
+
+// newWorkerPool creates a worker pool with the specified number of workers
+// and intake queue depth and starts the workers.
+func newWorkerPool(name string, workers int, queueDepth int) *workerPool {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	pool := workerPool{
+		name:     name,
+		queue:    make(chan func(context.Context), queueDepth),
+		ctx:      ctx,
+		cancel:   cancel,
+		stopping: make(chan struct{}),
+	}
+
+	pool.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go pool.worker()
+	}
+
+	log.Debugf(
+		"%s: started %d workers with queue depth %d",
+		name,
+		workers,
+		queueDepth,
+	)
+
+	return &pool
+}
+
+// worker runs queued jobs until the intake queue is closed and empty.
+func (p *workerPool) worker() {
+	defer p.wg.Done()
+
+	for job := range p.queue {
+		job(p.ctx)
+	}
+}
+
+// Submit places the job in the intake queue. false is returned if the queue
+// is full or if the pool has been stopped.
+func (p *workerPool) Submit(job func(context.Context)) bool {
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return false
+	}
+
+	select {
+	case p.queue <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// SubmitWait places the job in the intake queue, waiting for space if the
+// queue is full. false is returned if the pool has been stopped (or is
+// stopped while waiting).
+func (p *workerPool) SubmitWait(job func(context.Context)) bool {
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return false
+	}
+
+	select {
+	case p.queue <- job:
+		return true
+	case <-p.stopping:
+		return false
+	}
+}
+
+// Len returns the number of jobs waiting in the intake queue.
+func (p *workerPool) Len() int {
+	return len(p.queue)
+}
+
+// Stop closes the intake queue and waits for all queued and running jobs to
+// finish. If jobs are still running once the timeout is reached, the context
+// provided to each job is cancelled and Stop waits up to the timeout again
+// for the remaining jobs to return. true is returned if all jobs finished.
+func (p *workerPool) Stop(timeout time.Duration) bool {
+
+	p.stopOnce.Do(func() { close(p.stopping) })
+
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	log.Debugf("%s: waiting up to %v for %d queued jobs and running jobs", p.name, timeout, p.Len())
+
+	select {
+	case <-done:
+		p.cancel()
+		return true
+	case <-time.After(timeout):
+	}
+
+	log.Warnf("%s: timeout reached, cancelling remaining jobs", p.name)
+	p.cancel()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		log.Errorf("%s: jobs failed to return after cancellation", p.name)
+		return false
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolStopReleasesBlockedSubmitWait(t *testing.T) {
+	pool := newWorkerPool("test", 1, 1)
+
+	// Occupy the only worker until the job context is cancelled, then fill
+	// the queue so that SubmitWait blocks.
+	running := make(chan struct{})
+	pool.Submit(func(ctx context.Context) {
+		close(running)
+		<-ctx.Done()
+	})
+	<-running
+
+	if !pool.Submit(func(context.Context) {}) {
+		t.Fatal("failed to fill the intake queue")
+	}
+
+	submitted := make(chan bool)
+	go func() {
+		submitted <- pool.SubmitWait(func(context.Context) {})
+	}()
+
+	select {
+	case queued := <-submitted:
+		t.Fatalf("SubmitWait returned %t while the queue was full", queued)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	stopped := make(chan bool)
+	go func() {
+		stopped <- pool.Stop(50 * time.Millisecond)
+	}()
+
+	select {
+	case queued := <-submitted:
+		if queued {
+			t.Error("SubmitWait queued a job after Stop was called")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SubmitWait still blocked after Stop was called")
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+
+	if pool.SubmitWait(func(context.Context) {}) {
+		t.Error("SubmitWait queued a job after Stop returned")
+	}
+}
@@ -48,24 +48,79 @@ func (c *Config) String() string {
 		"UnifiedConfig: { "+
 			"Network.LocalTCPPort: %v, "+
 			"Network.LocalIPAddress: %v, "+
+			"Metrics.Enabled: %t, "+
+			"Metrics.LocalTCPPort: %d, "+
+			"Metrics.LocalIPAddress: %q, "+
 			"Logging.Level: %s, "+
 			"Logging.Output: %s, "+
 			"Logging.Format: %s, "+
+			"Logging.SyslogNetwork: %q, "+
+			"Logging.SyslogAddress: %q, "+
+			"Logging.SyslogFacility: %q, "+
+			"Logging.SyslogAppName: %q, "+
+			"Logging.File: %q, "+
+			"Logging.FilePermissions: %v, "+
+			"Logging.FileMaxSize: %v, "+
+			"Logging.FileRotateInterval: %v, "+
+			"Logging.FileMaxAge: %v, "+
+			"Logging.FileMaxBackups: %v, "+
+			"Logging.FileCompress: %t, "+
 			"DisabledUsers.File: %s, "+
 			"DisabledUsers.EntrySuffix: %s, "+
 			"DisabledUsers.FilePermissions: %v, "+
+			"DisabledUsers.FileOwner: %q, "+
+			"DisabledUsers.FileGroup: %q, "+
+			"DisabledUsers.TemplateFile: %q, "+
 			"ReportedUsers.LogFile: %q, "+
 			"ReportedUsers.LogFilePermissions: %v, "+
+			"ReportedUsers.LogFileOwner: %q, "+
+			"ReportedUsers.LogFileGroup: %q, "+
+			"ReportedUsers.ReportTemplateFile: %q, "+
+			"ReportedUsers.DisabledTemplateFile: %q, "+
+			"ReportedUsers.AlreadyDisabledTemplateFile: %q, "+
+			"ReportedUsers.IgnoredTemplateFile: %q, "+
+			"ReportedUsers.TerminatedTemplateFile: %q, "+
+			"Journal.File: %q, "+
+			"Journal.FilePermissions: %v, "+
+			"Dedup.TTL: %d, "+
+			"Dedup.File: %q, "+
+			"Dedup.FilePermissions: %v, "+
+			"StaleAlerts.MaxAge: %d, "+
+			"StaleAlerts.Action: %q, "+
+			"JSONEventsLog.File: %q, "+
+			"JSONEventsLog.FilePermissions: %v, "+
+			"SIEM.Format: %q, "+
+			"SIEM.File: %q, "+
+			"SIEM.FilePermissions: %v, "+
+			"SIEM.SyslogNetwork: %q, "+
+			"SIEM.SyslogAddress: %q, "+
+			"SIEM.SyslogFacility: %q, "+
 			"IgnoredUsers.File: %q, "+
 			"IsSetIgnoredUsersFile: %t, "+
 			"IgnoredIPAddresses.File: %q, "+
 			"IsSetIgnoredIPAddressesFile: %t, "+
 			"IgnoreLookupErrors: %t, "+
+			"Usernames.Lowercase: %t, "+
+			"Usernames.StripRealm: %t, "+
+			"Usernames.StripDomain: %t, "+
+			"Usernames.AliasFile: %q, "+
 			"MSTeams.WebhookURL: %q, "+
 			"MSTeams.RateLimit: %v, "+
 			"MSTeams.Retries: %v, "+
 			"MSTeams.RetryDelay: %v, "+
+			"MSTeams.MessageFormat: %q, "+
+			"MSTeams.TextTemplateFile: %q, "+
+			"MSTeams.Webhooks: %v, "+
 			"NotifyTeams: %t, "+
+			"Slack.WebhookURL: %q, "+
+			"Slack.RateLimit: %v, "+
+			"Slack.Retries: %v, "+
+			"Slack.RetryDelay: %v, "+
+			"Slack.TextTemplateFile: %q, "+
+			"NotifySlack: %t, "+
+			"Webhooks: %v, "+
+			"NotifyWebhooks: %t, "+
+			"Routes: %v, "+
 			"NotifyEmail: %t, "+
 			"Email.Server: %q, "+
 			"Email.Port: %v, "+
@@ -75,33 +130,110 @@ func (c *Config) String() string {
 			"Email.RateLimit: %v, "+
 			"Email.Retries: %v, "+
 			"Email.RetryDelay: %v, "+
+			"Email.StartTLS: %q, "+
+			"Email.ImplicitTLS: %t, "+
+			"Email.AuthMechanism: %q, "+
+			"Email.Username: %q, "+
+			"Email.PasswordFile: %q, "+
+			"Email.CAFile: %q, "+
+			"Email.AttachAlert: %t, "+
+			"Email.BodyTemplate: %q, "+
+			"Email.SubjectTemplateFile: %q, "+
+			"Email.BodyTemplateFile: %q, "+
+			"Email.HTMLBodyTemplateFile: %q, "+
+			"Outbox.File: %q, "+
+			"Outbox.FilePermissions: %v, "+
+			"Outbox.MaxAttempts: %d, "+
+			"Outbox.RetryDelay: %d, "+
+			"Aggregation.Enabled: %t, "+
+			"Aggregation.Window: %d, "+
+			"Digest.Schedule: %q, "+
+			"Digest.Time: %q, "+
+			"Digest.Weekday: %q, "+
+			"Digest.Top: %d, "+
 			"EZproxy.ExecutablePath: %v, "+
 			"EZproxy.ActiveFilePath: %v, "+
 			"EZproxy.AuditFileDirPath: %v, "+
 			"EZproxy.SearchRetries: %v, "+
 			"EZproxy.SearchDelay: %v, "+
 			"EZproxy.TerminateSessions: %t, "+
+			"EZproxy.User: %q, "+
 			"ConfigFile: %q}",
 		c.LocalTCPPort(),
 		c.LocalIPAddress(),
+		c.MetricsEnabled(),
+		c.MetricsLocalTCPPort(),
+		c.MetricsLocalIPAddress(),
 		c.LogLevel(),
 		c.LogOutput(),
 		c.LogFormat(),
+		c.LogSyslogNetwork(),
+		c.LogSyslogAddress(),
+		c.LogSyslogFacility(),
+		c.LogSyslogAppName(),
+		c.LogFile(),
+		c.LogFilePermissions(),
+		c.LogFileMaxSize(),
+		c.LogFileRotateInterval(),
+		c.LogFileMaxAge(),
+		c.LogFileMaxBackups(),
+		c.LogFileCompress(),
 		c.DisabledUsersFile(),
 		c.DisabledUsersFileEntrySuffix(),
 		c.DisabledUsersFilePermissions(),
+		c.DisabledUsersFileOwner(),
+		c.DisabledUsersFileGroup(),
+		c.DisabledUsersTemplateFile(),
 		c.ReportedUsersLogFile(),
 		c.ReportedUsersLogFilePermissions(),
+		c.ReportedUsersLogFileOwner(),
+		c.ReportedUsersLogFileGroup(),
+		c.ReportedUsersReportTemplateFile(),
+		c.ReportedUsersDisabledTemplateFile(),
+		c.ReportedUsersAlreadyDisabledTemplateFile(),
+		c.ReportedUsersIgnoredTemplateFile(),
+		c.ReportedUsersTerminatedTemplateFile(),
+		c.JournalFile(),
+		c.JournalFilePermissions(),
+		c.DedupTTL(),
+		c.DedupFile(),
+		c.DedupFilePermissions(),
+		c.StaleAlertMaxAge(),
+		c.StaleAlertAction(),
+		c.JSONEventsLogFile(),
+		c.JSONEventsLogFilePermissions(),
+		c.SIEMFormat(),
+		c.SIEMFile(),
+		c.SIEMFilePermissions(),
+		c.SIEMSyslogNetwork(),
+		c.SIEMSyslogAddress(),
+		c.SIEMSyslogFacility(),
 		c.IgnoredUsersFile(),
 		c.IsSetIgnoredUsersFile(),
 		c.IgnoredIPAddressesFile(),
 		c.IsSetIgnoredIPAddressesFile(),
 		c.IgnoreLookupErrors(),
+		c.UsernameLowercase(),
+		c.UsernameStripRealm(),
+		c.UsernameStripDomain(),
+		c.UsernameAliasFile(),
 		c.TeamsWebhookURL(),
 		c.TeamsNotificationRateLimit(),
 		c.TeamsNotificationRetries(),
 		c.TeamsNotificationRetryDelay(),
+		c.TeamsMessageFormat(),
+		c.TeamsTextTemplateFile(),
+		c.teamsWebhookNames(),
 		c.NotifyTeams(),
+		c.SlackWebhookURL(),
+		c.SlackNotificationRateLimit(),
+		c.SlackNotificationRetries(),
+		c.SlackNotificationRetryDelay(),
+		c.SlackTextTemplateFile(),
+		c.NotifySlack(),
+		c.webhookNames(),
+		c.NotifyWebhooks(),
+		c.routeNames(),
 		c.NotifyEmail(),
 		c.EmailServer(),
 		c.EmailServerPort(),
@@ -111,16 +243,72 @@ func (c *Config) String() string {
 		c.EmailNotificationRateLimit(),
 		c.EmailNotificationRetries(),
 		c.EmailNotificationRetryDelay(),
+		c.EmailStartTLS(),
+		c.EmailImplicitTLS(),
+		c.EmailAuthMechanism(),
+		c.EmailUsername(),
+		c.EmailPasswordFile(),
+		c.EmailCAFile(),
+		c.EmailAttachAlert(),
+		c.EmailBodyTemplate(),
+		c.EmailSubjectTemplateFile(),
+		c.EmailBodyTemplateFile(),
+		c.EmailHTMLBodyTemplateFile(),
+		c.OutboxFile(),
+		c.OutboxFilePermissions(),
+		c.OutboxMaxAttempts(),
+		c.OutboxRetryDelay(),
+		c.AggregateNotifications(),
+		c.AggregationWindow(),
+		c.DigestSchedule(),
+		c.DigestTime(),
+		c.DigestWeekday(),
+		c.DigestTop(),
 		c.EZproxyExecutablePath(),
 		c.EZproxyActiveFilePath(),
 		c.EZproxyAuditFileDirPath(),
 		c.EZproxySearchRetries(),
 		c.EZproxySearchDelay(),
 		c.EZproxyTerminateSessions(),
+		c.EZproxyUser(),
 		c.ConfigFile(),
 	)
 }
 
+// teamsWebhookNames returns the names of the additional Microsoft Teams
+// webhook URLs.
+func (c Config) teamsWebhookNames() []string {
+	names := make([]string, 0, len(c.fileConfig.MSTeams.Webhooks))
+	for _, webhook := range c.fileConfig.MSTeams.Webhooks {
+		if webhook.Name != nil {
+			names = append(names, *webhook.Name)
+		}
+	}
+	return names
+}
+
+// routeNames returns the names of the notification routing rules.
+func (c Config) routeNames() []string {
+	routes := c.NotificationRoutes()
+	names := make([]string, 0, len(routes))
+	for _, route := range routes {
+		names = append(names, route.Name)
+	}
+	return names
+}
+
+// webhookNames returns the names of the configured webhook targets. Only the
+// names are listed in order to keep secrets (e.g., bearer tokens) out of log
+// messages.
+func (c Config) webhookNames() []string {
+	targets := c.WebhookTargets()
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		names = append(names, target.Name)
+	}
+	return names
+}
+
 // Version emits version information and associated branding details whenever
 // the user specifies the `--version` flag. The application exits after
 // displaying this information.
@@ -136,36 +324,14 @@ func (c configTemplate) Description() string {
 	return MyAppDescription
 }
 
-// GetNotificationTimeout calculates the timeout value for the entire message
-// submission process, including the initial attempt and all retry attempts.
-//
-// This overall timeout value is computed using multiple values; (1) the base
-// timeout value for a single message submission attempt, (2) the next
-// scheduled notification (which was created using the configured delay we
-// wish to force between message submission attempts), (3) the total number of
-// retries allowed, (4) the delay between each retry attempt.
-//
-// This computed timeout value is intended to be used to cancel a notification
-// attempt once it reaches this timeout threshold.
-func GetNotificationTimeout(
-	baseTimeout time.Duration,
-	schedule time.Time,
-	retries int,
-	retriesDelay int,
-) time.Duration {
-
-	timeoutValue := (baseTimeout + time.Until(schedule)) +
-		(time.Duration(retriesDelay) * time.Duration(retries))
-
-	return timeoutValue
-}
-
 // MessageTrailer generates a branded "footer" for use with notifications.
 func MessageTrailer(format BrandingFormat) string {
 
 	switch format {
 	case BrandingMarkdownFormat:
 	case BrandingTextileFormat:
+	case BrandingSlackFormat:
+	case BrandingHTMLFormat:
 	default:
 		errMsg := fmt.Sprintf("Invalid branding format %q used!", format)
 		log.Warn(errMsg)
@@ -201,7 +367,12 @@ func NewConfig() (*Config, error) {
 	// rely on default values if user did not specify via command-line. We'll
 	// reapply logging settings later once the configuration file has been
 	// parsed and those settings available for evaluation.
-	config.configureLogging()
+	//
+	// If syslog output settings are only partially provided via flags (e.g.,
+	// the remaining settings are in the configuration file) this initial
+	// attempt may fail; the error is only returned if logging settings are
+	// not reapplied successfully.
+	loggingErr := config.configureLogging()
 
 	// If user specified a config file, try to use it, fail if not found
 	log.Debugf(
@@ -302,7 +473,15 @@ func NewConfig() (*Config, error) {
 		// settings; any CLI-specified logging settings still have precedence,
 		// so we will not be undoing any logging configuration settings
 		// already applied based on provided flag values.
-		config.configureLogging()
+		loggingErr = config.configureLogging()
+	}
+
+	if loggingErr != nil {
+		return nil, fmt.Errorf(
+			"%s: failed to apply logging settings: %w",
+			myFuncName,
+			loggingErr,
+		)
 	}
 
 	// If no errors were encountered during parsing, proceed to validation of
@@ -20,6 +20,8 @@ import (
 	"os"
 	"regexp"
 	"time"
+
+	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
 )
 
 // BrandingFormat is a type used to emulate a set of enums for use with
@@ -52,12 +54,32 @@ const (
 	// Redmine instance configured to use the (older) Textile formatting
 	// option.
 	BrandingTextileFormat BrandingFormat = `Message generated by "%s":%s (%s) at %s`
+
+	// BrandingSlackFormat is used as a Slack mrkdwn-compatible template for
+	// message "trailers" or "footers" on outgoing Slack notifications.
+	BrandingSlackFormat BrandingFormat = `Message generated by <%[2]s|%[1]s> (%[3]s) at %[4]s`
+
+	// BrandingHTMLFormat is used as an HTML template for message "trailers"
+	// or "footers" on the HTML part of outgoing email notifications.
+	BrandingHTMLFormat BrandingFormat = `Message generated by <a href="%[2]s">%[1]s</a> (%[3]s) at %[4]s`
 )
 
 // emailRegex is a regular expression provided by the W3C for email address
 // format validation.
 var emailRegex = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
 
+// teamsWorkflowURLRegex is a minimal regular expression for matching webhook
+// URLs provided by Power Automate Workflows.
+var teamsWorkflowURLRegex = regexp.MustCompile(`^https://[^/]+\.(?:logic\.azure\.com|api\.powerplatform\.com)(?::443)?/`)
+
+// TeamsWebhookURLValidationPatterns is the list of patterns used to validate
+// Microsoft Teams webhook URLs. Both Office 365 Connector and Power Automate
+// Workflows webhook URLs are accepted.
+var TeamsWebhookURLValidationPatterns = []string{
+	goteamsnotify.DefaultWebhookURLValidationPattern,
+	teamsWorkflowURLRegex.String(),
+}
+
 // Default (flag, config file, etc) settings if not overridden by user input
 const (
 	defaultLocalTCPPort int    = 8000
@@ -66,6 +88,29 @@ const (
 	defaultLogOutput    string = "stdout"
 	defaultLogFormat    string = "text"
 
+	// Used only if syslog is the chosen logging output. The default values
+	// target the local syslog daemon socket found on most Linux systems.
+	defaultLogSyslogNetwork  string = "unixgram"
+	defaultLogSyslogAddress  string = "/dev/log"
+	defaultLogSyslogFacility string = "daemon"
+	defaultLogSyslogAppName  string = MyAppName
+
+	// Used only if file is the chosen logging output.
+	defaultLogFile               string      = "/var/log/brick/brick.log"
+	defaultLogFilePerms          os.FileMode = 0o644
+	defaultLogFileMaxSize        int         = 100
+	defaultLogFileRotateInterval int         = 0
+	defaultLogFileMaxAge         int         = 30
+	defaultLogFileMaxBackups     int         = 30
+	defaultLogFileCompress       bool        = false
+
+	// The metrics endpoint is optional and is not enabled unless the
+	// sysadmin requests it. A port of 0 indicates that the endpoint is
+	// exposed on the main listener.
+	defaultMetricsEnabled        bool   = false
+	defaultMetricsLocalTCPPort   int    = 0
+	defaultMetricsLocalIPAddress string = "localhost"
+
 	// This application does not assume a specific path for the configuration
 	// file, so we default to an empty string if the user does not specify a
 	// value via CLI or environment variable.
@@ -82,8 +127,79 @@ const (
 	defaultIgnoredUsersFile          string      = "/usr/local/etc/brick/users.brick-ignored.txt"
 	defaultIgnoredIPAddressesFile    string      = "/usr/local/etc/brick/ips.brick-ignored.txt"
 
+	// The journal file holds request payloads, including the headers sent
+	// with each payload, so access is limited to the service account.
+	defaultJournalFile      string      = "/var/cache/brick/brick.journal"
+	defaultJournalFilePerms os.FileMode = 0o600
+
+	// Duplicate alerts are detected for an hour by default. Remembered
+	// alerts are held in memory unless the sysadmin specifies a file.
+	defaultDedupTTL       int         = 60
+	defaultDedupFile      string      = ""
+	defaultDedupFilePerms os.FileMode = 0o600
+
+	// Alerts are processed regardless of how long ago the reported user
+	// activity occurred unless the sysadmin specifies a maximum age.
+	defaultStaleAlertMaxAge int    = 0
+	defaultStaleAlertAction string = StaleAlertActionReject
+
+	// Notifications are held in the outbox until delivered. As with the
+	// journal, the outbox file holds the headers sent with each payload.
+	defaultOutboxFile        string      = "/var/cache/brick/brick.outbox"
+	defaultOutboxFilePerms   os.FileMode = 0o600
+	defaultOutboxMaxAttempts int         = 5
+	defaultOutboxRetryDelay  int         = 5
+
+	// Notifications are sent for each step taken in response to an alert
+	// unless the sysadmin opts to have them consolidated. The window allows
+	// for the delay in locating sessions to terminate.
+	defaultAggregationEnabled bool = false
+	defaultAggregationWindow  int  = 60
+
+	// Digest reports are not sent unless the sysadmin specifies a schedule.
+	defaultDigestSchedule string = DigestScheduleOff
+	defaultDigestTime     string = "08:00"
+	defaultDigestWeekday  string = "monday"
+	defaultDigestTop      int    = 5
+
+	// The preview subcommand renders all notification types for a disabled
+	// username unless the sysadmin specifies otherwise.
+	defaultPreviewAction   string = "username.disabled"
+	defaultPreviewNotifier string = PreviewNotifierAll
+
+	// The JSON events log is optional and is not enabled unless the
+	// sysadmin specifies a path.
+	defaultJSONEventsLogFile      string      = ""
+	defaultJSONEventsLogFilePerms os.FileMode = 0o644
+
+	// SIEM output is optional and is not enabled unless the sysadmin
+	// specifies a file path or syslog address.
+	defaultSIEMFormat         string      = SIEMFormatCEF
+	defaultSIEMFile           string      = ""
+	defaultSIEMFilePerms      os.FileMode = 0o644
+	defaultSIEMSyslogNetwork  string      = "udp"
+	defaultSIEMSyslogAddress  string      = ""
+	defaultSIEMSyslogFacility string      = "auth"
+
 	defaultIgnoreLookupErrors bool = true
 
+	// File ownership is not changed unless the sysadmin specifies an owner
+	// or group.
+	defaultFileOwner string = ""
+	defaultFileGroup string = ""
+
+	// Template files are optional; the built-in templates are used unless
+	// the sysadmin specifies a replacement.
+	defaultDisabledUsersTemplateFile string = ""
+	defaultReportedUsersTemplateFile string = ""
+
+	// Reported usernames are case-folded by default to match the behavior
+	// of earlier releases; other normalization steps are opt-in.
+	defaultUsernameLowercase   bool   = true
+	defaultUsernameStripRealm  bool   = false
+	defaultUsernameStripDomain bool   = false
+	defaultUsernameAliasFile   string = ""
+
 	// No assumptions can be safely made here; user has to supply this
 	defaultMSTeamsWebhookURL string = ""
 
@@ -102,6 +218,48 @@ const (
 	// attempts; applies to Microsoft Teams notifications only.
 	defaultMSTeamsRetryDelay int = 5
 
+	// defaultMSTeamsMessageFormat selects the message format used for
+	// Microsoft Teams notifications based on the webhook URL.
+	defaultMSTeamsMessageFormat string = TeamsMessageFormatAuto
+
+	// defaultMSTeamsTextTemplateFile is the path to the template file used
+	// in place of the built-in summary text of Microsoft Teams notifications.
+	// The built-in summary text is used if not set.
+	defaultMSTeamsTextTemplateFile string = ""
+
+	// No assumptions can be safely made here; user has to supply this
+	defaultSlackWebhookURL string = ""
+
+	// defaultSlackRateLimit is the number of seconds to wait between Slack
+	// notification attempts. Slack allows an average of one message per
+	// second for each incoming webhook.
+	defaultSlackRateLimit int = 1
+
+	// defaultSlackRetries is the number of attempts to deliver messages
+	// before giving up; applies to Slack notifications only.
+	defaultSlackRetries int = 2
+
+	// defaultSlackRetryDelay is the number of seconds to wait between retry
+	// attempts; applies to Slack notifications only.
+	defaultSlackRetryDelay int = 5
+
+	// defaultSlackTextTemplateFile is the path to the template file used in
+	// place of the built-in summary text of Slack notifications. The
+	// built-in summary text is used if not set.
+	defaultSlackTextTemplateFile string = ""
+
+	// defaultWebhookRateLimit is the number of seconds to wait between
+	// notification attempts for each generic webhook target.
+	defaultWebhookRateLimit int = 1
+
+	// defaultWebhookRetries is the number of attempts to deliver messages
+	// before giving up; applies to generic webhook notifications only.
+	defaultWebhookRetries int = 2
+
+	// defaultWebhookRetryDelay is the number of seconds to wait between
+	// retry attempts; applies to generic webhook notifications only.
+	defaultWebhookRetryDelay int = 5
+
 	// defaultSMTPServerFQDN is the SMTP server that this application should
 	// connect to for email message delivery.
 	defaultSMTPServerFQDN string = ""
@@ -118,6 +276,55 @@ const (
 	// EHLO greeting to the SMTP server.
 	defaultSMTPClientIdentity string = "brick"
 
+	// defaultSMTPStartTLS controls whether the connection to the SMTP server
+	// is upgraded using STARTTLS. This is off by default to retain the
+	// behavior of earlier releases.
+	defaultSMTPStartTLS string = EmailStartTLSOff
+
+	// defaultSMTPImplicitTLS controls whether the connection to the SMTP
+	// server is encrypted from the start.
+	defaultSMTPImplicitTLS bool = false
+
+	// defaultSMTPAuthMechanism is the SMTP authentication mechanism used
+	// when submitting email messages.
+	defaultSMTPAuthMechanism string = EmailAuthNone
+
+	// defaultSMTPUsername is the username used to authenticate to the SMTP
+	// server.
+	defaultSMTPUsername string = ""
+
+	// defaultSMTPPasswordFile is the path to the file containing the
+	// password used to authenticate to the SMTP server.
+	defaultSMTPPasswordFile string = ""
+
+	// defaultSMTPCAFile is the path to a PEM encoded bundle of certificate
+	// authorities used to verify the SMTP server certificate. The system
+	// certificate pool is used if not set.
+	defaultSMTPCAFile string = ""
+
+	// defaultSMTPAttachAlert controls whether the alert payload is attached
+	// to email notifications.
+	defaultSMTPAttachAlert bool = false
+
+	// defaultSMTPBodyTemplate is the built-in template used for the plain
+	// text part of email notifications.
+	defaultSMTPBodyTemplate string = EmailBodyTemplateTextile
+
+	// defaultSMTPSubjectTemplateFile is the path to the template file used in
+	// place of the built-in subject of email notifications. The built-in
+	// subject is used if not set.
+	defaultSMTPSubjectTemplateFile string = ""
+
+	// defaultSMTPBodyTemplateFile is the path to the template file used in
+	// place of the built-in template for the plain text part of email
+	// notifications. The built-in template is used if not set.
+	defaultSMTPBodyTemplateFile string = ""
+
+	// defaultSMTPHTMLBodyTemplateFile is the path to the template file used
+	// in place of the built-in template for the HTML part of email
+	// notifications. The built-in template is used if not set.
+	defaultSMTPHTMLBodyTemplateFile string = ""
+
 	// defaultSMTPRateLimit is the number of seconds to wait between email
 	// notification attempts.
 	defaultSMTPRateLimit int = 3
@@ -154,6 +361,11 @@ const (
 
 	// defaultEZproxyTerminateSessions is the toggle for sessions termination
 	defaultEZproxyTerminateSessions bool = false
+
+	// defaultEZproxyUser is the OS user account that the EZproxy daemon runs
+	// as. The check that this user account can read the disabled users file
+	// is skipped unless the sysadmin specifies a value.
+	defaultEZproxyUser string = ""
 )
 
 // TODO: Expose these settings via flags, config file
@@ -173,8 +385,8 @@ const (
 const HTTPServerShutdownTimeout time.Duration = 30 * time.Second
 
 // NotifyMgrServicesShutdownTimeout is used by the NotifyMgr to determine how
-// long it should wait for results from each notifier or notifier "service"
-// before continuing on with the shutdown process.
+// long it should wait for results from the notification services before
+// continuing on with the shutdown process.
 const NotifyMgrServicesShutdownTimeout time.Duration = 2 * time.Second
 
 // Timing-related settings (delays, timeouts) used by our notification manager
@@ -182,21 +394,25 @@ const NotifyMgrServicesShutdownTimeout time.Duration = 2 * time.Second
 const (
 
 	// NotifyMgrTeamsNotificationTimeout is the timeout setting applied to
-	// each Microsoft Teams notification attempt. This value does NOT take
-	// into account the number of configured retries, retry delays or overall
-	// rate limit for Teams notifications. The final timeout value applied to
-	// each notification attempt should be based on those calculations and
-	// this value. The GetNotificationTimeout method does just that.
+	// each Microsoft Teams notification attempt. The configured retry delays
+	// and rate limit for Teams notifications are applied separately.
 	NotifyMgrTeamsNotificationTimeout time.Duration = 10 * time.Second
 
 	// NotifyMgrEmailNotificationTimeout is the timeout setting applied to
-	// each email notification attempt. This value does NOT take into account
-	// the number of configured retries, retry delays or overall rate limit
-	// for email notifications. The final timeout value applied to each
-	// notification attempt should be based on those calculations and this
-	// value. The GetNotificationTimeout method does just that.
+	// each email notification attempt. The configured retry delays and rate
+	// limit for email notifications are applied separately.
 	NotifyMgrEmailNotificationTimeout time.Duration = 30 * time.Second
 
+	// NotifyMgrSlackNotificationTimeout is the timeout setting applied to
+	// each Slack notification attempt. The configured retry delays and rate
+	// limit for Slack notifications are applied separately.
+	NotifyMgrSlackNotificationTimeout time.Duration = 10 * time.Second
+
+	// NotifyMgrWebhookNotificationTimeout is the timeout setting applied to
+	// each generic webhook notification attempt. The configured retry delays
+	// and rate limit for each webhook target are applied separately.
+	NotifyMgrWebhookNotificationTimeout time.Duration = 10 * time.Second
+
 	// NotifyStatsMonitorDelay limits notification stats logging to no more
 	// often than this duration. This limiter is to keep from logging the
 	// details so often that the information simply becomes noise.
@@ -219,6 +435,33 @@ const (
 // to place items into the queue.
 const NotifyMgrQueueDepth int = 5
 
+// Settings used by the worker pool which processes disable requests. Requests
+// received while all workers are busy wait in the intake queue; requests
+// received while the intake queue is full are rejected so that the remote
+// monitoring system can retry them later.
+const (
+
+	// DisableWorkerPoolSize is the number of disable requests processed
+	// concurrently.
+	DisableWorkerPoolSize int = 4
+
+	// DisableWorkerQueueDepth is the number of accepted disable requests
+	// allowed to wait for an available worker.
+	DisableWorkerQueueDepth int = 50
+
+	// DisableWorkerDrainTimeout is used by the shutdown process to control
+	// how long to wait for accepted disable requests to finish processing
+	// before cancelling any remaining work.
+	DisableWorkerDrainTimeout time.Duration = 2 * time.Minute
+)
+
+// AlertMaxClockSkew is how far in the future the reported user activity for
+// an alert may be before the alert is considered to be future-dated. This
+// allows for small differences between the clocks of this system and the
+// systems which recorded the user activity. This value is only used when a
+// maximum alert age is specified.
+const AlertMaxClockSkew time.Duration = 5 * time.Minute
+
 // TCP port ranges
 // http://www.iana.org/assignments/port-numbers
 // Port numbers are assigned in various ways, based on three ranges: System
@@ -289,6 +532,144 @@ const (
 	LogFormatDiscard string = "discard"
 )
 
+// Supported output formats for events recorded for SIEM ingestion.
+const (
+
+	// SIEMFormatCEF represents the ArcSight Common Event Format.
+	SIEMFormatCEF string = "cef"
+
+	// SIEMFormatLEEF represents the IBM QRadar Log Event Extended Format.
+	SIEMFormatLEEF string = "leef"
+)
+
+// Supported outcomes matched by notification routing rules.
+const (
+
+	// RouteOutcomeAny matches both successful and failed events.
+	RouteOutcomeAny string = "any"
+
+	// RouteOutcomeSuccess matches successful (or skipped) events.
+	RouteOutcomeSuccess string = "success"
+
+	// RouteOutcomeFailure matches failed events.
+	RouteOutcomeFailure string = "failure"
+)
+
+// Supported message formats for Microsoft Teams notifications.
+const (
+
+	// TeamsMessageFormatAuto selects the message format based on the webhook
+	// URL; Adaptive Cards are used for Power Automate Workflows webhook URLs
+	// and MessageCards for all others.
+	TeamsMessageFormatAuto string = "auto"
+
+	// TeamsMessageFormatMessageCard represents the legacy MessageCard format
+	// used by Office 365 Connectors.
+	TeamsMessageFormatMessageCard string = "messagecard"
+
+	// TeamsMessageFormatAdaptiveCard represents the Adaptive Card format used
+	// by Power Automate Workflows.
+	TeamsMessageFormatAdaptiveCard string = "adaptivecard"
+)
+
+// Supported actions applied to alerts reporting user activity older than the
+// specified maximum age (or future-dated user activity).
+const (
+
+	// StaleAlertActionReject rejects the alert; the alert is not recorded.
+	StaleAlertActionReject string = "reject"
+
+	// StaleAlertActionReport records and reports the alert, but does not
+	// disable the user account.
+	StaleAlertActionReport string = "report"
+)
+
+// Supported digest report schedules. The daily and weekly schedules are also
+// the periods supported by the digest subcommand.
+const (
+
+	// DigestScheduleOff disables scheduled digest reports.
+	DigestScheduleOff string = "off"
+
+	// DigestScheduleDaily sends a digest report covering the last day.
+	DigestScheduleDaily string = "daily"
+
+	// DigestScheduleWeekly sends a digest report covering the last week.
+	DigestScheduleWeekly string = "weekly"
+)
+
+// Supported STARTTLS modes for email notifications.
+const (
+
+	// EmailStartTLSOff never upgrades the connection to the SMTP server.
+	EmailStartTLSOff string = "off"
+
+	// EmailStartTLSOpportunistic upgrades the connection to the SMTP server
+	// if the server advertises support for STARTTLS.
+	EmailStartTLSOpportunistic string = "opportunistic"
+
+	// EmailStartTLSRequired upgrades the connection to the SMTP server and
+	// fails delivery if the server does not support STARTTLS.
+	EmailStartTLSRequired string = "required"
+)
+
+// Built-in templates used for the plain text part of email notifications.
+const (
+
+	// EmailBodyTemplateTextile represents the Textile formatted template
+	// intended for ticket systems such as Redmine.
+	EmailBodyTemplateTextile string = "textile"
+
+	// EmailBodyTemplateMarkdown represents the Markdown formatted template.
+	EmailBodyTemplateMarkdown string = "markdown"
+)
+
+// Supported SMTP authentication mechanisms for email notifications.
+const (
+
+	// EmailAuthNone disables SMTP authentication.
+	EmailAuthNone string = "none"
+
+	// EmailAuthPlain represents the PLAIN authentication mechanism.
+	EmailAuthPlain string = "plain"
+
+	// EmailAuthLogin represents the (non-standard, but widely supported)
+	// LOGIN authentication mechanism.
+	EmailAuthLogin string = "login"
+
+	// EmailAuthCRAMMD5 represents the CRAM-MD5 authentication mechanism.
+	EmailAuthCRAMMD5 string = "cram-md5"
+)
+
+// Notification types rendered by the preview subcommand.
+const (
+
+	// PreviewNotifierAll renders email, Microsoft Teams and Slack
+	// notifications.
+	PreviewNotifierAll string = "all"
+
+	// PreviewNotifierEmail renders email notifications.
+	PreviewNotifierEmail string = "email"
+
+	// PreviewNotifierTeams renders Microsoft Teams notifications.
+	PreviewNotifierTeams string = "teams"
+
+	// PreviewNotifierSlack renders Slack notifications.
+	PreviewNotifierSlack string = "slack"
+)
+
+// Actions supported by the deadletters subcommand.
+const (
+
+	// DeadLettersCommandList lists notifications which could not be
+	// delivered.
+	DeadLettersCommandList string = "list"
+
+	// DeadLettersCommandRetry queues notifications which could not be
+	// delivered for delivery again.
+	DeadLettersCommandRetry string = "retry"
+)
+
 const (
 
 	// LogOutputStdout represents os.Stdout
@@ -296,4 +677,10 @@ const (
 
 	// LogOutputStderr represents os.Stderr
 	LogOutputStderr string = "stderr"
+
+	// LogOutputSyslog represents a local or remote syslog daemon
+	LogOutputSyslog string = "syslog"
+
+	// LogOutputFile represents a log file with built-in rotation
+	LogOutputFile string = "file"
 )
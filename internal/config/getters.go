@@ -17,6 +17,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"time"
 
@@ -69,6 +70,171 @@ func (c Config) LogOutput() string {
 	}
 }
 
+// LogSyslogNetwork returns the user-provided network type used to connect to
+// the syslog daemon when syslog is the chosen logging output or the default
+// value if not provided. CLI flag values take precedence if provided.
+func (c Config) LogSyslogNetwork() string {
+
+	switch {
+	case c.cliConfig.Logging.SyslogNetwork != nil:
+		return *c.cliConfig.Logging.SyslogNetwork
+	case c.fileConfig.Logging.SyslogNetwork != nil:
+		return *c.fileConfig.Logging.SyslogNetwork
+	default:
+		return defaultLogSyslogNetwork
+	}
+}
+
+// LogSyslogAddress returns the user-provided address of the syslog daemon
+// used when syslog is the chosen logging output or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) LogSyslogAddress() string {
+
+	switch {
+	case c.cliConfig.Logging.SyslogAddress != nil:
+		return *c.cliConfig.Logging.SyslogAddress
+	case c.fileConfig.Logging.SyslogAddress != nil:
+		return *c.fileConfig.Logging.SyslogAddress
+	default:
+		return defaultLogSyslogAddress
+	}
+}
+
+// LogSyslogFacility returns the user-provided syslog facility used for log
+// messages when syslog is the chosen logging output or the default value if
+// not provided. CLI flag values take precedence if provided.
+func (c Config) LogSyslogFacility() string {
+
+	switch {
+	case c.cliConfig.Logging.SyslogFacility != nil:
+		return *c.cliConfig.Logging.SyslogFacility
+	case c.fileConfig.Logging.SyslogFacility != nil:
+		return *c.fileConfig.Logging.SyslogFacility
+	default:
+		return defaultLogSyslogFacility
+	}
+}
+
+// LogSyslogAppName returns the user-provided APP-NAME used for log messages
+// when syslog is the chosen logging output or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) LogSyslogAppName() string {
+
+	switch {
+	case c.cliConfig.Logging.SyslogAppName != nil:
+		return *c.cliConfig.Logging.SyslogAppName
+	case c.fileConfig.Logging.SyslogAppName != nil:
+		return *c.fileConfig.Logging.SyslogAppName
+	default:
+		return defaultLogSyslogAppName
+	}
+}
+
+// LogFile returns the user-provided path to the log file used when file is
+// the chosen logging output or the default value if not provided. CLI flag
+// values take precedence if provided.
+func (c Config) LogFile() string {
+
+	switch {
+	case c.cliConfig.Logging.File != nil:
+		return *c.cliConfig.Logging.File
+	case c.fileConfig.Logging.File != nil:
+		return *c.fileConfig.Logging.File
+	default:
+		return defaultLogFile
+	}
+}
+
+// LogFilePermissions returns the user-provided permissions for the log file
+// used when file is the chosen logging output or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) LogFilePermissions() os.FileMode {
+
+	switch {
+	case c.cliConfig.Logging.FilePermissions != nil:
+		return *c.cliConfig.Logging.FilePermissions
+	case c.fileConfig.Logging.FilePermissions != nil:
+		return *c.fileConfig.Logging.FilePermissions
+	default:
+		return defaultLogFilePerms
+	}
+}
+
+// LogFileMaxSize returns the user-provided size in megabytes the log file may
+// reach before it is rotated or the default value if not provided. CLI flag
+// values take precedence if provided.
+func (c Config) LogFileMaxSize() int {
+
+	switch {
+	case c.cliConfig.Logging.FileMaxSize != nil:
+		return *c.cliConfig.Logging.FileMaxSize
+	case c.fileConfig.Logging.FileMaxSize != nil:
+		return *c.fileConfig.Logging.FileMaxSize
+	default:
+		return defaultLogFileMaxSize
+	}
+}
+
+// LogFileRotateInterval returns the user-provided number of hours the log
+// file is written to before it is rotated or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) LogFileRotateInterval() int {
+
+	switch {
+	case c.cliConfig.Logging.FileRotateInterval != nil:
+		return *c.cliConfig.Logging.FileRotateInterval
+	case c.fileConfig.Logging.FileRotateInterval != nil:
+		return *c.fileConfig.Logging.FileRotateInterval
+	default:
+		return defaultLogFileRotateInterval
+	}
+}
+
+// LogFileMaxAge returns the user-provided number of days rotated log files are
+// retained or the default value if not provided. CLI flag
+// values take precedence if provided.
+func (c Config) LogFileMaxAge() int {
+
+	switch {
+	case c.cliConfig.Logging.FileMaxAge != nil:
+		return *c.cliConfig.Logging.FileMaxAge
+	case c.fileConfig.Logging.FileMaxAge != nil:
+		return *c.fileConfig.Logging.FileMaxAge
+	default:
+		return defaultLogFileMaxAge
+	}
+}
+
+// LogFileMaxBackups returns the user-provided number of rotated log files
+// retained or the default value if not provided. CLI flag
+// values take precedence if provided.
+func (c Config) LogFileMaxBackups() int {
+
+	switch {
+	case c.cliConfig.Logging.FileMaxBackups != nil:
+		return *c.cliConfig.Logging.FileMaxBackups
+	case c.fileConfig.Logging.FileMaxBackups != nil:
+		return *c.fileConfig.Logging.FileMaxBackups
+	default:
+		return defaultLogFileMaxBackups
+	}
+}
+
+// LogFileCompress indicates whether rotated log files should be compressed.
+// The user-provided value is returned or the default value if not provided.
+// CLI flag values take precedence if provided.
+func (c Config) LogFileCompress() bool {
+
+	switch {
+	case c.cliConfig.Logging.FileCompress != nil:
+		return *c.cliConfig.Logging.FileCompress
+	case c.fileConfig.Logging.FileCompress != nil:
+		return *c.fileConfig.Logging.FileCompress
+	default:
+		return defaultLogFileCompress
+	}
+}
+
 // LocalTCPPort returns the user-provided logging format or the default value
 // if not provided. CLI flag values take precedence if provided.
 func (c Config) LocalTCPPort() int {
@@ -97,6 +263,52 @@ func (c Config) LocalIPAddress() string {
 	}
 }
 
+// MetricsEnabled returns the user-provided choice of whether the metrics
+// endpoint is exposed or the default value if not provided. CLI flag values
+// take precedence if provided.
+func (c Config) MetricsEnabled() bool {
+
+	switch {
+	case c.cliConfig.Metrics.Enabled != nil:
+		return *c.cliConfig.Metrics.Enabled
+	case c.fileConfig.Metrics.Enabled != nil:
+		return *c.fileConfig.Metrics.Enabled
+	default:
+		return defaultMetricsEnabled
+	}
+}
+
+// MetricsLocalTCPPort returns the user-provided TCP port of the separate
+// metrics listener or the default value if not provided. CLI flag values
+// take precedence if provided. A value of 0 indicates that the metrics
+// endpoint is exposed on the main listener.
+func (c Config) MetricsLocalTCPPort() int {
+
+	switch {
+	case c.cliConfig.Metrics.LocalTCPPort != nil:
+		return *c.cliConfig.Metrics.LocalTCPPort
+	case c.fileConfig.Metrics.LocalTCPPort != nil:
+		return *c.fileConfig.Metrics.LocalTCPPort
+	default:
+		return defaultMetricsLocalTCPPort
+	}
+}
+
+// MetricsLocalIPAddress returns the user-provided IP Address of the separate
+// metrics listener or the default value if not provided. CLI flag values
+// take precedence if provided.
+func (c Config) MetricsLocalIPAddress() string {
+
+	switch {
+	case c.cliConfig.Metrics.LocalIPAddress != nil:
+		return *c.cliConfig.Metrics.LocalIPAddress
+	case c.fileConfig.Metrics.LocalIPAddress != nil:
+		return *c.fileConfig.Metrics.LocalIPAddress
+	default:
+		return defaultMetricsLocalIPAddress
+	}
+}
+
 // RequireTrustedPayloadSender indicates whether the sysadmin specified a list
 // of IP Addresses to trust for payload submission.
 func (c Config) RequireTrustedPayloadSender() bool {
@@ -152,6 +364,36 @@ func (c Config) DisabledUsersFilePermissions() os.FileMode {
 	}
 }
 
+// DisabledUsersFileOwner returns the user-provided OS user account which
+// should own the disabled users file or the default value if not provided.
+// CLI flag values take precedence if provided.
+func (c Config) DisabledUsersFileOwner() string {
+
+	switch {
+	case c.cliConfig.DisabledUsers.FileOwner != nil:
+		return *c.cliConfig.DisabledUsers.FileOwner
+	case c.fileConfig.DisabledUsers.FileOwner != nil:
+		return *c.fileConfig.DisabledUsers.FileOwner
+	default:
+		return defaultFileOwner
+	}
+}
+
+// DisabledUsersFileGroup returns the user-provided OS group which should own
+// the disabled users file or the default value if not provided. CLI flag
+// values take precedence if provided.
+func (c Config) DisabledUsersFileGroup() string {
+
+	switch {
+	case c.cliConfig.DisabledUsers.FileGroup != nil:
+		return *c.cliConfig.DisabledUsers.FileGroup
+	case c.fileConfig.DisabledUsers.FileGroup != nil:
+		return *c.fileConfig.DisabledUsers.FileGroup
+	default:
+		return defaultFileGroup
+	}
+}
+
 // ReportedUsersLogFile returns the fully-qualified path to the log file where
 // this application should log user disable request events for fail2ban to
 // ingest or the default value if not provided. CLI flag values take
@@ -187,6 +429,358 @@ func (c Config) ReportedUsersLogFilePermissions() os.FileMode {
 	}
 }
 
+// ReportedUsersLogFileOwner returns the user-provided OS user account which
+// should own the reported users log file or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) ReportedUsersLogFileOwner() string {
+
+	switch {
+	case c.cliConfig.ReportedUsers.LogFileOwner != nil:
+		return *c.cliConfig.ReportedUsers.LogFileOwner
+	case c.fileConfig.ReportedUsers.LogFileOwner != nil:
+		return *c.fileConfig.ReportedUsers.LogFileOwner
+	default:
+		return defaultFileOwner
+	}
+}
+
+// ReportedUsersLogFileGroup returns the user-provided OS group which should
+// own the reported users log file or the default value if not provided. CLI
+// flag values take precedence if provided.
+func (c Config) ReportedUsersLogFileGroup() string {
+
+	switch {
+	case c.cliConfig.ReportedUsers.LogFileGroup != nil:
+		return *c.cliConfig.ReportedUsers.LogFileGroup
+	case c.fileConfig.ReportedUsers.LogFileGroup != nil:
+		return *c.fileConfig.ReportedUsers.LogFileGroup
+	default:
+		return defaultFileGroup
+	}
+}
+
+// DisabledUsersTemplateFile returns the user-provided path to an optional
+// template file used when writing entries to the disabled users file or the
+// default value if not provided. CLI flag values take precedence if provided.
+func (c Config) DisabledUsersTemplateFile() string {
+
+	switch {
+	case c.cliConfig.DisabledUsers.TemplateFile != nil:
+		return *c.cliConfig.DisabledUsers.TemplateFile
+	case c.fileConfig.DisabledUsers.TemplateFile != nil:
+		return *c.fileConfig.DisabledUsers.TemplateFile
+	default:
+		return defaultDisabledUsersTemplateFile
+	}
+}
+
+// ReportedUsersReportTemplateFile returns the user-provided path to an
+// optional template file used for the log line written when a user account is
+// reported or the default value if not provided. CLI flag values take
+// precedence if provided.
+func (c Config) ReportedUsersReportTemplateFile() string {
+
+	switch {
+	case c.cliConfig.ReportedUsers.ReportTemplateFile != nil:
+		return *c.cliConfig.ReportedUsers.ReportTemplateFile
+	case c.fileConfig.ReportedUsers.ReportTemplateFile != nil:
+		return *c.fileConfig.ReportedUsers.ReportTemplateFile
+	default:
+		return defaultReportedUsersTemplateFile
+	}
+}
+
+// ReportedUsersDisabledTemplateFile returns the user-provided path to an
+// optional template file used for the log line written when a user account is
+// disabled or the default value if not provided. CLI flag values take
+// precedence if provided.
+func (c Config) ReportedUsersDisabledTemplateFile() string {
+
+	switch {
+	case c.cliConfig.ReportedUsers.DisabledTemplateFile != nil:
+		return *c.cliConfig.ReportedUsers.DisabledTemplateFile
+	case c.fileConfig.ReportedUsers.DisabledTemplateFile != nil:
+		return *c.fileConfig.ReportedUsers.DisabledTemplateFile
+	default:
+		return defaultReportedUsersTemplateFile
+	}
+}
+
+// ReportedUsersAlreadyDisabledTemplateFile returns the user-provided path to
+// an optional template file used for the log line written when an already
+// disabled user account is reported again or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) ReportedUsersAlreadyDisabledTemplateFile() string {
+
+	switch {
+	case c.cliConfig.ReportedUsers.AlreadyDisabledTemplateFile != nil:
+		return *c.cliConfig.ReportedUsers.AlreadyDisabledTemplateFile
+	case c.fileConfig.ReportedUsers.AlreadyDisabledTemplateFile != nil:
+		return *c.fileConfig.ReportedUsers.AlreadyDisabledTemplateFile
+	default:
+		return defaultReportedUsersTemplateFile
+	}
+}
+
+// ReportedUsersIgnoredTemplateFile returns the user-provided path to an
+// optional template file used for the log line written when a user account or
+// IP Address is ignored or the default value if not provided. CLI flag values
+// take precedence if provided.
+func (c Config) ReportedUsersIgnoredTemplateFile() string {
+
+	switch {
+	case c.cliConfig.ReportedUsers.IgnoredTemplateFile != nil:
+		return *c.cliConfig.ReportedUsers.IgnoredTemplateFile
+	case c.fileConfig.ReportedUsers.IgnoredTemplateFile != nil:
+		return *c.fileConfig.ReportedUsers.IgnoredTemplateFile
+	default:
+		return defaultReportedUsersTemplateFile
+	}
+}
+
+// ReportedUsersTerminatedTemplateFile returns the user-provided path to an
+// optional template file used for the log line written when a user session is
+// terminated or the default value if not provided. CLI flag values take
+// precedence if provided.
+func (c Config) ReportedUsersTerminatedTemplateFile() string {
+
+	switch {
+	case c.cliConfig.ReportedUsers.TerminatedTemplateFile != nil:
+		return *c.cliConfig.ReportedUsers.TerminatedTemplateFile
+	case c.fileConfig.ReportedUsers.TerminatedTemplateFile != nil:
+		return *c.fileConfig.ReportedUsers.TerminatedTemplateFile
+	default:
+		return defaultReportedUsersTemplateFile
+	}
+}
+
+// JournalFile returns the user-provided path to the journal file where
+// accepted disable requests are recorded until processing is complete or the
+// default value if not provided. CLI flag values take precedence if
+// provided.
+func (c Config) JournalFile() string {
+
+	switch {
+	case c.cliConfig.Journal.File != nil:
+		return *c.cliConfig.Journal.File
+	case c.fileConfig.Journal.File != nil:
+		return *c.fileConfig.Journal.File
+	default:
+		return defaultJournalFile
+	}
+}
+
+// JournalFilePermissions returns the user-provided permissions for the
+// journal file or the default value if not provided. CLI flag values take
+// precedence if provided.
+func (c Config) JournalFilePermissions() os.FileMode {
+
+	switch {
+	case c.cliConfig.Journal.FilePermissions != nil:
+		return *c.cliConfig.Journal.FilePermissions
+	case c.fileConfig.Journal.FilePermissions != nil:
+		return *c.fileConfig.Journal.FilePermissions
+	default:
+		return defaultJournalFilePerms
+	}
+}
+
+// DedupTTL returns the user-provided number of minutes an alert is
+// remembered for duplicate detection or the default value if not provided.
+// CLI flag values take precedence if provided.
+func (c Config) DedupTTL() int {
+
+	switch {
+	case c.cliConfig.Dedup.TTL != nil:
+		return *c.cliConfig.Dedup.TTL
+	case c.fileConfig.Dedup.TTL != nil:
+		return *c.fileConfig.Dedup.TTL
+	default:
+		return defaultDedupTTL
+	}
+}
+
+// DedupFile returns the user-provided path to the optional file used to
+// remember alerts across restarts or the default value if not provided. CLI
+// flag values take precedence if provided.
+func (c Config) DedupFile() string {
+
+	switch {
+	case c.cliConfig.Dedup.File != nil:
+		return *c.cliConfig.Dedup.File
+	case c.fileConfig.Dedup.File != nil:
+		return *c.fileConfig.Dedup.File
+	default:
+		return defaultDedupFile
+	}
+}
+
+// DedupFilePermissions returns the user-provided permissions for the
+// optional file used to remember alerts across restarts or the default value
+// if not provided. CLI flag values take precedence if provided.
+func (c Config) DedupFilePermissions() os.FileMode {
+
+	switch {
+	case c.cliConfig.Dedup.FilePermissions != nil:
+		return *c.cliConfig.Dedup.FilePermissions
+	case c.fileConfig.Dedup.FilePermissions != nil:
+		return *c.fileConfig.Dedup.FilePermissions
+	default:
+		return defaultDedupFilePerms
+	}
+}
+
+// StaleAlertMaxAge returns the user-provided number of minutes after the
+// reported user activity that an alert is still acted upon or the default
+// value if not provided. CLI flag values take precedence if provided.
+func (c Config) StaleAlertMaxAge() int {
+
+	switch {
+	case c.cliConfig.StaleAlerts.MaxAge != nil:
+		return *c.cliConfig.StaleAlerts.MaxAge
+	case c.fileConfig.StaleAlerts.MaxAge != nil:
+		return *c.fileConfig.StaleAlerts.MaxAge
+	default:
+		return defaultStaleAlertMaxAge
+	}
+}
+
+// StaleAlertAction returns the user-provided action applied to stale or
+// future-dated alerts or the default value if not provided. CLI flag values
+// take precedence if provided.
+func (c Config) StaleAlertAction() string {
+
+	switch {
+	case c.cliConfig.StaleAlerts.Action != nil:
+		return *c.cliConfig.StaleAlerts.Action
+	case c.fileConfig.StaleAlerts.Action != nil:
+		return *c.fileConfig.StaleAlerts.Action
+	default:
+		return defaultStaleAlertAction
+	}
+}
+
+// JSONEventsLogFile returns the user-provided path to the optional log file
+// where this application should record events in JSON format or the default
+// value if not provided. CLI flag values take precedence if provided.
+func (c Config) JSONEventsLogFile() string {
+
+	switch {
+	case c.cliConfig.JSONEventsLog.File != nil:
+		return *c.cliConfig.JSONEventsLog.File
+	case c.fileConfig.JSONEventsLog.File != nil:
+		return *c.fileConfig.JSONEventsLog.File
+	default:
+		return defaultJSONEventsLogFile
+	}
+}
+
+// JSONEventsLogFilePermissions returns the user-provided permissions for the
+// optional log file where this application should record events in JSON
+// format or the default value if not provided. CLI flag values take
+// precedence if provided.
+func (c Config) JSONEventsLogFilePermissions() os.FileMode {
+
+	switch {
+	case c.cliConfig.JSONEventsLog.FilePermissions != nil:
+		return *c.cliConfig.JSONEventsLog.FilePermissions
+	case c.fileConfig.JSONEventsLog.FilePermissions != nil:
+		return *c.fileConfig.JSONEventsLog.FilePermissions
+	default:
+		return defaultJSONEventsLogFilePerms
+	}
+}
+
+// SIEMFormat returns the user-provided output format used to record events
+// for SIEM ingestion or the default value if not provided. CLI flag values
+// take precedence if provided.
+func (c Config) SIEMFormat() string {
+
+	switch {
+	case c.cliConfig.SIEM.Format != nil:
+		return *c.cliConfig.SIEM.Format
+	case c.fileConfig.SIEM.Format != nil:
+		return *c.fileConfig.SIEM.Format
+	default:
+		return defaultSIEMFormat
+	}
+}
+
+// SIEMFile returns the user-provided path to the optional file where events
+// are recorded in the chosen SIEM format or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) SIEMFile() string {
+
+	switch {
+	case c.cliConfig.SIEM.File != nil:
+		return *c.cliConfig.SIEM.File
+	case c.fileConfig.SIEM.File != nil:
+		return *c.fileConfig.SIEM.File
+	default:
+		return defaultSIEMFile
+	}
+}
+
+// SIEMFilePermissions returns the user-provided permissions for the optional
+// file where events are recorded in the chosen SIEM format or the default
+// value if not provided. CLI flag values take precedence if provided.
+func (c Config) SIEMFilePermissions() os.FileMode {
+
+	switch {
+	case c.cliConfig.SIEM.FilePermissions != nil:
+		return *c.cliConfig.SIEM.FilePermissions
+	case c.fileConfig.SIEM.FilePermissions != nil:
+		return *c.fileConfig.SIEM.FilePermissions
+	default:
+		return defaultSIEMFilePerms
+	}
+}
+
+// SIEMSyslogNetwork returns the user-provided network type used to connect to
+// the syslog daemon receiving SIEM events or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) SIEMSyslogNetwork() string {
+
+	switch {
+	case c.cliConfig.SIEM.SyslogNetwork != nil:
+		return *c.cliConfig.SIEM.SyslogNetwork
+	case c.fileConfig.SIEM.SyslogNetwork != nil:
+		return *c.fileConfig.SIEM.SyslogNetwork
+	default:
+		return defaultSIEMSyslogNetwork
+	}
+}
+
+// SIEMSyslogAddress returns the user-provided address of the optional syslog
+// daemon which should receive SIEM events or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) SIEMSyslogAddress() string {
+
+	switch {
+	case c.cliConfig.SIEM.SyslogAddress != nil:
+		return *c.cliConfig.SIEM.SyslogAddress
+	case c.fileConfig.SIEM.SyslogAddress != nil:
+		return *c.fileConfig.SIEM.SyslogAddress
+	default:
+		return defaultSIEMSyslogAddress
+	}
+}
+
+// SIEMSyslogFacility returns the user-provided syslog facility used for SIEM
+// events sent to the syslog daemon or the default value if not provided. CLI
+// flag values take precedence if provided.
+func (c Config) SIEMSyslogFacility() string {
+
+	switch {
+	case c.cliConfig.SIEM.SyslogFacility != nil:
+		return *c.cliConfig.SIEM.SyslogFacility
+	case c.fileConfig.SIEM.SyslogFacility != nil:
+		return *c.fileConfig.SIEM.SyslogFacility
+	default:
+		return defaultSIEMSyslogFacility
+	}
+}
+
 // IgnoredUsersFile returns the user-provided path to the file containing a
 // list of user accounts which should not be disabled and whose associated IP
 // should not be banned by this application. If not specified, the default
@@ -275,6 +869,64 @@ func (c Config) IgnoreLookupErrors() bool {
 	}
 }
 
+// UsernameLowercase indicates whether reported usernames should be converted
+// to lowercase. The user-provided value is returned or the default value if
+// not provided. CLI flag values take precedence if provided.
+func (c Config) UsernameLowercase() bool {
+	switch {
+	case c.cliConfig.Usernames.Lowercase != nil:
+		return *c.cliConfig.Usernames.Lowercase
+	case c.fileConfig.Usernames.Lowercase != nil:
+		return *c.fileConfig.Usernames.Lowercase
+	default:
+		return defaultUsernameLowercase
+	}
+}
+
+// UsernameStripRealm indicates whether a trailing realm or domain (e.g.,
+// jdoe@example.edu) should be removed from reported usernames. The
+// user-provided value is returned or the default value if not provided. CLI
+// flag values take precedence if provided.
+func (c Config) UsernameStripRealm() bool {
+	switch {
+	case c.cliConfig.Usernames.StripRealm != nil:
+		return *c.cliConfig.Usernames.StripRealm
+	case c.fileConfig.Usernames.StripRealm != nil:
+		return *c.fileConfig.Usernames.StripRealm
+	default:
+		return defaultUsernameStripRealm
+	}
+}
+
+// UsernameStripDomain indicates whether a leading Windows-style domain
+// (e.g., EXAMPLE\jdoe) should be removed from reported usernames. The
+// user-provided value is returned or the default value if not provided. CLI
+// flag values take precedence if provided.
+func (c Config) UsernameStripDomain() bool {
+	switch {
+	case c.cliConfig.Usernames.StripDomain != nil:
+		return *c.cliConfig.Usernames.StripDomain
+	case c.fileConfig.Usernames.StripDomain != nil:
+		return *c.fileConfig.Usernames.StripDomain
+	default:
+		return defaultUsernameStripDomain
+	}
+}
+
+// UsernameAliasFile returns the user-provided path to the file containing
+// alias to canonical username mappings or the default value if not provided.
+// CLI flag values take precedence if provided.
+func (c Config) UsernameAliasFile() string {
+	switch {
+	case c.cliConfig.Usernames.AliasFile != nil:
+		return *c.cliConfig.Usernames.AliasFile
+	case c.fileConfig.Usernames.AliasFile != nil:
+		return *c.fileConfig.Usernames.AliasFile
+	default:
+		return defaultUsernameAliasFile
+	}
+}
+
 // DisabledUsersFileEntrySuffix returns the user-provided disabled users entry
 // suffix or the default value if not provided. CLI flag values take
 // precedence if provided.
@@ -312,63 +964,319 @@ func (c Config) TeamsWebhookURL() string {
 // user-provided rate limit in seconds between Microsoft Teams notifications
 // or the default value if not provided. CLI flag values take precedence if
 // provided.
-func (c Config) TeamsNotificationRateLimit() time.Duration {
+func (c Config) TeamsNotificationRateLimit() time.Duration {
+	var rateLimitSeconds int
+	switch {
+	case c.cliConfig.MSTeams.RateLimit != nil:
+		rateLimitSeconds = *c.cliConfig.MSTeams.RateLimit
+	case c.fileConfig.MSTeams.RateLimit != nil:
+		rateLimitSeconds = *c.fileConfig.MSTeams.RateLimit
+	default:
+		rateLimitSeconds = defaultMSTeamsRateLimit
+	}
+
+	return time.Duration(rateLimitSeconds) * time.Second
+}
+
+// TeamsNotificationRetries returns the user-provided retry limit before
+// giving up on message delivery or the default value if not provided. CLI
+// flag values take precedence if provided.
+func (c Config) TeamsNotificationRetries() int {
+
+	switch {
+	case c.cliConfig.MSTeams.Retries != nil:
+		return *c.cliConfig.MSTeams.Retries
+	case c.fileConfig.MSTeams.Retries != nil:
+		return *c.fileConfig.MSTeams.Retries
+	default:
+		return defaultMSTeamsRetries
+	}
+}
+
+// TeamsNotificationRetryDelay returns the user-provided delay between retry
+// attempts for Microsoft Teams notifications or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) TeamsNotificationRetryDelay() int {
+
+	switch {
+	case c.cliConfig.MSTeams.RetryDelay != nil:
+		return *c.cliConfig.MSTeams.RetryDelay
+	case c.fileConfig.MSTeams.RetryDelay != nil:
+		return *c.fileConfig.MSTeams.RetryDelay
+	default:
+		return defaultMSTeamsRetryDelay
+	}
+}
+
+// NotifyTeams indicates whether or not notifications should be sent to a
+// Microsoft Teams channel.
+func (c Config) NotifyTeams() bool {
+
+	// Assumption: config.validate() has already been called for the existing
+	// instance of the Config type and this method is now being called by
+	// later stages of the codebase to determine only whether an attempt
+	// should be made to send a message to Teams.
+
+	// For now, use the same logic that validate() uses to determine whether
+	// validation checks should be run.
+	return c.TeamsWebhookURL() != "" || len(c.fileConfig.MSTeams.Webhooks) > 0
+
+}
+
+// TeamsMessageFormat returns the user-provided message format used for
+// notifications submitted to the Microsoft Teams webhook URL or the default
+// value if not provided. CLI flag values take precedence if provided.
+func (c Config) TeamsMessageFormat() string {
+
+	switch {
+	case c.cliConfig.MSTeams.MessageFormat != nil:
+		return *c.cliConfig.MSTeams.MessageFormat
+	case c.fileConfig.MSTeams.MessageFormat != nil:
+		return *c.fileConfig.MSTeams.MessageFormat
+	default:
+		return defaultMSTeamsMessageFormat
+	}
+}
+
+// TeamsTextTemplateFile returns the user-provided path to an optional
+// template file used in place of the built-in summary text of Microsoft Teams
+// notifications or the default value if not provided. CLI flag values take
+// precedence if provided.
+func (c Config) TeamsTextTemplateFile() string {
+
+	switch {
+	case c.cliConfig.MSTeams.TextTemplateFile != nil:
+		return *c.cliConfig.MSTeams.TextTemplateFile
+	case c.fileConfig.MSTeams.TextTemplateFile != nil:
+		return *c.fileConfig.MSTeams.TextTemplateFile
+	default:
+		return defaultMSTeamsTextTemplateFile
+	}
+}
+
+// TeamsTargets returns the Microsoft Teams webhook URL (if provided) along
+// with any additional webhook URLs from the configuration file. The message
+// format for each is resolved based on the webhook URL if set to auto.
+func (c Config) TeamsTargets() []TeamsTarget {
+
+	targets := make([]TeamsTarget, 0, 1+len(c.fileConfig.MSTeams.Webhooks))
+
+	if c.TeamsWebhookURL() != "" {
+		targets = append(targets, TeamsTarget{
+			URL:           c.TeamsWebhookURL(),
+			MessageFormat: resolveTeamsMessageFormat(c.TeamsWebhookURL(), c.TeamsMessageFormat()),
+		})
+	}
+
+	for _, webhook := range c.fileConfig.MSTeams.Webhooks {
+
+		var target TeamsTarget
+
+		if webhook.Name != nil {
+			target.Name = *webhook.Name
+		}
+		if webhook.URL != nil {
+			target.URL = *webhook.URL
+		}
+
+		format := defaultMSTeamsMessageFormat
+		if webhook.MessageFormat != nil {
+			format = *webhook.MessageFormat
+		}
+		target.MessageFormat = resolveTeamsMessageFormat(target.URL, format)
+
+		targets = append(targets, target)
+	}
+
+	return targets
+}
+
+// resolveTeamsMessageFormat returns the message format for the provided
+// webhook URL. If the format is auto, Adaptive Cards are selected for Power
+// Automate Workflows webhook URLs and MessageCards for all others.
+func resolveTeamsMessageFormat(webhookURL string, format string) string {
+
+	if format != TeamsMessageFormatAuto {
+		return format
+	}
+
+	if teamsWorkflowURLRegex.MatchString(webhookURL) {
+		return TeamsMessageFormatAdaptiveCard
+	}
+
+	return TeamsMessageFormatMessageCard
+}
+
+// SlackWebhookURL returns the user-provided webhook URL used for Slack
+// notifications or the default value if not provided. CLI flag values take
+// precedence if provided.
+func (c Config) SlackWebhookURL() string {
+
+	switch {
+	case c.cliConfig.Slack.WebhookURL != nil:
+		return *c.cliConfig.Slack.WebhookURL
+	case c.fileConfig.Slack.WebhookURL != nil:
+		return *c.fileConfig.Slack.WebhookURL
+	default:
+		return defaultSlackWebhookURL
+	}
+}
+
+// SlackNotificationRateLimit returns a time.Duration value based on the
+// user-provided rate limit in seconds between Slack notifications or the
+// default value if not provided. CLI flag values take precedence if
+// provided.
+func (c Config) SlackNotificationRateLimit() time.Duration {
 	var rateLimitSeconds int
 	switch {
-	case c.cliConfig.MSTeams.RateLimit != nil:
-		rateLimitSeconds = *c.cliConfig.MSTeams.RateLimit
-	case c.fileConfig.MSTeams.RateLimit != nil:
-		rateLimitSeconds = *c.fileConfig.MSTeams.RateLimit
+	case c.cliConfig.Slack.RateLimit != nil:
+		rateLimitSeconds = *c.cliConfig.Slack.RateLimit
+	case c.fileConfig.Slack.RateLimit != nil:
+		rateLimitSeconds = *c.fileConfig.Slack.RateLimit
 	default:
-		rateLimitSeconds = defaultMSTeamsRateLimit
+		rateLimitSeconds = defaultSlackRateLimit
 	}
 
 	return time.Duration(rateLimitSeconds) * time.Second
 }
 
-// TeamsNotificationRetries returns the user-provided retry limit before
-// giving up on message delivery or the default value if not provided. CLI
+// SlackNotificationRetries returns the user-provided retry limit before
+// giving up on Slack message delivery or the default value if not provided.
+// CLI flag values take precedence if provided.
+func (c Config) SlackNotificationRetries() int {
+
+	switch {
+	case c.cliConfig.Slack.Retries != nil:
+		return *c.cliConfig.Slack.Retries
+	case c.fileConfig.Slack.Retries != nil:
+		return *c.fileConfig.Slack.Retries
+	default:
+		return defaultSlackRetries
+	}
+}
+
+// SlackNotificationRetryDelay returns the user-provided delay between retry
+// attempts for Slack notifications or the default value if not provided. CLI
 // flag values take precedence if provided.
-func (c Config) TeamsNotificationRetries() int {
+func (c Config) SlackNotificationRetryDelay() int {
 
 	switch {
-	case c.cliConfig.MSTeams.Retries != nil:
-		return *c.cliConfig.MSTeams.Retries
-	case c.fileConfig.MSTeams.Retries != nil:
-		return *c.fileConfig.MSTeams.Retries
+	case c.cliConfig.Slack.RetryDelay != nil:
+		return *c.cliConfig.Slack.RetryDelay
+	case c.fileConfig.Slack.RetryDelay != nil:
+		return *c.fileConfig.Slack.RetryDelay
 	default:
-		return defaultMSTeamsRetries
+		return defaultSlackRetryDelay
 	}
 }
 
-// TeamsNotificationRetryDelay returns the user-provided delay between retry
-// attempts for Microsoft Teams notifications or the default value if not
-// provided. CLI flag values take precedence if provided.
-func (c Config) TeamsNotificationRetryDelay() int {
+// SlackTextTemplateFile returns the user-provided path to an optional
+// template file used in place of the built-in summary text of Slack
+// notifications or the default value if not provided. CLI flag values take
+// precedence if provided.
+func (c Config) SlackTextTemplateFile() string {
 
 	switch {
-	case c.cliConfig.MSTeams.RetryDelay != nil:
-		return *c.cliConfig.MSTeams.RetryDelay
-	case c.fileConfig.MSTeams.RetryDelay != nil:
-		return *c.fileConfig.MSTeams.RetryDelay
+	case c.cliConfig.Slack.TextTemplateFile != nil:
+		return *c.cliConfig.Slack.TextTemplateFile
+	case c.fileConfig.Slack.TextTemplateFile != nil:
+		return *c.fileConfig.Slack.TextTemplateFile
 	default:
-		return defaultMSTeamsRetryDelay
+		return defaultSlackTextTemplateFile
 	}
 }
 
-// NotifyTeams indicates whether or not notifications should be sent to a
-// Microsoft Teams channel.
-func (c Config) NotifyTeams() bool {
+// NotifySlack indicates whether or not notifications should be sent to a
+// Slack channel.
+func (c Config) NotifySlack() bool {
+	return c.SlackWebhookURL() != ""
+}
 
-	// Assumption: config.validate() has already been called for the existing
-	// instance of the Config type and this method is now being called by
-	// later stages of the codebase to determine only whether an attempt
-	// should be made to send a message to Teams.
+// WebhookTargets returns the user-provided list of generic webhook targets
+// with default values applied for any settings not provided. Webhook targets
+// may only be specified in the configuration file.
+func (c Config) WebhookTargets() []WebhookTarget {
 
-	// For now, use the same logic that validate() uses to determine whether
-	// validation checks should be run.
-	return c.TeamsWebhookURL() != ""
+	targets := make([]WebhookTarget, 0, len(c.fileConfig.Webhooks))
+
+	for _, webhook := range c.fileConfig.Webhooks {
+
+		target := WebhookTarget{
+			Headers:    webhook.Headers,
+			EventTypes: webhook.EventTypes,
+			Source:     MyAppURL,
+			RateLimit:  defaultWebhookRateLimit,
+			RetryDelay: defaultWebhookRetryDelay,
+			Retries:    defaultWebhookRetries,
+		}
+
+		if webhook.Name != nil {
+			target.Name = *webhook.Name
+		}
+		if webhook.URL != nil {
+			target.URL = *webhook.URL
+		}
+		if webhook.BearerToken != nil {
+			target.BearerToken = *webhook.BearerToken
+		}
+		if webhook.HMACSecret != nil {
+			target.HMACSecret = *webhook.HMACSecret
+		}
+		if webhook.Source != nil && *webhook.Source != "" {
+			target.Source = *webhook.Source
+		}
+		if webhook.RateLimit != nil {
+			target.RateLimit = *webhook.RateLimit
+		}
+		if webhook.RetryDelay != nil {
+			target.RetryDelay = *webhook.RetryDelay
+		}
+		if webhook.Retries != nil {
+			target.Retries = *webhook.Retries
+		}
+
+		targets = append(targets, target)
+	}
+
+	return targets
+}
+
+// NotificationRoutes returns the user-provided list of notification routing
+// rules with default values applied for any settings not provided. Routing
+// rules may only be specified in the configuration file.
+func (c Config) NotificationRoutes() []NotificationRoute {
+
+	routes := make([]NotificationRoute, 0, len(c.fileConfig.Routes))
+
+	for i, route := range c.fileConfig.Routes {
+
+		nr := NotificationRoute{
+			Name:            fmt.Sprintf("route %d", i+1),
+			Actions:         route.Actions,
+			Outcome:         RouteOutcomeAny,
+			AlertNames:      route.AlertNames,
+			Usernames:       route.Usernames,
+			Notifiers:       route.Notifiers,
+			EmailRecipients: route.EmailRecipients,
+		}
+
+		if route.Name != nil && *route.Name != "" {
+			nr.Name = *route.Name
+		}
+		if route.Outcome != nil && *route.Outcome != "" {
+			nr.Outcome = *route.Outcome
+		}
+
+		routes = append(routes, nr)
+	}
 
+	return routes
+}
+
+// NotifyWebhooks indicates whether or not notifications should be sent to
+// one or more generic webhook targets.
+func (c Config) NotifyWebhooks() bool {
+	return len(c.fileConfig.Webhooks) > 0
 }
 
 // NotifyEmail indicates whether or not notifications should be generated and
@@ -517,6 +1425,343 @@ func (c Config) EmailNotificationRetryDelay() int {
 	}
 }
 
+// EmailStartTLS returns the user-provided STARTTLS mode used when connecting
+// to the SMTP server or the default value if not provided. CLI flag values
+// take precedence if provided.
+func (c Config) EmailStartTLS() string {
+	switch {
+	case c.cliConfig.Email.StartTLS != nil:
+		return *c.cliConfig.Email.StartTLS
+	case c.fileConfig.Email.StartTLS != nil:
+		return *c.fileConfig.Email.StartTLS
+	default:
+		return defaultSMTPStartTLS
+	}
+}
+
+// EmailImplicitTLS returns the user-provided choice of whether the connection
+// to the SMTP server is encrypted from the start or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) EmailImplicitTLS() bool {
+	switch {
+	case c.cliConfig.Email.ImplicitTLS != nil:
+		return *c.cliConfig.Email.ImplicitTLS
+	case c.fileConfig.Email.ImplicitTLS != nil:
+		return *c.fileConfig.Email.ImplicitTLS
+	default:
+		return defaultSMTPImplicitTLS
+	}
+}
+
+// EmailAuthMechanism returns the user-provided SMTP authentication mechanism
+// or the default value if not provided. CLI flag values take precedence if
+// provided.
+func (c Config) EmailAuthMechanism() string {
+	switch {
+	case c.cliConfig.Email.AuthMechanism != nil:
+		return *c.cliConfig.Email.AuthMechanism
+	case c.fileConfig.Email.AuthMechanism != nil:
+		return *c.fileConfig.Email.AuthMechanism
+	default:
+		return defaultSMTPAuthMechanism
+	}
+}
+
+// EmailUsername returns the user-provided username used to authenticate to
+// the SMTP server or the default value if not provided. CLI flag values take
+// precedence if provided.
+func (c Config) EmailUsername() string {
+	switch {
+	case c.cliConfig.Email.Username != nil:
+		return *c.cliConfig.Email.Username
+	case c.fileConfig.Email.Username != nil:
+		return *c.fileConfig.Email.Username
+	default:
+		return defaultSMTPUsername
+	}
+}
+
+// EmailPasswordFile returns the user-provided path to the file containing the
+// password used to authenticate to the SMTP server or the default value if
+// not provided. CLI flag values take precedence if provided.
+func (c Config) EmailPasswordFile() string {
+	switch {
+	case c.cliConfig.Email.PasswordFile != nil:
+		return *c.cliConfig.Email.PasswordFile
+	case c.fileConfig.Email.PasswordFile != nil:
+		return *c.fileConfig.Email.PasswordFile
+	default:
+		return defaultSMTPPasswordFile
+	}
+}
+
+// EmailCAFile returns the user-provided path to the PEM encoded bundle of
+// certificate authorities used to verify the SMTP server certificate or the
+// default value if not provided. CLI flag values take precedence if provided.
+func (c Config) EmailCAFile() string {
+	switch {
+	case c.cliConfig.Email.CAFile != nil:
+		return *c.cliConfig.Email.CAFile
+	case c.fileConfig.Email.CAFile != nil:
+		return *c.fileConfig.Email.CAFile
+	default:
+		return defaultSMTPCAFile
+	}
+}
+
+// EmailAttachAlert returns the user-provided choice of whether the alert
+// payload is attached to email notifications or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) EmailAttachAlert() bool {
+	switch {
+	case c.cliConfig.Email.AttachAlert != nil:
+		return *c.cliConfig.Email.AttachAlert
+	case c.fileConfig.Email.AttachAlert != nil:
+		return *c.fileConfig.Email.AttachAlert
+	default:
+		return defaultSMTPAttachAlert
+	}
+}
+
+// EmailBodyTemplate returns the user-provided choice of built-in template
+// used for the plain text part of email notifications or the default value if
+// not provided. CLI flag values take precedence if provided.
+func (c Config) EmailBodyTemplate() string {
+	switch {
+	case c.cliConfig.Email.BodyTemplate != nil:
+		return *c.cliConfig.Email.BodyTemplate
+	case c.fileConfig.Email.BodyTemplate != nil:
+		return *c.fileConfig.Email.BodyTemplate
+	default:
+		return defaultSMTPBodyTemplate
+	}
+}
+
+// EmailSubjectTemplateFile returns the user-provided path to an optional
+// template file used in place of the built-in subject of email notifications
+// or the default value if not provided. CLI flag values take precedence if
+// provided.
+func (c Config) EmailSubjectTemplateFile() string {
+	switch {
+	case c.cliConfig.Email.SubjectTemplateFile != nil:
+		return *c.cliConfig.Email.SubjectTemplateFile
+	case c.fileConfig.Email.SubjectTemplateFile != nil:
+		return *c.fileConfig.Email.SubjectTemplateFile
+	default:
+		return defaultSMTPSubjectTemplateFile
+	}
+}
+
+// EmailBodyTemplateFile returns the user-provided path to an optional
+// template file used in place of the built-in template for the plain text
+// part of email notifications or the default value if not provided. CLI flag
+// values take precedence if provided.
+func (c Config) EmailBodyTemplateFile() string {
+	switch {
+	case c.cliConfig.Email.BodyTemplateFile != nil:
+		return *c.cliConfig.Email.BodyTemplateFile
+	case c.fileConfig.Email.BodyTemplateFile != nil:
+		return *c.fileConfig.Email.BodyTemplateFile
+	default:
+		return defaultSMTPBodyTemplateFile
+	}
+}
+
+// EmailHTMLBodyTemplateFile returns the user-provided path to an optional
+// template file used in place of the built-in template for the HTML part of
+// email notifications or the default value if not provided. CLI flag values
+// take precedence if provided.
+func (c Config) EmailHTMLBodyTemplateFile() string {
+	switch {
+	case c.cliConfig.Email.HTMLBodyTemplateFile != nil:
+		return *c.cliConfig.Email.HTMLBodyTemplateFile
+	case c.fileConfig.Email.HTMLBodyTemplateFile != nil:
+		return *c.fileConfig.Email.HTMLBodyTemplateFile
+	default:
+		return defaultSMTPHTMLBodyTemplateFile
+	}
+}
+
+// OutboxFile returns the user-provided path to the file used to hold
+// notifications until they are delivered or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) OutboxFile() string {
+
+	switch {
+	case c.cliConfig.Outbox.File != nil:
+		return *c.cliConfig.Outbox.File
+	case c.fileConfig.Outbox.File != nil:
+		return *c.fileConfig.Outbox.File
+	default:
+		return defaultOutboxFile
+	}
+}
+
+// OutboxFilePermissions returns the user-provided permissions for the
+// outbox file or the default value if not provided. CLI flag values take
+// precedence if provided.
+func (c Config) OutboxFilePermissions() os.FileMode {
+
+	switch {
+	case c.cliConfig.Outbox.FilePermissions != nil:
+		return *c.cliConfig.Outbox.FilePermissions
+	case c.fileConfig.Outbox.FilePermissions != nil:
+		return *c.fileConfig.Outbox.FilePermissions
+	default:
+		return defaultOutboxFilePerms
+	}
+}
+
+// OutboxMaxAttempts returns the user-provided number of delivery attempts
+// made for each notification before it is moved to the dead-letter list or
+// the default value if not provided. CLI flag values take precedence if
+// provided.
+func (c Config) OutboxMaxAttempts() int {
+
+	switch {
+	case c.cliConfig.Outbox.MaxAttempts != nil:
+		return *c.cliConfig.Outbox.MaxAttempts
+	case c.fileConfig.Outbox.MaxAttempts != nil:
+		return *c.fileConfig.Outbox.MaxAttempts
+	default:
+		return defaultOutboxMaxAttempts
+	}
+}
+
+// OutboxRetryDelay returns the user-provided number of minutes to wait
+// between notification delivery attempts or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) OutboxRetryDelay() int {
+
+	switch {
+	case c.cliConfig.Outbox.RetryDelay != nil:
+		return *c.cliConfig.Outbox.RetryDelay
+	case c.fileConfig.Outbox.RetryDelay != nil:
+		return *c.fileConfig.Outbox.RetryDelay
+	default:
+		return defaultOutboxRetryDelay
+	}
+}
+
+// AggregateNotifications returns the user-provided choice of whether event
+// Records for the same alert are collected and sent as one consolidated
+// notification or the default value if not provided. CLI flag values take
+// precedence if provided.
+func (c Config) AggregateNotifications() bool {
+
+	switch {
+	case c.cliConfig.Aggregation.Enabled != nil:
+		return *c.cliConfig.Aggregation.Enabled
+	case c.fileConfig.Aggregation.Enabled != nil:
+		return *c.fileConfig.Aggregation.Enabled
+	default:
+		return defaultAggregationEnabled
+	}
+}
+
+// AggregationWindow returns the user-provided maximum number of seconds that
+// event Records for an alert are collected before a consolidated
+// notification is sent or the default value if not provided. CLI flag values
+// take precedence if provided.
+func (c Config) AggregationWindow() int {
+
+	switch {
+	case c.cliConfig.Aggregation.Window != nil:
+		return *c.cliConfig.Aggregation.Window
+	case c.fileConfig.Aggregation.Window != nil:
+		return *c.fileConfig.Aggregation.Window
+	default:
+		return defaultAggregationWindow
+	}
+}
+
+// DigestSchedule returns the user-provided digest report schedule or the
+// default value if not provided. CLI flag values take precedence if
+// provided.
+func (c Config) DigestSchedule() string {
+
+	switch {
+	case c.cliConfig.DigestReports.Schedule != nil:
+		return *c.cliConfig.DigestReports.Schedule
+	case c.fileConfig.DigestReports.Schedule != nil:
+		return *c.fileConfig.DigestReports.Schedule
+	default:
+		return defaultDigestSchedule
+	}
+}
+
+// DigestTime returns the user-provided local time of day when digest reports
+// are sent or the default value if not provided. CLI flag values take
+// precedence if provided.
+func (c Config) DigestTime() string {
+
+	switch {
+	case c.cliConfig.DigestReports.Time != nil:
+		return *c.cliConfig.DigestReports.Time
+	case c.fileConfig.DigestReports.Time != nil:
+		return *c.fileConfig.DigestReports.Time
+	default:
+		return defaultDigestTime
+	}
+}
+
+// DigestWeekday returns the user-provided day of the week when weekly digest
+// reports are sent or the default value if not provided. CLI flag values
+// take precedence if provided.
+func (c Config) DigestWeekday() string {
+
+	switch {
+	case c.cliConfig.DigestReports.Weekday != nil:
+		return *c.cliConfig.DigestReports.Weekday
+	case c.fileConfig.DigestReports.Weekday != nil:
+		return *c.fileConfig.DigestReports.Weekday
+	default:
+		return defaultDigestWeekday
+	}
+}
+
+// DigestTop returns the user-provided maximum number of alert names and
+// repeat offenders listed in digest reports or the default value if not
+// provided. CLI flag values take precedence if provided.
+func (c Config) DigestTop() int {
+
+	switch {
+	case c.cliConfig.DigestReports.Top != nil:
+		return *c.cliConfig.DigestReports.Top
+	case c.fileConfig.DigestReports.Top != nil:
+		return *c.fileConfig.DigestReports.Top
+	default:
+		return defaultDigestTop
+	}
+}
+
+// DeadLettersCommand returns the deadletters subcommand action requested by
+// the user or an empty string if the deadletters subcommand was not
+// specified.
+func (c Config) DeadLettersCommand() string {
+
+	switch {
+	case c.cliConfig.DeadLetters == nil:
+		return ""
+	case c.cliConfig.DeadLetters.Retry != nil:
+		return DeadLettersCommandRetry
+	default:
+		return DeadLettersCommandList
+	}
+}
+
+// DeadLettersRetryIDs returns the IDs of the dead-letter notifications the
+// user requested to retry. All dead-letter notifications are to be retried
+// if empty.
+func (c Config) DeadLettersRetryIDs() []string {
+
+	if c.cliConfig.DeadLetters == nil || c.cliConfig.DeadLetters.Retry == nil {
+		return nil
+	}
+
+	return c.cliConfig.DeadLetters.Retry.IDs
+}
+
 // EZproxyExecutablePath returns the user-provided, fully-qualified path to
 // the EZproxy executable or the default value if not provided. CLI flag
 // values take precedence if provided.
@@ -601,3 +1846,99 @@ func (c Config) EZproxyTerminateSessions() bool {
 		return defaultEZproxyTerminateSessions
 	}
 }
+
+// EZproxyUser returns the user-provided OS user account that the EZproxy
+// daemon runs as or the default value if not provided. CLI flag values take
+// precedence if provided.
+func (c Config) EZproxyUser() string {
+
+	switch {
+	case c.cliConfig.EZproxy.User != nil:
+		return *c.cliConfig.EZproxy.User
+	case c.fileConfig.EZproxy.User != nil:
+		return *c.fileConfig.EZproxy.User
+	default:
+		return defaultEZproxyUser
+	}
+}
+
+// DigestCommand indicates whether the digest subcommand was specified.
+func (c Config) DigestCommand() bool {
+	return c.cliConfig.Digest != nil
+}
+
+// DigestCommandPeriod returns the period covered by the digest report
+// requested using the digest subcommand. If not specified, the period of
+// scheduled digest reports is used or daily if digest reports are not
+// scheduled.
+func (c Config) DigestCommandPeriod() string {
+
+	switch {
+	case c.cliConfig.Digest != nil && c.cliConfig.Digest.Period != nil:
+		return *c.cliConfig.Digest.Period
+	case c.DigestSchedule() == DigestScheduleWeekly:
+		return DigestScheduleWeekly
+	default:
+		return DigestScheduleDaily
+	}
+}
+
+// DigestCommandSend indicates whether the digest report requested using the
+// digest subcommand is sent using the configured notifiers instead of being
+// written to stdout.
+func (c Config) DigestCommandSend() bool {
+	return c.cliConfig.Digest != nil && c.cliConfig.Digest.Send
+}
+
+// PreviewCommand indicates whether the preview subcommand was specified.
+func (c Config) PreviewCommand() bool {
+	return c.cliConfig.Preview != nil
+}
+
+// PreviewCommandPayloadFile returns the path to the sample alert payload file
+// specified for the preview subcommand.
+func (c Config) PreviewCommandPayloadFile() string {
+
+	if c.cliConfig.Preview == nil {
+		return ""
+	}
+
+	return c.cliConfig.Preview.PayloadFile
+}
+
+// PreviewCommandAction returns the short name of the simulated action
+// specified for the preview subcommand or the default value if not
+// specified.
+func (c Config) PreviewCommandAction() string {
+
+	switch {
+	case c.cliConfig.Preview != nil && c.cliConfig.Preview.Action != nil:
+		return *c.cliConfig.Preview.Action
+	default:
+		return defaultPreviewAction
+	}
+}
+
+// PreviewCommandNotifier returns the notification type rendered by the
+// preview subcommand or the default value if not specified.
+func (c Config) PreviewCommandNotifier() string {
+
+	switch {
+	case c.cliConfig.Preview != nil && c.cliConfig.Preview.Notifier != nil:
+		return *c.cliConfig.Preview.Notifier
+	default:
+		return defaultPreviewNotifier
+	}
+}
+
+// PreviewCommandOutputDir returns the directory where notifications rendered
+// by the preview subcommand are written or an empty string if they are
+// written to stdout.
+func (c Config) PreviewCommandOutputDir() string {
+
+	if c.cliConfig.Preview == nil || c.cliConfig.Preview.OutputDir == nil {
+		return ""
+	}
+
+	return *c.cliConfig.Preview.OutputDir
+}
@@ -17,7 +17,10 @@
 package config
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/cli"
@@ -25,11 +28,16 @@ import (
 	"github.com/apex/log/handlers/json"
 	"github.com/apex/log/handlers/logfmt"
 	"github.com/apex/log/handlers/text"
+
+	"github.com/atc0005/brick/internal/logfile"
+	"github.com/atc0005/brick/internal/syslog"
 )
 
 // configureLogging is a wrapper function to enable setting requested logging
-// settings.
-func (c Config) configureLogging() {
+// settings. An error is returned if syslog or file is the chosen logging
+// output and the syslog daemon or log file could not be opened; log messages
+// are sent to stdout in that case.
+func (c *Config) configureLogging() error {
 
 	switch c.LogLevel() {
 	case LogLevelFatal:
@@ -44,28 +52,102 @@ func (c Config) configureLogging() {
 		log.SetLevel(log.DebugLevel)
 	}
 
-	// Apply user-specified logging output target
-	var outputTarget *os.File
-	switch c.LogOutput() {
-	case LogOutputStdout:
-		outputTarget = os.Stdout
-	case LogOutputStderr:
-		outputTarget = os.Stderr
-	default:
-		outputTarget = os.Stdout
+	// Close the connection to the syslog daemon or log file opened when
+	// logging settings were last applied (if any).
+	if c.logSyslogWriter != nil {
+		if err := c.logSyslogWriter.Close(); err != nil {
+			log.Errorf("failed to close syslog log output: %s", err)
+		}
+		c.logSyslogWriter = nil
+	}
+	if c.logFile != nil {
+		if err := c.logFile.Close(); err != nil {
+			log.Errorf("failed to close log file output: %s", err)
+		}
+		c.logFile = nil
 	}
 
+	// The same formatting handlers are used for all output targets; for
+	// syslog they are used to render the message body.
+	var newHandler func(w io.Writer) log.Handler
 	switch c.LogFormat() {
 	case LogFormatText:
-		log.SetHandler(text.New(outputTarget))
+		newHandler = func(w io.Writer) log.Handler { return text.New(w) }
 	case LogFormatCLI:
-		log.SetHandler(cli.New(outputTarget))
+		newHandler = func(w io.Writer) log.Handler { return cli.New(w) }
 	case LogFormatLogFmt:
-		log.SetHandler(logfmt.New(outputTarget))
+		newHandler = func(w io.Writer) log.Handler { return logfmt.New(w) }
 	case LogFormatJSON:
-		log.SetHandler(json.New(outputTarget))
+		newHandler = func(w io.Writer) log.Handler { return json.New(w) }
 	case LogFormatDiscard:
 		log.SetHandler(discard.New())
+		return nil
+	default:
+		return nil
+	}
+
+	// Apply user-specified logging output target
+	switch c.LogOutput() {
+	case LogOutputStdout:
+		log.SetHandler(newHandler(os.Stdout))
+	case LogOutputStderr:
+		log.SetHandler(newHandler(os.Stderr))
+	case LogOutputSyslog:
+		facility, err := syslog.ParseFacility(c.LogSyslogFacility())
+		if err != nil {
+			log.SetHandler(newHandler(os.Stdout))
+			return fmt.Errorf("failed to configure syslog log output: %w", err)
+		}
+
+		w, err := syslog.Dial(
+			c.LogSyslogNetwork(),
+			c.LogSyslogAddress(),
+			facility,
+			c.LogSyslogAppName(),
+		)
+		if err != nil {
+			log.SetHandler(newHandler(os.Stdout))
+			return fmt.Errorf("failed to configure syslog log output: %w", err)
+		}
+
+		c.logSyslogWriter = w
+		log.SetHandler(syslog.NewHandler(w, newHandler))
+	case LogOutputFile:
+		f, err := logfile.Open(
+			c.LogFile(),
+			c.LogFilePermissions(),
+			logfile.Options{
+				MaxSize:        int64(c.LogFileMaxSize()) * 1024 * 1024,
+				RotateInterval: time.Duration(c.LogFileRotateInterval()) * time.Hour,
+				MaxAge:         time.Duration(c.LogFileMaxAge()) * 24 * time.Hour,
+				MaxBackups:     c.LogFileMaxBackups(),
+				Compress:       c.LogFileCompress(),
+			},
+		)
+		if err != nil {
+			log.SetHandler(newHandler(os.Stdout))
+			return fmt.Errorf("failed to configure file log output: %w", err)
+		}
+
+		c.logFile = f
+		log.SetHandler(newHandler(f))
+	default:
+		log.SetHandler(newHandler(os.Stdout))
+	}
+
+	return nil
+
+}
+
+// ReopenLogFiles closes and reopens the log file used when file is the
+// chosen logging output. This is intended to be called after the log file
+// has been rotated by an external tool such as logrotate. This is a no-op if
+// another logging output is in use.
+func (c *Config) ReopenLogFiles() error {
+
+	if c.logFile == nil {
+		return nil
 	}
 
+	return c.logFile.Reopen()
 }
@@ -20,6 +20,9 @@ import (
 	"os"
 
 	"github.com/alexflint/go-arg"
+
+	"github.com/atc0005/brick/internal/logfile"
+	"github.com/atc0005/brick/internal/syslog"
 )
 
 // Config is a unified set of configuration values for this application. This
@@ -35,6 +38,16 @@ type Config struct {
 	fileConfig configTemplate
 
 	flagParser *arg.Parser `toml:"-" arg:"-"`
+
+	// logSyslogWriter is the connection to the syslog daemon used when
+	// syslog is the chosen logging output. This is retained so that the
+	// connection can be closed if logging settings are reapplied.
+	logSyslogWriter *syslog.Writer `toml:"-" arg:"-"`
+
+	// logFile is the log file used when file is the chosen logging output.
+	// This is retained so that the file can be reopened on request or closed
+	// if logging settings are reapplied.
+	logFile *logfile.File `toml:"-" arg:"-"`
 }
 
 // Network is a collection of network-related settings provided via CLI and
@@ -56,20 +69,82 @@ type Network struct {
 	TrustedIPAddresses []string `toml:"trusted_ip_addresses" arg:"--trusted-ip-addresses,env:BRICK_TRUSTED_IP_ADDRESSES" help:"One or many single IP Addresses which are trusted for payload submission. If this is defined, all other sender IPs are ignored. If this is not defined, payloads are accepted from all IP Addresses not otherwise rejected by local/remote firewall rules."`
 }
 
+// Metrics is a collection of settings for the Prometheus metrics endpoint
+// provided via CLI and config file sources.
+type Metrics struct {
+
+	// Enabled indicates whether the metrics endpoint is exposed.
+	Enabled *bool `toml:"enabled" arg:"--metrics-enabled,env:BRICK_METRICS_ENABLED" help:"Whether metrics are exposed in the Prometheus text format on the /metrics endpoint."`
+
+	// LocalTCPPort is the TCP port of an optional, separate listener for
+	// the metrics endpoint. If not set, the metrics endpoint is exposed on
+	// the main listener.
+	LocalTCPPort *int `toml:"local_tcp_port" arg:"--metrics-port,env:BRICK_METRICS_LOCAL_TCP_PORT" help:"TCP port of an optional, separate listener for the /metrics endpoint. If not specified, the /metrics endpoint is exposed on the main listener."`
+
+	// LocalIPAddress is the IP Address that the separate metrics listener
+	// should listen on.
+	LocalIPAddress *string `toml:"local_ip_address" arg:"--metrics-ip-address,env:BRICK_METRICS_LOCAL_IP_ADDRESS" help:"Local IP Address that the separate /metrics listener should listen on. Only used if a metrics port is specified."`
+}
+
 // Logging is a collection of logging-related settings provided via CLI and
 // config file sources.
 type Logging struct {
 	// Level is the chosen logging level
 	Level *string `toml:"level" arg:"--log-level,env:BRICK_LOG_LEVEL" help:"Log message priority filter. Log messages with a lower level are ignored."`
 
-	// Output is one of the standard application outputs, stdout or stderr
-	Output *string `toml:"output" arg:"--log-output,env:BRICK_LOG_OUTPUT" help:"Log messages are written to this output target."`
+	// Output is one of the standard application outputs, stdout or stderr,
+	// a syslog daemon or a log file
+	Output *string `toml:"output" arg:"--log-output,env:BRICK_LOG_OUTPUT" help:"Log messages are written to this output target; one of stdout, stderr, syslog or file."`
 
 	// LogFormat controls which output format is used for log messages
 	// generated by this application. This value is from a smaller subset
 	// of the formats supported by the third-party leveled-logging package
 	// used by this application.
 	Format *string `toml:"format" arg:"--log-format,env:BRICK_LOG_FORMAT" help:"Log messages are written in this format."`
+
+	// SyslogNetwork is the network type used to connect to the syslog daemon
+	// when syslog is the chosen logging output.
+	SyslogNetwork *string `toml:"syslog_network" arg:"--log-syslog-network,env:BRICK_LOG_SYSLOG_NETWORK" help:"Network type used to connect to the syslog daemon when syslog is the chosen log output; one of udp, tcp, unix or unixgram."`
+
+	// SyslogAddress is the address of the syslog daemon when syslog is the
+	// chosen logging output.
+	SyslogAddress *string `toml:"syslog_address" arg:"--log-syslog-address,env:BRICK_LOG_SYSLOG_ADDRESS" help:"Address (host:port or socket path) of the syslog daemon when syslog is the chosen log output."`
+
+	// SyslogFacility is the syslog facility used for log messages when syslog
+	// is the chosen logging output.
+	SyslogFacility *string `toml:"syslog_facility" arg:"--log-syslog-facility,env:BRICK_LOG_SYSLOG_FACILITY" help:"Syslog facility used for log messages when syslog is the chosen log output (e.g., daemon, local0)."`
+
+	// SyslogAppName is the APP-NAME (aka, tag or program name) used for log
+	// messages when syslog is the chosen logging output.
+	SyslogAppName *string `toml:"syslog_app_name" arg:"--log-syslog-app-name,env:BRICK_LOG_SYSLOG_APP_NAME" help:"APP-NAME (aka, tag or program name) used for log messages when syslog is the chosen log output."`
+
+	// File is the fully-qualified path to the log file used when file is the
+	// chosen logging output.
+	File *string `toml:"file_path" arg:"--log-file,env:BRICK_LOG_FILE" help:"Fully-qualified path to the log file used when file is the chosen log output."`
+
+	// FilePermissions is the desired file permissions when the log file is
+	// created.
+	FilePermissions *os.FileMode `toml:"file_permissions" arg:"--log-file-perms,env:BRICK_LOG_FILE_PERMISSIONS" help:"Desired file permissions when the log file is created."`
+
+	// FileMaxSize is the size in megabytes the log file may reach before it
+	// is rotated. A value of 0 disables size based rotation.
+	FileMaxSize *int `toml:"file_max_size" arg:"--log-file-max-size,env:BRICK_LOG_FILE_MAX_SIZE" help:"Size in megabytes the log file may reach before it is rotated. A value of 0 disables size based rotation."`
+
+	// FileRotateInterval is the number of hours the log file is written to
+	// before it is rotated. A value of 0 disables time based rotation.
+	FileRotateInterval *int `toml:"file_rotate_interval" arg:"--log-file-rotate-interval,env:BRICK_LOG_FILE_ROTATE_INTERVAL" help:"Number of hours the log file is written to before it is rotated. A value of 0 disables time based rotation."`
+
+	// FileMaxAge is the number of days rotated log files are retained. A
+	// value of 0 disables removal based on age.
+	FileMaxAge *int `toml:"file_max_age" arg:"--log-file-max-age,env:BRICK_LOG_FILE_MAX_AGE" help:"Number of days rotated log files are retained. A value of 0 disables removal based on age."`
+
+	// FileMaxBackups is the number of rotated log files retained. A value of
+	// 0 disables removal based on count.
+	FileMaxBackups *int `toml:"file_max_backups" arg:"--log-file-max-backups,env:BRICK_LOG_FILE_MAX_BACKUPS" help:"Number of rotated log files retained. A value of 0 disables removal based on count."`
+
+	// FileCompress controls whether rotated log files are compressed using
+	// gzip.
+	FileCompress *bool `toml:"file_compress" arg:"--log-file-compress,env:BRICK_LOG_FILE_COMPRESS" help:"Whether rotated log files are compressed using gzip."`
 }
 
 // DisabledUsers represents the path to, and permissions for, the file
@@ -88,6 +163,19 @@ type DisabledUsers struct {
 	// Permissions is the desired file permissions when this file is created.
 	// Note: The ezproxy daemon will need to be able to read this file.
 	FilePermissions *os.FileMode `toml:"file_permissions" arg:"--disabled-users-file-perms,env:BRICK_DISABLED_USERS_FILE_PERMISSIONS" help:"Desired file permissions when this file is created. Note: The ezproxy daemon will need to be able to read this file."`
+
+	// FileOwner is the OS user account which should own this file. The
+	// ownership of the file is applied at startup.
+	FileOwner *string `toml:"file_owner" arg:"--disabled-users-file-owner,env:BRICK_DISABLED_USERS_FILE_OWNER" help:"OS user account which should own the disabled users file. Ownership is applied at startup. If not specified, ownership is not changed."`
+
+	// FileGroup is the OS group which should own this file. The group
+	// ownership of the file is applied at startup.
+	FileGroup *string `toml:"file_group" arg:"--disabled-users-file-group,env:BRICK_DISABLED_USERS_FILE_GROUP" help:"OS group which should own the disabled users file. Group ownership is applied at startup. If not specified, group ownership is not changed."`
+
+	// TemplateFile is the fully-qualified path to an optional template file
+	// used in place of the built-in template when writing entries to the
+	// disabled users file.
+	TemplateFile *string `toml:"template_file" arg:"--disabled-users-template-file,env:BRICK_DISABLED_USERS_TEMPLATE_FILE" help:"Fully-qualified path to an optional template file used in place of the built-in template when writing entries to the disabled users file."`
 }
 
 // ReportedUsers represents the path to, and permissions for, the file
@@ -102,6 +190,138 @@ type ReportedUsers struct {
 	// Permissions is the desired file permissions when this file is created.
 	// Note: fail2ban will need to be able to read this file.
 	LogFilePermissions *os.FileMode `toml:"file_permissions" arg:"--reported-users-log-file-perms,env:BRICK_REPORTED_USERS_LOG_FILE_PERMISSIONS" help:"Desired file permissions when this file is created. Note: fail2ban will need to be able to read this file."`
+
+	// LogFileOwner is the OS user account which should own this file. The
+	// ownership of the file is applied at startup.
+	LogFileOwner *string `toml:"file_owner" arg:"--reported-users-log-file-owner,env:BRICK_REPORTED_USERS_LOG_FILE_OWNER" help:"OS user account which should own the reported users log file. Ownership is applied at startup. If not specified, ownership is not changed."`
+
+	// LogFileGroup is the OS group which should own this file. The group
+	// ownership of the file is applied at startup.
+	LogFileGroup *string `toml:"file_group" arg:"--reported-users-log-file-group,env:BRICK_REPORTED_USERS_LOG_FILE_GROUP" help:"OS group which should own the reported users log file. Group ownership is applied at startup. If not specified, group ownership is not changed."`
+
+	// ReportTemplateFile is the fully-qualified path to an optional template
+	// file used in place of the built-in template for the log line written
+	// when a user account is reported.
+	ReportTemplateFile *string `toml:"report_template_file" arg:"--reported-users-report-template-file,env:BRICK_REPORTED_USERS_REPORT_TEMPLATE_FILE" help:"Fully-qualified path to an optional template file used in place of the built-in template for the log line written when a user account is reported."`
+
+	// DisabledTemplateFile is the fully-qualified path to an optional
+	// template file used in place of the built-in template for the log line
+	// written when a user account is disabled.
+	DisabledTemplateFile *string `toml:"disabled_template_file" arg:"--reported-users-disabled-template-file,env:BRICK_REPORTED_USERS_DISABLED_TEMPLATE_FILE" help:"Fully-qualified path to an optional template file used in place of the built-in template for the log line written when a user account is disabled."`
+
+	// AlreadyDisabledTemplateFile is the fully-qualified path to an optional
+	// template file used in place of the built-in template for the log line
+	// written when a user account is reported again after it was already
+	// disabled.
+	AlreadyDisabledTemplateFile *string `toml:"already_disabled_template_file" arg:"--reported-users-already-disabled-template-file,env:BRICK_REPORTED_USERS_ALREADY_DISABLED_TEMPLATE_FILE" help:"Fully-qualified path to an optional template file used in place of the built-in template for the log line written when a user account is reported again after it was already disabled."`
+
+	// IgnoredTemplateFile is the fully-qualified path to an optional template
+	// file used in place of the built-in template for the log line written
+	// when a user account or IP Address is ignored.
+	IgnoredTemplateFile *string `toml:"ignored_template_file" arg:"--reported-users-ignored-template-file,env:BRICK_REPORTED_USERS_IGNORED_TEMPLATE_FILE" help:"Fully-qualified path to an optional template file used in place of the built-in template for the log line written when a user account or IP Address is ignored."`
+
+	// TerminatedTemplateFile is the fully-qualified path to an optional
+	// template file used in place of the built-in template for the log line
+	// written when a user session is terminated.
+	TerminatedTemplateFile *string `toml:"terminated_template_file" arg:"--reported-users-terminated-template-file,env:BRICK_REPORTED_USERS_TERMINATED_TEMPLATE_FILE" help:"Fully-qualified path to an optional template file used in place of the built-in template for the log line written when a user session is terminated."`
+}
+
+// Journal represents the path to, and permissions for, the journal file
+// where this application records each accepted disable request until
+// processing of that request is complete.
+type Journal struct {
+
+	// File is the fully-qualified path to the journal file. Accepted disable
+	// requests are recorded in this file before the payload sender is told
+	// that the payload was received. Unfinished requests found in this file
+	// at startup are processed again. The journal is disabled if set to an
+	// empty value.
+	File *string `toml:"file_path" arg:"--journal-file,env:BRICK_JOURNAL_FILE" help:"Fully-qualified path to the journal file where accepted disable requests are recorded until processing is complete. Unfinished requests are processed again at startup. Set to an empty value to disable."`
+
+	// FilePermissions is the desired file permissions when this file is
+	// created.
+	FilePermissions *os.FileMode `toml:"file_permissions" arg:"--journal-file-perms,env:BRICK_JOURNAL_FILE_PERMISSIONS" help:"Desired file permissions when this file is created."`
+}
+
+// Dedup is a collection of settings used to detect repeated deliveries of
+// the same alert (e.g., Splunk retries or multiple search heads sending the
+// same alert).
+type Dedup struct {
+
+	// TTL is the number of minutes an alert is remembered after it is first
+	// received. Alerts with the same search ID and username received within
+	// this period are treated as duplicates. A value of 0 disables duplicate
+	// detection.
+	TTL *int `toml:"ttl" arg:"--dedup-ttl,env:BRICK_DEDUP_TTL" help:"Number of minutes an alert is remembered after it is first received. Alerts with the same search ID and username received within this period are treated as duplicates and not processed again. A value of 0 disables duplicate detection."`
+
+	// File is the fully-qualified path to an optional file used to persist
+	// remembered alerts across restarts. Remembered alerts are held in
+	// memory only if not specified.
+	File *string `toml:"file_path" arg:"--dedup-file,env:BRICK_DEDUP_FILE" help:"Fully-qualified path to an optional file used to remember alerts across restarts. Alerts are remembered in memory only if not specified."`
+
+	// FilePermissions is the desired file permissions when this file is
+	// created.
+	FilePermissions *os.FileMode `toml:"file_permissions" arg:"--dedup-file-perms,env:BRICK_DEDUP_FILE_PERMISSIONS" help:"Desired file permissions when this file is created."`
+}
+
+// StaleAlerts is a collection of settings used to handle alerts which report
+// user activity that occurred long before the alert was received.
+type StaleAlerts struct {
+
+	// MaxAge is the number of minutes after the reported user activity that
+	// an alert is still acted upon. A value of 0 disables stale and
+	// future-dated alert detection.
+	MaxAge *int `toml:"max_age" arg:"--stale-alert-max-age,env:BRICK_STALE_ALERT_MAX_AGE" help:"Number of minutes after the reported user activity that an alert is still acted upon. Alerts reporting older (or future-dated) user activity are handled as specified by the stale alert action. A value of 0 disables stale alert detection."`
+
+	// Action is the action applied to stale or future-dated alerts.
+	Action *string `toml:"action" arg:"--stale-alert-action,env:BRICK_STALE_ALERT_ACTION" help:"Action applied to stale or future-dated alerts. Supported options are \"reject\" (alert is rejected and not recorded) and \"report\" (alert is recorded and reported, but the user account is not disabled)."`
+}
+
+// JSONEventsLog represents the path to, and permissions for, the optional
+// log file generated by this application where every event is recorded as a
+// single JSON object per line for consumption by external tooling.
+type JSONEventsLog struct {
+
+	// File is the fully-qualified path to the log file where this application
+	// should record events in JSON format. This log file is disabled if not
+	// specified.
+	File *string `toml:"file_path" arg:"--json-events-log-file,env:BRICK_JSON_EVENTS_LOG_FILE" help:"Fully-qualified path to an optional log file where this application should record every event as a single JSON object per line. Not enabled unless specified."`
+
+	// FilePermissions is the desired file permissions when this file is
+	// created.
+	FilePermissions *os.FileMode `toml:"file_permissions" arg:"--json-events-log-file-perms,env:BRICK_JSON_EVENTS_LOG_FILE_PERMISSIONS" help:"Desired file permissions when this file is created."`
+}
+
+// SIEM is a collection of settings used to record events in a format
+// suitable for ingestion by a SIEM. Events may be written to a file, sent to
+// a syslog daemon or both. This output is disabled unless a file or syslog
+// address is specified.
+type SIEM struct {
+
+	// Format is the output format used for each event; one of ArcSight
+	// Common Event Format (CEF) or IBM QRadar Log Event Extended Format
+	// (LEEF).
+	Format *string `toml:"format" arg:"--siem-format,env:BRICK_SIEM_FORMAT" help:"Output format used to record events for SIEM ingestion; one of cef or leef."`
+
+	// File is the fully-qualified path to the file where this application
+	// should record events for SIEM ingestion.
+	File *string `toml:"file_path" arg:"--siem-file,env:BRICK_SIEM_FILE" help:"Fully-qualified path to an optional file where this application should record events in the chosen SIEM format. Not enabled unless specified."`
+
+	// FilePermissions is the desired file permissions when this file is
+	// created.
+	FilePermissions *os.FileMode `toml:"file_permissions" arg:"--siem-file-perms,env:BRICK_SIEM_FILE_PERMISSIONS" help:"Desired file permissions when the SIEM events file is created."`
+
+	// SyslogNetwork is the network type used to connect to the syslog
+	// daemon.
+	SyslogNetwork *string `toml:"syslog_network" arg:"--siem-syslog-network,env:BRICK_SIEM_SYSLOG_NETWORK" help:"Network type used to connect to the syslog daemon receiving SIEM events; one of udp, tcp, unix or unixgram."`
+
+	// SyslogAddress is the address of the syslog daemon which should receive
+	// events in the chosen SIEM format.
+	SyslogAddress *string `toml:"syslog_address" arg:"--siem-syslog-address,env:BRICK_SIEM_SYSLOG_ADDRESS" help:"Address (host:port or socket path) of an optional syslog daemon which should receive events in the chosen SIEM format. Not enabled unless specified."`
+
+	// SyslogFacility is the syslog facility used for events sent to the
+	// syslog daemon.
+	SyslogFacility *string `toml:"syslog_facility" arg:"--siem-syslog-facility,env:BRICK_SIEM_SYSLOG_FACILITY" help:"Syslog facility used for SIEM events sent to the syslog daemon (e.g., auth, authpriv, daemon, local0)."`
 }
 
 // IgnoredUsers represents the fully-qualified path to the file containing a
@@ -131,6 +351,29 @@ type IgnoredIPAddresses struct {
 	File *string `toml:"file_path" arg:"--ignored-ips-file,env:BRICK_IGNORED_IP_ADDRESSES_FILE" help:"Fully-qualified path to the file containing a list of individual IP Addresses which should not be disabled and which user account reported in the same alert should not be disabled by this application. Leading and trailing whitespace per line is ignored."`
 }
 
+// Usernames represents the various configuration settings used to normalize
+// usernames reported via alert payloads. Normalization is applied once when
+// the alert is received and the result is used for all later ignore checks,
+// disabled status checks and file entries.
+type Usernames struct {
+
+	// Lowercase controls whether reported usernames are converted to
+	// lowercase.
+	Lowercase *bool `toml:"lowercase" arg:"--username-lowercase,env:BRICK_USERNAME_LOWERCASE" help:"Whether reported usernames are converted to lowercase."`
+
+	// StripRealm controls whether a trailing realm or domain (e.g.,
+	// jdoe@example.edu) is removed from reported usernames.
+	StripRealm *bool `toml:"strip_realm" arg:"--username-strip-realm,env:BRICK_USERNAME_STRIP_REALM" help:"Whether a trailing realm or domain (e.g., jdoe@example.edu) is removed from reported usernames."`
+
+	// StripDomain controls whether a leading Windows-style domain (e.g.,
+	// EXAMPLE\jdoe) is removed from reported usernames.
+	StripDomain *bool `toml:"strip_domain" arg:"--username-strip-domain,env:BRICK_USERNAME_STRIP_DOMAIN" help:"Whether a leading Windows-style domain (e.g., EXAMPLE\\jdoe) is removed from reported usernames."`
+
+	// AliasFile is the fully-qualified path to an optional file containing
+	// alias to canonical username mappings, one pair per line.
+	AliasFile *string `toml:"alias_file" arg:"--username-alias-file,env:BRICK_USERNAME_ALIAS_FILE" help:"Fully-qualified path to an optional file containing alias to canonical username mappings, one whitespace-separated pair per line. Lines beginning with a '#' character are ignored. Aliases are matched after other normalization steps are applied."`
+}
+
 // MSTeams represents the various configuration settings used to send
 // notifications to a Microsoft Teams channel.
 type MSTeams struct {
@@ -158,6 +401,187 @@ type MSTeams struct {
 	// Retries is the number of attempts that this application will make to
 	// deliver Microsoft Teams messages before giving up.
 	Retries *int `toml:"retries" arg:"--teams-notify-retries,env:BRICK_MSTEAMS_WEBHOOK_RETRIES" help:"The number of attempts that this application will make to deliver Microsoft Teams messages before giving up."`
+
+	// MessageFormat is the message format used for notifications submitted
+	// to the webhook URL. The format is selected based on the webhook URL
+	// unless specified.
+	MessageFormat *string `toml:"message_format" arg:"--teams-message-format,env:BRICK_MSTEAMS_MESSAGE_FORMAT" help:"The message format used for Microsoft Teams notifications. Adaptive Cards are required by Power Automate Workflows webhook URLs while MessageCards are required by (legacy) Connector webhook URLs. If set to auto, the format is selected based on the webhook URL."`
+
+	// TextTemplateFile is the fully-qualified path to an optional template
+	// file used in place of the built-in summary text of Microsoft Teams
+	// notifications.
+	TextTemplateFile *string `toml:"text_template_file" arg:"--teams-text-template-file,env:BRICK_MSTEAMS_TEXT_TEMPLATE_FILE" help:"Fully-qualified path to an optional template file used in place of the built-in summary text of Microsoft Teams notifications."`
+
+	// Webhooks is an optional list of additional Microsoft Teams webhook
+	// URLs, each with a separate message format. This is intended to help
+	// with the move from Connectors to Workflows. Additional webhook URLs may
+	// only be specified in the configuration file.
+	Webhooks []TeamsWebhook `toml:"webhooks" arg:"-"`
+}
+
+// TeamsWebhook represents an additional Microsoft Teams webhook URL.
+type TeamsWebhook struct {
+
+	// Name uniquely identifies the webhook URL. This name is used to
+	// identify the webhook URL in log messages, metrics and the outbox.
+	Name *string `toml:"name"`
+
+	// URL is the full URL used to submit messages to the Teams channel.
+	URL *string `toml:"url"`
+
+	// MessageFormat is the message format used for notifications submitted
+	// to the webhook URL.
+	MessageFormat *string `toml:"message_format"`
+}
+
+// TeamsTarget is a Microsoft Teams webhook URL with the message format used
+// for notifications submitted to it. The Name is empty for the webhook URL
+// specified by the teams-webhook-url flag.
+type TeamsTarget struct {
+	Name          string
+	URL           string
+	MessageFormat string
+}
+
+// Slack represents the various configuration settings used to send
+// notifications to a Slack channel.
+type Slack struct {
+
+	// WebhookURL is the full URL used to submit messages to the Slack
+	// channel. This URL is in the form of
+	// https://hooks.slack.com/services/xxx and needs to be created in advance
+	// by adding an Incoming Webhook to a Slack app for the workspace.
+	WebhookURL *string `toml:"webhook_url" arg:"--slack-webhook-url,env:BRICK_SLACK_WEBHOOK_URL" help:"The Incoming Webhook URL provided by a Slack app. If specified, this application will attempt to send client request details to the Slack channel associated with the webhook URL."`
+
+	// RateLimit is the number of seconds to wait between Slack notification
+	// attempts. This rate limit is intended to help prevent unintentional
+	// abuse of remote services and is applied regardless of whether the last
+	// notification attempt was initially successful or required one or more
+	// retry attempts.
+	RateLimit *int `toml:"rate_limit" arg:"--slack-notify-rate-limit,env:BRICK_SLACK_WEBHOOK_RATE_LIMIT" help:"The number of seconds to wait between Slack notification attempts. This rate limit is intended to help prevent unintentional abuse of remote services and is applied regardless of whether the last notification attempt was initially successful or required one or more retry attempts."`
+
+	// RetryDelay is the number of seconds to wait between Slack message
+	// delivery retry attempts.
+	RetryDelay *int `toml:"retry_delay" arg:"--slack-notify-retry-delay,env:BRICK_SLACK_WEBHOOK_RETRY_DELAY" help:"The number of seconds to wait between Slack message delivery retry attempts."`
+
+	// Retries is the number of attempts that this application will make to
+	// deliver Slack messages before giving up.
+	Retries *int `toml:"retries" arg:"--slack-notify-retries,env:BRICK_SLACK_WEBHOOK_RETRIES" help:"The number of attempts that this application will make to deliver Slack messages before giving up."`
+
+	// TextTemplateFile is the fully-qualified path to an optional template
+	// file used in place of the built-in summary text of Slack
+	// notifications.
+	TextTemplateFile *string `toml:"text_template_file" arg:"--slack-text-template-file,env:BRICK_SLACK_TEXT_TEMPLATE_FILE" help:"Fully-qualified path to an optional template file used in place of the built-in summary text of Slack notifications."`
+}
+
+// Webhook represents the various configuration settings used to send
+// notifications to a generic webhook receiver (e.g., a ticketing system) as
+// CloudEvents. Webhook targets may only be specified in the configuration
+// file; multiple targets are supported.
+type Webhook struct {
+
+	// Name uniquely identifies the webhook target. This name is used to
+	// identify the target in log messages, metrics and the outbox.
+	Name *string `toml:"name"`
+
+	// URL is the full URL used to submit notifications to the webhook
+	// receiver.
+	URL *string `toml:"url"`
+
+	// Headers is an optional set of HTTP headers included with each
+	// notification submitted to the webhook receiver.
+	Headers map[string]string `toml:"headers"`
+
+	// BearerToken is an optional token provided in the Authorization header
+	// of each notification submitted to the webhook receiver.
+	BearerToken *string `toml:"bearer_token"`
+
+	// HMACSecret is an optional shared secret used to sign the body of each
+	// notification submitted to the webhook receiver. The signature is
+	// provided in the X-Brick-Signature-256 header.
+	HMACSecret *string `toml:"hmac_secret"`
+
+	// EventTypes is an optional list of CloudEvents event types (or
+	// path.Match patterns) that should be submitted to the webhook receiver.
+	// All event types are submitted if not specified.
+	EventTypes []string `toml:"event_types"`
+
+	// Source is an optional CloudEvents source attribute value used in
+	// place of the default value (the URL for this project).
+	Source *string `toml:"source"`
+
+	// RateLimit is the number of seconds to wait between notification
+	// attempts for this webhook target.
+	RateLimit *int `toml:"rate_limit"`
+
+	// RetryDelay is the number of seconds to wait between notification
+	// delivery retry attempts for this webhook target.
+	RetryDelay *int `toml:"retry_delay"`
+
+	// Retries is the number of attempts that this application will make to
+	// deliver notifications to this webhook target before giving up.
+	Retries *int `toml:"retries"`
+}
+
+// Route represents a notification routing rule. Event Records matching all
+// provided criteria are sent to the listed notifiers. Routing rules may only
+// be specified in the configuration file.
+type Route struct {
+
+	// Name is an optional name for the routing rule used in log messages.
+	Name *string `toml:"name"`
+
+	// Actions is an optional list of Action names (or path.Match patterns)
+	// matched by this routing rule.
+	Actions []string `toml:"actions"`
+
+	// Outcome optionally limits this routing rule to either successful or
+	// failed events.
+	Outcome *string `toml:"outcome"`
+
+	// AlertNames is an optional list of alert (search) names (or path.Match
+	// patterns) matched by this routing rule.
+	AlertNames []string `toml:"alert_names"`
+
+	// Usernames is an optional list of usernames (or path.Match patterns)
+	// matched by this routing rule.
+	Usernames []string `toml:"usernames"`
+
+	// Notifiers is the list of notifier names (or path.Match patterns) that
+	// event Records matching this routing rule are sent to.
+	Notifiers []string `toml:"notifiers"`
+
+	// EmailRecipients is an optional list of email addresses used in place
+	// of the default recipients for email notifications sent for event
+	// Records matching this routing rule.
+	EmailRecipients []string `toml:"email_recipients"`
+}
+
+// NotificationRoute is a notification routing rule from the configuration
+// file with default values applied for any settings not provided.
+type NotificationRoute struct {
+	Name            string
+	Actions         []string
+	Outcome         string
+	AlertNames      []string
+	Usernames       []string
+	Notifiers       []string
+	EmailRecipients []string
+}
+
+// WebhookTarget is a webhook target from the configuration file with default
+// values applied for any settings not provided.
+type WebhookTarget struct {
+	Name        string
+	URL         string
+	Headers     map[string]string
+	BearerToken string
+	HMACSecret  string
+	EventTypes  []string
+	Source      string
+	RateLimit   int
+	RetryDelay  int
+	Retries     int
 }
 
 // Email represents the various configuration settings ued to send email
@@ -207,6 +631,166 @@ type Email struct {
 	// Retries is the number of attempts that this application will make to
 	// deliver email messages before giving up.
 	Retries *int `toml:"retries" arg:"--email-notify-retries,env:BRICK_EMAIL_NOTIFY_RETRIES" help:"The number of attempts that this application will make to deliver email messages before giving up."`
+
+	// StartTLS controls whether the connection to the SMTP server is
+	// upgraded using the STARTTLS extension. Valid options are off,
+	// opportunistic and required.
+	StartTLS *string `toml:"starttls" arg:"--email-starttls,env:BRICK_EMAIL_STARTTLS" help:"Whether the connection to the SMTP server is upgraded using STARTTLS. Valid options are off, opportunistic (upgrade if the server advertises support) and required (fail delivery if the upgrade is not possible)."`
+
+	// ImplicitTLS controls whether the connection to the SMTP server is
+	// encrypted from the start (e.g., port 465) instead of being upgraded
+	// using STARTTLS.
+	ImplicitTLS *bool `toml:"implicit_tls" arg:"--email-implicit-tls,env:BRICK_EMAIL_IMPLICIT_TLS" help:"Whether the connection to the SMTP server is encrypted from the start (e.g., port 465). Cannot be combined with STARTTLS."`
+
+	// AuthMechanism is the SMTP authentication mechanism used when
+	// submitting email messages. Valid options are none, plain, login and
+	// cram-md5.
+	AuthMechanism *string `toml:"auth_mechanism" arg:"--email-auth,env:BRICK_EMAIL_AUTH" help:"The SMTP authentication mechanism used when submitting email messages. Valid options are none, plain, login and cram-md5."`
+
+	// Username is the username used to authenticate to the SMTP server.
+	Username *string `toml:"username" arg:"--email-username,env:BRICK_EMAIL_USERNAME" help:"The username used to authenticate to the SMTP server."`
+
+	// PasswordFile is the fully-qualified path to a file containing the
+	// password (or secret) used to authenticate to the SMTP server.
+	PasswordFile *string `toml:"password_file" arg:"--email-password-file,env:BRICK_EMAIL_PASSWORD_FILE" help:"Fully-qualified path to a file containing the password (or secret) used to authenticate to the SMTP server. The file is read for each delivery attempt so that the password can be rotated without restarting this application."`
+
+	// CAFile is the fully-qualified path to a PEM encoded bundle of
+	// certificate authorities used to verify the SMTP server certificate
+	// instead of the system certificate pool.
+	CAFile *string `toml:"ca_file" arg:"--email-ca-file,env:BRICK_EMAIL_CA_FILE" help:"Fully-qualified path to a PEM encoded bundle of certificate authorities used to verify the SMTP server certificate instead of the system certificate pool."`
+
+	// AttachAlert controls whether the alert payload (JSON) as received from
+	// the alert sender is attached to email notifications.
+	AttachAlert *bool `toml:"attach_alert" arg:"--email-attach-alert,env:BRICK_EMAIL_ATTACH_ALERT" help:"Whether the alert payload (JSON) as received from the alert sender is attached to email notifications."`
+
+	// BodyTemplate is the built-in template used for the plain text part of
+	// email notifications. Valid options are textile and markdown.
+	BodyTemplate *string `toml:"body_template" arg:"--email-body-template,env:BRICK_EMAIL_BODY_TEMPLATE" help:"The built-in template used for the plain text part of email notifications. Valid options are textile (e.g., for ticket systems such as Redmine) and markdown. Ignored if a body template file is specified."`
+
+	// SubjectTemplateFile is the fully-qualified path to an optional template
+	// file used in place of the built-in subject of email notifications.
+	SubjectTemplateFile *string `toml:"subject_template_file" arg:"--email-subject-template-file,env:BRICK_EMAIL_SUBJECT_TEMPLATE_FILE" help:"Fully-qualified path to an optional template file used in place of the built-in subject of email notifications."`
+
+	// BodyTemplateFile is the fully-qualified path to an optional template
+	// file used in place of the built-in template for the plain text part of
+	// email notifications.
+	BodyTemplateFile *string `toml:"body_template_file" arg:"--email-body-template-file,env:BRICK_EMAIL_BODY_TEMPLATE_FILE" help:"Fully-qualified path to an optional template file used in place of the built-in template for the plain text part of email notifications."`
+
+	// HTMLBodyTemplateFile is the fully-qualified path to an optional
+	// template file used in place of the built-in template for the HTML part
+	// of email notifications.
+	HTMLBodyTemplateFile *string `toml:"html_body_template_file" arg:"--email-html-body-template-file,env:BRICK_EMAIL_HTML_BODY_TEMPLATE_FILE" help:"Fully-qualified path to an optional template file used in place of the built-in template for the HTML part of email notifications."`
+}
+
+// Outbox represents the path to, and permissions for, the file used to hold
+// notifications until they are delivered along with the settings used to
+// retry notifications which could not be delivered.
+type Outbox struct {
+
+	// File is the fully-qualified path to the file used to hold
+	// notifications until they are delivered. Notifications are held in
+	// memory only if set to an empty string.
+	File *string `toml:"file_path" arg:"--outbox-file,env:BRICK_OUTBOX_FILE" help:"Fully-qualified path to the file used to hold notifications until they are delivered. Notifications waiting to be delivered are lost if brick stops while this is set to an empty string."`
+
+	// FilePermissions is the desired file permissions when this file is
+	// created.
+	FilePermissions *os.FileMode `toml:"file_permissions" arg:"--outbox-file-perms,env:BRICK_OUTBOX_FILE_PERMISSIONS" help:"Desired file permissions when this file is created."`
+
+	// MaxAttempts is the number of times delivery of a notification is
+	// attempted before it is moved to the dead-letter list. Each attempt
+	// includes the retries specific to each notification service.
+	MaxAttempts *int `toml:"max_attempts" arg:"--outbox-max-attempts,env:BRICK_OUTBOX_MAX_ATTEMPTS" help:"Number of times delivery of a notification is attempted before it is moved to the dead-letter list. Each attempt includes the retries configured for the notification service."`
+
+	// RetryDelay is the number of minutes to wait before attempting delivery
+	// of a notification again after a failed attempt.
+	RetryDelay *int `toml:"retry_delay" arg:"--outbox-retry-delay,env:BRICK_OUTBOX_RETRY_DELAY" help:"Number of minutes to wait before attempting delivery of a notification again after a failed attempt."`
+}
+
+// Aggregation represents the settings used to collect the event Records
+// generated while processing a single alert so that they are sent as one
+// consolidated notification instead of one notification per step.
+type Aggregation struct {
+
+	// Enabled indicates whether event Records for the same alert are
+	// collected and sent as one consolidated notification.
+	Enabled *bool `toml:"enabled" arg:"--notify-aggregate,env:BRICK_NOTIFY_AGGREGATE" help:"Whether notifications for the steps taken in response to an alert are collected and sent as one consolidated notification once processing of the alert finishes instead of one notification per step."`
+
+	// Window is the maximum number of seconds that event Records for an alert
+	// are collected before a consolidated notification is sent, even if
+	// processing of the alert has not finished.
+	Window *int `toml:"window" arg:"--notify-aggregate-window,env:BRICK_NOTIFY_AGGREGATE_WINDOW" help:"Maximum number of seconds that notifications for an alert are collected before a consolidated notification is sent, even if processing of the alert has not finished."`
+}
+
+// DigestReports represents the settings used to send scheduled summary
+// ("digest") reports of the alerts processed by this application.
+type DigestReports struct {
+
+	// Schedule is how often digest reports are sent; one of off, daily or
+	// weekly.
+	Schedule *string `toml:"schedule" arg:"--digest-schedule,env:BRICK_DIGEST_SCHEDULE" help:"How often a summary (digest) report of the alerts processed during the last period is sent using the email and Microsoft Teams notifiers; one of off, daily or weekly. Digest reports are built from the JSON events log."`
+
+	// Time is the local time of day (HH:MM, 24-hour format) when digest
+	// reports are sent.
+	Time *string `toml:"time" arg:"--digest-time,env:BRICK_DIGEST_TIME" help:"Local time of day (HH:MM, 24-hour format) when digest reports are sent."`
+
+	// Weekday is the day of the week when weekly digest reports are sent.
+	Weekday *string `toml:"weekday" arg:"--digest-weekday,env:BRICK_DIGEST_WEEKDAY" help:"Day of the week (e.g., monday) when weekly digest reports are sent."`
+
+	// Top is the maximum number of alert names and repeat offenders listed
+	// in digest reports.
+	Top *int `toml:"top" arg:"--digest-top,env:BRICK_DIGEST_TOP" help:"Maximum number of alert names and repeat offenders (usernames reported more than once) listed in digest reports."`
+}
+
+// DeadLettersCmd represents the deadletters subcommand used to review and
+// retry notifications which a running instance of this application could
+// not deliver.
+type DeadLettersCmd struct {
+	List  *DeadLettersListCmd  `arg:"subcommand:list" help:"List notifications which could not be delivered."`
+	Retry *DeadLettersRetryCmd `arg:"subcommand:retry" help:"Queue notifications which could not be delivered for delivery again."`
+}
+
+// DeadLettersListCmd represents the deadletters list subcommand. This is the
+// default if no deadletters subcommand action is specified.
+type DeadLettersListCmd struct{}
+
+// DeadLettersRetryCmd represents the deadletters retry subcommand.
+type DeadLettersRetryCmd struct {
+
+	// IDs is the list of dead-letter notification IDs to retry. All
+	// dead-letter notifications are retried if not specified.
+	IDs []string `arg:"positional" help:"IDs of the notifications to retry. All notifications which could not be delivered are retried if not specified."`
+}
+
+// DigestCmd represents the digest subcommand used to generate a digest
+// report on demand.
+type DigestCmd struct {
+
+	// Period is the period covered by the digest report, ending now.
+	Period *string `arg:"--period" help:"Period covered by the digest report, ending now; one of daily or weekly. Defaults to the scheduled digest report period or daily if digest reports are not scheduled."`
+
+	// Send indicates whether the digest report is sent using the email and
+	// Microsoft Teams notifiers instead of being written to stdout.
+	Send bool `arg:"--send" help:"Send the digest report using the configured email and Microsoft Teams notifiers instead of writing it to stdout."`
+}
+
+// PreviewCmd represents the preview subcommand used to render the
+// notifications generated for a sample alert payload without sending them.
+type PreviewCmd struct {
+
+	// PayloadFile is the path to a file containing a sample Splunk alert
+	// payload.
+	PayloadFile string `arg:"positional,required" help:"Path to a file containing a sample Splunk alert payload (e.g., contrib/tests/splunk-test-submission.json)."`
+
+	// Action is the short name of the simulated action taken in response to
+	// the sample alert.
+	Action *string `arg:"--action" help:"Short name of the simulated action taken in response to the sample alert (e.g., username.disabled, username.ignored or sessions.termination_failed)."`
+
+	// Notifier is the notification type rendered.
+	Notifier *string `arg:"--notifier" help:"Notification rendered; one of all, email, teams or slack."`
+
+	// OutputDir is the optional directory where rendered notifications are
+	// written instead of stdout.
+	OutputDir *string `arg:"--output-dir" help:"Directory where rendered notifications are written (email.eml, teams.json and slack.json) instead of stdout."`
 }
 
 // EZproxy represents that various configuration settings used to interact
@@ -224,6 +808,11 @@ type EZproxy struct {
 	// current sessions and hosts managed by EZproxy.
 	ActiveFilePath *string `toml:"active_file_path" arg:"--ezproxy-active-file-path,env:BRICK_EZPROXY_ACTIVE_FILE_PATH" help:"The fully-qualified path to the Active Users and Hosts 'state' file used by EZproxy (and this application) to track current sessions and hosts managed by EZproxy."`
 
+	// User is the OS user account that the EZproxy daemon runs as. If
+	// specified, this is used at startup to confirm that EZproxy is able to
+	// read the disabled users file.
+	User *string `toml:"user" arg:"--ezproxy-user,env:BRICK_EZPROXY_USER" help:"OS user account that the EZproxy daemon runs as. If specified, startup checks confirm that this user account is able to read the disabled users file."`
+
 	// AuditFileDirPath is the path to the directory containing the EZproxy
 	// audit files. The assumption is made that all files within are based on
 	// YYYYMMDD.txt pattern. Any other file pattern found within this path is
@@ -274,18 +863,50 @@ type configTemplate struct {
 	// changes which more closely mirror the encoding/json standard library
 	// behavior.
 	Network            `toml:"network"`
+	Metrics            `toml:"metrics"`
 	Logging            `toml:"logging"`
 	DisabledUsers      `toml:"disabledusers"`
 	ReportedUsers      `toml:"reportedusers"`
+	Journal            `toml:"journal"`
+	Dedup              `toml:"dedup"`
+	StaleAlerts        `toml:"stalealerts"`
+	JSONEventsLog      `toml:"jsoneventslog"`
+	SIEM               `toml:"siem"`
 	IgnoredUsers       `toml:"ignoredusers"`
 	IgnoredIPAddresses `toml:"ignoredipaddresses"`
+	Usernames          `toml:"usernames"`
 	MSTeams            `toml:"msteams"`
+	Slack              `toml:"slack"`
 	Email              `toml:"email"`
+	Outbox             `toml:"outbox"`
+	Aggregation        `toml:"aggregation"`
+	DigestReports      `toml:"digest"`
 	EZproxy            `toml:"ezproxy"`
 
+	// Webhooks is the list of generic webhook targets. Webhook targets may
+	// only be specified in the configuration file.
+	Webhooks []Webhook `toml:"webhooks" arg:"-"`
+
+	// Routes is the list of notification routing rules. If specified, event
+	// Records are only sent to the notifiers listed by matching rules.
+	// Routing rules may only be specified in the configuration file.
+	Routes []Route `toml:"routes" arg:"-"`
+
 	IgnoreLookupErrors *bool `toml:"ignore_lookup_errors" arg:"--ignore-lookup-errors,env:BRICK_IGNORE_LOOKUP_ERRORS" help:"Whether application should continue if attempts to lookup existing disabled or ignored status for a username or IP Address fail."`
 
 	// ConfigFile represents the fully-qualified path to a configuration file
 	// consulted for settings not provided via CLI flags
 	ConfigFile *string `toml:"-" arg:"--config-file,env:BRICK_CONFIG_FILE" help:"Full path to optional TOML-formatted configuration file. See contrib/brick/config.example.toml for a starter template."`
+
+	// DeadLetters is the optional subcommand used to review and retry
+	// notifications which the running instance could not deliver.
+	DeadLetters *DeadLettersCmd `toml:"-" arg:"subcommand:deadletters" help:"List or retry notifications which the running instance of brick could not deliver."`
+
+	// Digest is the optional subcommand used to generate a digest report on
+	// demand.
+	Digest *DigestCmd `toml:"-" arg:"subcommand:digest" help:"Generate a summary (digest) report of the alerts processed during the last period."`
+
+	// Preview is the optional subcommand used to render notifications for a
+	// sample alert payload without sending them.
+	Preview *PreviewCmd `toml:"-" arg:"subcommand:preview" help:"Render the email, Microsoft Teams and Slack notifications generated for a sample alert payload without sending them."`
 }
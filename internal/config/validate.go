@@ -19,11 +19,231 @@ package config
 import (
 	"fmt"
 	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
 
 	"github.com/apex/log"
 	goteamsnotify "github.com/atc0005/go-teams-notify/v2"
+
+	"github.com/atc0005/brick/internal/digest"
+	"github.com/atc0005/brick/internal/events"
+	"github.com/atc0005/brick/internal/syslog"
 )
 
+// webhookNameRegex matches the supported characters for webhook target
+// names.
+var webhookNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// webhookHeaderRegex matches valid HTTP header field names.
+var webhookHeaderRegex = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9a-zA-Z-]+$")
+
+// validateTeamsTargets receives the list of Microsoft Teams webhook URLs and
+// whether the list begins with the (unnamed) webhook URL specified by flag
+// and validates each of them. A error message indicating the reason for
+// validation failure is returned or nil if no issues were found.
+func validateTeamsTargets(targets []TeamsTarget, hasUnnamed bool) error {
+
+	// Create Microsoft Teams client
+	mstClient := goteamsnotify.NewTeamsClient()
+	mstClient.AddWebhookURLValidationPatterns(TeamsWebhookURLValidationPatterns...)
+
+	names := make(map[string]struct{}, len(targets))
+
+	for i, target := range targets {
+
+		log.Debugf("Microsoft Teams WebhookURL provided: %v", target.URL)
+
+		// The webhook URL specified by flag is not named; all others are.
+		if i > 0 || !hasUnnamed {
+			if !webhookNameRegex.MatchString(target.Name) {
+				return fmt.Errorf(
+					"invalid name %q specified for MS Teams webhook; letters, digits, underscores and hyphens are supported",
+					target.Name,
+				)
+			}
+
+			if _, ok := names[target.Name]; ok {
+				return fmt.Errorf("duplicate name %q specified for MS Teams webhooks", target.Name)
+			}
+			names[target.Name] = struct{}{}
+		}
+
+		if err := mstClient.ValidateWebhook(target.URL); err != nil {
+			return fmt.Errorf("webhook URL validation failed: %w", err)
+		}
+
+		switch target.MessageFormat {
+		case TeamsMessageFormatMessageCard:
+		case TeamsMessageFormatAdaptiveCard:
+		default:
+			return fmt.Errorf(
+				"invalid message format specified for MS Teams webhook %q: %q",
+				target.Name,
+				target.MessageFormat,
+			)
+		}
+	}
+
+	return nil
+}
+
+// validatePatterns receives a list of path.Match patterns along with a
+// description of their purpose and the routing rule they belong to and
+// validates each of them.
+func validatePatterns(patterns []string, purpose string, route string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("invalid %s %q specified for routing rule %q", purpose, pattern, route)
+		}
+	}
+	return nil
+}
+
+// validateRoutes receives the list of notification routing rules and
+// validates each of them. A error message indicating the reason for
+// validation failure is returned or nil if no issues were found.
+func validateRoutes(routes []NotificationRoute) error {
+
+	actionNames := append(events.ActionNames(), events.ActionNameUnknown)
+
+	for _, route := range routes {
+
+		log.Debugf("Validating routing rule %q", route.Name)
+
+		if err := validatePatterns(route.Actions, "action", route.Name); err != nil {
+			return err
+		}
+
+		// Catch typos by requiring each pattern to match a known Action.
+		for _, pattern := range route.Actions {
+			var matched bool
+			for _, name := range actionNames {
+				if ok, _ := path.Match(pattern, name); ok {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return fmt.Errorf(
+					"action %q specified for routing rule %q does not match any known action",
+					pattern,
+					route.Name,
+				)
+			}
+		}
+
+		switch route.Outcome {
+		case RouteOutcomeAny:
+		case RouteOutcomeSuccess:
+		case RouteOutcomeFailure:
+		default:
+			return fmt.Errorf("invalid outcome %q specified for routing rule %q", route.Outcome, route.Name)
+		}
+
+		if err := validatePatterns(route.AlertNames, "alert name", route.Name); err != nil {
+			return err
+		}
+
+		if err := validatePatterns(route.Usernames, "username", route.Name); err != nil {
+			return err
+		}
+
+		if len(route.Notifiers) == 0 {
+			return fmt.Errorf("no notifiers specified for routing rule %q", route.Name)
+		}
+
+		if err := validatePatterns(route.Notifiers, "notifier", route.Name); err != nil {
+			return err
+		}
+
+		for _, emailAddr := range route.EmailRecipients {
+			if err := validateEmailAddress(emailAddr, "recipient"); err != nil {
+				return fmt.Errorf("routing rule %q: %w", route.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateWebhookTargets receives the list of generic webhook targets and
+// validates each of them. A error message indicating the reason for
+// validation failure is returned or nil if no issues were found.
+func validateWebhookTargets(targets []WebhookTarget) error {
+
+	names := make(map[string]struct{}, len(targets))
+
+	for i, target := range targets {
+
+		log.Debugf("Validating webhook target %d: %q", i+1, target.Name)
+
+		if !webhookNameRegex.MatchString(target.Name) {
+			return fmt.Errorf(
+				"invalid name %q specified for webhook target %d; letters, digits, underscores and hyphens are supported",
+				target.Name,
+				i+1,
+			)
+		}
+
+		if _, ok := names[target.Name]; ok {
+			return fmt.Errorf("duplicate name %q specified for webhook targets", target.Name)
+		}
+		names[target.Name] = struct{}{}
+
+		u, err := url.Parse(target.URL)
+		switch {
+		case err != nil:
+			return fmt.Errorf("webhook %q URL validation failed: %w", target.Name, err)
+		case u.Scheme != "https" && u.Scheme != "http", u.Host == "":
+			return fmt.Errorf(
+				"webhook %q URL validation failed: %q is not an http or https URL",
+				target.Name,
+				target.URL,
+			)
+		}
+
+		for header := range target.Headers {
+			if !webhookHeaderRegex.MatchString(header) {
+				return fmt.Errorf("invalid header name %q specified for webhook %q", header, target.Name)
+			}
+		}
+
+		for _, eventType := range target.EventTypes {
+			if _, err := path.Match(eventType, ""); err != nil || eventType == "" {
+				return fmt.Errorf("invalid event type %q specified for webhook %q", eventType, target.Name)
+			}
+		}
+
+		if target.RateLimit < 0 {
+			return fmt.Errorf(
+				"invalid rate limit specified for webhook %q notifications: %d",
+				target.Name,
+				target.RateLimit,
+			)
+		}
+
+		if target.RetryDelay < 0 {
+			return fmt.Errorf(
+				"invalid retry delay specified for webhook %q notifications: %d",
+				target.Name,
+				target.RetryDelay,
+			)
+		}
+
+		if target.Retries < 0 {
+			return fmt.Errorf(
+				"invalid retries limit specified for webhook %q notifications: %d",
+				target.Name,
+				target.Retries,
+			)
+		}
+	}
+
+	return nil
+}
+
 // validateEmailAddress receives a string representing an email address and
 // the intent or purpose for the email address (e.g., "sender", "recipient")
 // and validates the email address. A error message indicating the reason for
@@ -142,6 +362,29 @@ func validate(c Config) error {
 		return fmt.Errorf("local IP Address not provided")
 	}
 
+	// Exposing the metrics endpoint on the main listener is a valid choice.
+	// Perform validation of the separate listener settings if a port is
+	// provided.
+	if c.MetricsEnabled() && c.MetricsLocalTCPPort() != 0 {
+		switch {
+		case c.MetricsLocalTCPPort() < TCPSystemPortStart || c.MetricsLocalTCPPort() > TCPDynamicPrivatePortEnd:
+			return fmt.Errorf(
+				"port %d is not a valid TCP port for the metrics endpoint",
+				c.MetricsLocalTCPPort(),
+			)
+		case c.MetricsLocalTCPPort() == c.LocalTCPPort():
+			return fmt.Errorf(
+				"metrics port %d conflicts with main listener port; "+
+					"leave the metrics port unset to expose metrics on the main listener",
+				c.MetricsLocalTCPPort(),
+			)
+		}
+
+		if c.MetricsLocalIPAddress() == "" {
+			return fmt.Errorf("metrics local IP Address not provided")
+		}
+	}
+
 	// true if sysadmin specified a value via CLI or config file
 	if c.RequireTrustedPayloadSender() {
 		switch {
@@ -173,6 +416,47 @@ func validate(c Config) error {
 	switch c.LogOutput() {
 	case LogOutputStderr:
 	case LogOutputStdout:
+	case LogOutputSyslog:
+		if !syslog.ValidNetwork(c.LogSyslogNetwork()) {
+			return fmt.Errorf("invalid option %q provided for log syslog network",
+				c.LogSyslogNetwork())
+		}
+
+		if c.LogSyslogAddress() == "" {
+			return fmt.Errorf("syslog address not provided for syslog log output")
+		}
+
+		if _, err := syslog.ParseFacility(c.LogSyslogFacility()); err != nil {
+			return fmt.Errorf("invalid option provided for log syslog facility: %w", err)
+		}
+
+		if c.LogSyslogAppName() == "" {
+			return fmt.Errorf("syslog app name not provided for syslog log output")
+		}
+	case LogOutputFile:
+		if c.LogFile() == "" {
+			return fmt.Errorf("path to log file not provided for file log output")
+		}
+
+		if c.LogFileMaxSize() < 0 {
+			return fmt.Errorf("invalid max size specified for log file: %d",
+				c.LogFileMaxSize())
+		}
+
+		if c.LogFileRotateInterval() < 0 {
+			return fmt.Errorf("invalid rotate interval specified for log file: %d",
+				c.LogFileRotateInterval())
+		}
+
+		if c.LogFileMaxAge() < 0 {
+			return fmt.Errorf("invalid max age specified for log file: %d",
+				c.LogFileMaxAge())
+		}
+
+		if c.LogFileMaxBackups() < 0 {
+			return fmt.Errorf("invalid max backups specified for log file: %d",
+				c.LogFileMaxBackups())
+		}
 	default:
 		return fmt.Errorf("invalid option %q provided for log output",
 			c.LogOutput())
@@ -214,17 +498,129 @@ func validate(c Config) error {
 		return fmt.Errorf("empty path to ignored ip addresses file provided")
 	}
 
-	// Not having a webhook URL is a valid choice. Perform validation if value
-	// is provided.
-	if c.TeamsWebhookURL() != "" {
-		log.Debugf("Microsoft Teams WebhookURL provided: %v", c.TeamsWebhookURL())
+	if c.DedupTTL() < 0 {
+		return fmt.Errorf("invalid TTL specified for duplicate alert detection: %d",
+			c.DedupTTL())
+	}
 
-		// Create Microsoft Teams client
-		mstClient := goteamsnotify.NewTeamsClient()
+	if c.StaleAlertMaxAge() < 0 {
+		return fmt.Errorf("invalid maximum age specified for stale alert detection: %d",
+			c.StaleAlertMaxAge())
+	}
 
-		if err := mstClient.ValidateWebhook(c.TeamsWebhookURL()); err != nil {
-			return fmt.Errorf("webhook URL validation failed: %w", err)
+	switch c.StaleAlertAction() {
+	case StaleAlertActionReject:
+	case StaleAlertActionReport:
+	default:
+		return fmt.Errorf("invalid option %q provided for stale alert action",
+			c.StaleAlertAction())
+	}
+
+	if c.OutboxMaxAttempts() < 1 {
+		return fmt.Errorf("invalid number of notification delivery attempts specified: %d",
+			c.OutboxMaxAttempts())
+	}
+
+	if c.OutboxRetryDelay() < 1 {
+		return fmt.Errorf("invalid notification delivery retry delay specified: %d",
+			c.OutboxRetryDelay())
+	}
+
+	if c.AggregationWindow() < 1 {
+		return fmt.Errorf("invalid notification aggregation window specified: %d",
+			c.AggregationWindow())
+	}
+
+	switch c.DigestSchedule() {
+	case DigestScheduleOff:
+	case DigestScheduleDaily:
+	case DigestScheduleWeekly:
+	default:
+		return fmt.Errorf("invalid option %q provided for digest report schedule",
+			c.DigestSchedule())
+	}
+
+	if _, _, err := digest.ParseTimeOfDay(c.DigestTime()); err != nil {
+		return fmt.Errorf("invalid digest report time specified: %w", err)
+	}
+
+	if _, err := digest.ParseWeekday(c.DigestWeekday()); err != nil {
+		return fmt.Errorf("invalid digest report weekday specified: %w", err)
+	}
+
+	if c.DigestTop() < 1 {
+		return fmt.Errorf("invalid number of digest report entries specified: %d",
+			c.DigestTop())
+	}
+
+	// Digest reports are built from the events recorded by the JSON events
+	// log.
+	if c.DigestSchedule() != DigestScheduleOff && c.JSONEventsLogFile() == "" {
+		return fmt.Errorf("digest reports require the JSON events log to be enabled")
+	}
+
+	if c.DigestCommand() {
+		switch c.DigestCommandPeriod() {
+		case DigestScheduleDaily:
+		case DigestScheduleWeekly:
+		default:
+			return fmt.Errorf("invalid option %q provided for digest report period",
+				c.DigestCommandPeriod())
+		}
+	}
+
+	if c.PreviewCommand() {
+		if _, ok := events.ActionByName(c.PreviewCommandAction()); !ok {
+			return fmt.Errorf("invalid option %q provided for preview action; one of %s expected",
+				c.PreviewCommandAction(),
+				strings.Join(events.ActionNames(), ", "))
+		}
+
+		switch c.PreviewCommandNotifier() {
+		case PreviewNotifierAll:
+		case PreviewNotifierEmail:
+		case PreviewNotifierTeams:
+		case PreviewNotifierSlack:
+		default:
+			return fmt.Errorf("invalid option %q provided for preview notifier",
+				c.PreviewCommandNotifier())
+		}
+	}
+
+	switch c.SIEMFormat() {
+	case SIEMFormatCEF:
+	case SIEMFormatLEEF:
+	default:
+		return fmt.Errorf("invalid option %q provided for SIEM format",
+			c.SIEMFormat())
+	}
+
+	// Not specifying a syslog daemon for SIEM events is a valid choice.
+	// Perform validation of related values if the address is provided.
+	if c.SIEMSyslogAddress() != "" {
+		if !syslog.ValidNetwork(c.SIEMSyslogNetwork()) {
+			return fmt.Errorf("invalid option %q provided for SIEM syslog network",
+				c.SIEMSyslogNetwork())
 		}
+
+		if _, err := syslog.ParseFacility(c.SIEMSyslogFacility()); err != nil {
+			return fmt.Errorf("invalid option provided for SIEM syslog facility: %w", err)
+		}
+	}
+
+	switch c.TeamsMessageFormat() {
+	case TeamsMessageFormatAuto:
+	case TeamsMessageFormatMessageCard:
+	case TeamsMessageFormatAdaptiveCard:
+	default:
+		return fmt.Errorf("invalid message format specified for MS Teams notifications: %q",
+			c.TeamsMessageFormat())
+	}
+
+	// Not having a webhook URL is a valid choice. Perform validation of each
+	// webhook URL if provided.
+	if err := validateTeamsTargets(c.TeamsTargets(), c.TeamsWebhookURL() != ""); err != nil {
+		return err
 	}
 
 	if c.TeamsNotificationRateLimit() < 0 {
@@ -251,6 +647,61 @@ func validate(c Config) error {
 		)
 	}
 
+	// Not having a webhook URL is a valid choice. Perform validation if value
+	// is provided. Any http or https URL is accepted so that a local
+	// stand-in can be used for testing.
+	if c.SlackWebhookURL() != "" {
+		log.Debugf("Slack WebhookURL provided: %v", c.SlackWebhookURL())
+
+		u, err := url.Parse(c.SlackWebhookURL())
+		switch {
+		case err != nil:
+			return fmt.Errorf("slack webhook URL validation failed: %w", err)
+		case u.Scheme != "https" && u.Scheme != "http", u.Host == "":
+			return fmt.Errorf(
+				"slack webhook URL validation failed: %q is not an http or https URL",
+				c.SlackWebhookURL(),
+			)
+		}
+	}
+
+	if c.SlackNotificationRateLimit() < 0 {
+		log.Debugf("unsupported rate limit specified for Slack notifications: %d ", c.SlackNotificationRateLimit())
+		return fmt.Errorf(
+			"invalid rate limit specified for Slack notifications: %d",
+			c.SlackNotificationRateLimit(),
+		)
+	}
+
+	if c.SlackNotificationRetryDelay() < 0 {
+		log.Debugf("unsupported retry delay specified for Slack notifications: %d ", c.SlackNotificationRetryDelay())
+		return fmt.Errorf(
+			"invalid retry delay specified for Slack notifications: %d",
+			c.SlackNotificationRetryDelay(),
+		)
+	}
+
+	if c.SlackNotificationRetries() < 0 {
+		log.Debugf("unsupported retry limit specified for Slack notifications: %d ", c.SlackNotificationRetries())
+		return fmt.Errorf(
+			"invalid retries limit specified for Slack notifications: %d",
+			c.SlackNotificationRetries(),
+		)
+	}
+
+	// Not specifying webhook targets is a valid choice. Perform validation
+	// of each target if provided.
+	if err := validateWebhookTargets(c.WebhookTargets()); err != nil {
+		return err
+	}
+
+	// Not specifying routing rules is a valid choice; all notifications are
+	// sent to all enabled notifiers. Perform validation of each rule if
+	// provided.
+	if err := validateRoutes(c.NotificationRoutes()); err != nil {
+		return err
+	}
+
 	// Not specifying an email server is a valid choice. Perform validation of
 	// this and other related values if the server name is provided.
 	if c.EmailServer() != "" {
@@ -335,6 +786,50 @@ func validate(c Config) error {
 			)
 		}
 
+		switch c.EmailBodyTemplate() {
+		case EmailBodyTemplateTextile:
+		case EmailBodyTemplateMarkdown:
+		default:
+			return fmt.Errorf("invalid option %q provided for email body template",
+				c.EmailBodyTemplate())
+		}
+
+		switch c.EmailStartTLS() {
+		case EmailStartTLSOff:
+		case EmailStartTLSOpportunistic:
+		case EmailStartTLSRequired:
+		default:
+			return fmt.Errorf("invalid option %q provided for email STARTTLS mode",
+				c.EmailStartTLS())
+		}
+
+		if c.EmailImplicitTLS() && c.EmailStartTLS() != EmailStartTLSOff {
+			return fmt.Errorf(
+				"implicit TLS and STARTTLS cannot both be enabled for email notifications",
+			)
+		}
+
+		switch c.EmailAuthMechanism() {
+		case EmailAuthNone:
+		case EmailAuthPlain, EmailAuthLogin, EmailAuthCRAMMD5:
+			if c.EmailUsername() == "" {
+				return fmt.Errorf(
+					"username not provided for %q email authentication",
+					c.EmailAuthMechanism(),
+				)
+			}
+
+			if c.EmailPasswordFile() == "" {
+				return fmt.Errorf(
+					"password file not provided for %q email authentication",
+					c.EmailAuthMechanism(),
+				)
+			}
+		default:
+			return fmt.Errorf("invalid option %q provided for email authentication mechanism",
+				c.EmailAuthMechanism())
+		}
+
 	}
 
 	if c.EZproxyExecutablePath() == "" {
@@ -0,0 +1,213 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedup
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/atc0005/brick/internal/jsonlines"
+)
+
+// pruneInterval limits how often expired entries are removed from the
+// cache.
+const pruneInterval time.Duration = time.Minute
+
+// entry is a single line in the cache file. Entries marked as removed
+// indicate that an earlier entry with the same key should be forgotten.
+type entry struct {
+	Key     string    `json:"key"`
+	Time    time.Time `json:"time"`
+	Removed bool      `json:"removed,omitempty"`
+}
+
+// Cache remembers alerts by search ID and username for a fixed period.
+// Cache is safe for concurrent use.
+type Cache struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	file      *jsonlines.File
+	lastPrune time.Time
+}
+
+// New creates a Cache which remembers alerts for the specified period. If a
+// path is provided, remembered alerts are loaded from and recorded to the
+// file at that path (creating it if needed).
+func New(ttl time.Duration, path string, permissions os.FileMode) (*Cache, error) {
+
+	c := Cache{
+		ttl:       ttl,
+		seen:      make(map[string]time.Time),
+		lastPrune: time.Now(),
+	}
+
+	if path == "" {
+		return &c, nil
+	}
+
+	c.file = jsonlines.New(path, permissions, "duplicate alert cache")
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	if err := c.file.Rewrite(c.entries()); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// key returns the cache key for the specified search ID and username.
+func key(searchID string, username string) string {
+	return searchID + "\x00" + username
+}
+
+// Add remembers the alert with the specified search ID and username. If the
+// alert was already remembered, true is returned along with the time it was
+// first received. Any error recording the alert to the cache file is
+// returned; the alert is still remembered in memory.
+func (c *Cache) Add(searchID string, username string) (bool, time.Time, error) {
+
+	now := time.Now()
+	k := key(searchID, username)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.prune(now)
+
+	if firstSeen, ok := c.seen[k]; ok && now.Sub(firstSeen) < c.ttl {
+		return true, firstSeen, nil
+	}
+
+	c.seen[k] = now
+
+	return false, now, c.write(entry{Key: k, Time: now})
+}
+
+// Remove forgets the alert with the specified search ID and username so that
+// a later delivery is not treated as a duplicate. This is intended for use
+// when an alert is rejected after it was added.
+func (c *Cache) Remove(searchID string, username string) error {
+
+	k := key(searchID, username)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.seen[k]; !ok {
+		return nil
+	}
+
+	delete(c.seen, k)
+
+	return c.write(entry{Key: k, Time: time.Now(), Removed: true})
+}
+
+// Len returns the number of alerts currently remembered.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.seen)
+}
+
+// Close closes the cache file, if any.
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+
+	return c.file.Close()
+}
+
+// prune removes expired entries. The cache file is rewritten once it holds
+// many more lines than there are remembered alerts. The caller is
+// responsible for holding the mutex.
+func (c *Cache) prune(now time.Time) {
+
+	if now.Sub(c.lastPrune) < pruneInterval {
+		return
+	}
+	c.lastPrune = now
+
+	for k, firstSeen := range c.seen {
+		if now.Sub(firstSeen) >= c.ttl {
+			delete(c.seen, k)
+		}
+	}
+
+	// If compaction fails it is attempted again on a later prune.
+	if c.file != nil && c.file.NeedsCompaction(len(c.seen)) {
+		c.file.Compact(c.entries())
+	}
+}
+
+// load reads remembered alerts from the cache file, skipping those which
+// have expired. A missing file is treated as empty.
+func (c *Cache) load() error {
+
+	now := time.Now()
+
+	return c.file.Read(func(lineno int, line []byte) error {
+
+		var e entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+
+		switch {
+		case e.Removed:
+			delete(c.seen, e.Key)
+		case now.Sub(e.Time) < c.ttl:
+			c.seen[e.Key] = e.Time
+		}
+
+		return nil
+	})
+}
+
+// entries returns the remembered alerts for writing to the cache file. The
+// caller is responsible for holding the mutex (if needed).
+func (c *Cache) entries() []interface{} {
+
+	entries := make([]interface{}, 0, len(c.seen))
+	for k, firstSeen := range c.seen {
+		entries = append(entries, entry{Key: k, Time: firstSeen})
+	}
+
+	return entries
+}
+
+// write appends the entry to the cache file, if any, and syncs it to disk.
+// The caller is responsible for holding the mutex.
+func (c *Cache) write(e entry) error {
+
+	if c.file == nil {
+		return nil
+	}
+
+	return c.file.Append(e)
+}
@@ -0,0 +1,265 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package digest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/atc0005/brick/internal/events"
+)
+
+// Count is the number of times a value (e.g., an alert name or username)
+// was seen during the report period.
+type Count struct {
+	Name  string
+	Count int
+}
+
+// Report is a summary of the alerts processed by this application during
+// the report period.
+type Report struct {
+
+	// Start is the beginning of the report period.
+	Start time.Time
+
+	// End is the end of the report period.
+	End time.Time
+
+	// Reports is the number of disable requests received.
+	Reports int
+
+	// Disabled is the number of usernames disabled.
+	Disabled int
+
+	// AlreadyDisabled is the number of usernames reported again after they
+	// were already disabled.
+	AlreadyDisabled int
+
+	// Ignored is the number of usernames ignored due to an ignored username
+	// or IP Address entry.
+	Ignored int
+
+	// Skipped is the number of usernames not disabled due to a stale alert.
+	Skipped int
+
+	// SessionsTerminated is the number of user sessions terminated.
+	SessionsTerminated int
+
+	// Failures is the number of events noting a failure.
+	Failures int
+
+	// TopAlertNames is the list of alert names with the most disable
+	// requests received, in descending order.
+	TopAlertNames []Count
+
+	// RepeatOffenders is the list of usernames reported more than once, in
+	// descending order of the number of disable requests received.
+	RepeatOffenders []Count
+
+	// CurrentlyDisabled is the number of usernames listed in the disabled
+	// users file when the report was built.
+	CurrentlyDisabled int
+}
+
+// Build creates a Report for the period from start (inclusive) to end
+// (exclusive) using the event Records read from the provided io.Reader, one
+// JSON object per line. At most top entries are included in the lists of
+// alert names and repeat offenders. Lines which cannot be parsed or which do
+// not note when the alert was received are skipped.
+func Build(r io.Reader, start time.Time, end time.Time, top int) (Report, error) {
+
+	report := Report{
+		Start: start,
+		End:   end,
+	}
+
+	alertNames := make(map[string]int)
+	usernames := make(map[string]int)
+
+	var skipped int
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+
+		var record events.Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			skipped++
+			continue
+		}
+
+		received, err := time.Parse(time.RFC3339, record.Alert.ArrivalTime)
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		if received.Before(start) || !received.Before(end) {
+			continue
+		}
+
+		if record.Failed() {
+			report.Failures++
+		}
+
+		switch record.Action {
+		case events.ActionSuccessDisableRequestReceived, events.ActionFailureDisableRequestReceived:
+			report.Reports++
+			alertNames[record.Alert.AlertName]++
+			usernames[record.Alert.Username]++
+
+		case events.ActionSuccessDisabledUsername:
+			report.Disabled++
+
+		case events.ActionSuccessDuplicatedUsername:
+			report.AlreadyDisabled++
+
+		case events.ActionSuccessIgnoredUsername, events.ActionSuccessIgnoredIPAddress:
+			report.Ignored++
+
+		case events.ActionSkippedStaleAlert:
+			report.Skipped++
+
+		case events.ActionSuccessTerminatedUserSession, events.ActionFailureTerminatedUserSession:
+			for _, result := range record.SessionTerminationResults {
+				if result.Error == nil && result.ExitCode == 0 {
+					report.SessionsTerminated++
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Report{}, fmt.Errorf("failed to read events: %w", err)
+	}
+
+	if skipped > 0 {
+		log.Warnf("digest: skipped %d events which could not be parsed", skipped)
+	}
+
+	report.TopAlertNames = topCounts(alertNames, 1, top)
+	report.RepeatOffenders = topCounts(usernames, 2, top)
+
+	return report, nil
+}
+
+// topCounts returns at most limit entries from the provided map which were
+// seen at least minimum times, in descending order of the number of times seen.
+// Entries seen the same number of times are sorted by name.
+func topCounts(counts map[string]int, minimum int, limit int) []Count {
+
+	list := make([]Count, 0, len(counts))
+	for name, count := range counts {
+		if count < minimum {
+			continue
+		}
+		list = append(list, Count{Name: name, Count: count})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Name < list[j].Name
+	})
+
+	if len(list) > limit {
+		list = list[:limit]
+	}
+
+	return list
+}
+
+// BuildFromFile creates a Report for the period from start (inclusive) to
+// end (exclusive) using the event Records recorded in the JSON events log at
+// the specified path. The number of usernames currently disabled is read
+// from the disabled users file at the specified path. At most top entries
+// are included in the lists of alert names and repeat offenders.
+func BuildFromFile(eventsLogFile string, disabledUsersFile string, start time.Time, end time.Time, top int) (Report, error) {
+
+	eventsLog, err := os.Open(eventsLogFile)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to open JSON events log: %w", err)
+	}
+	defer func() {
+		if err := eventsLog.Close(); err != nil {
+			log.Errorf("digest: failed to close JSON events log %q: %v", eventsLogFile, err)
+		}
+	}()
+
+	report, err := Build(eventsLog, start, end, top)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to build report from %q: %w", eventsLogFile, err)
+	}
+
+	disabled, err := CountEntries(disabledUsersFile)
+	if err != nil {
+		return Report{}, err
+	}
+	report.CurrentlyDisabled = disabled
+
+	return report, nil
+}
+
+// CountEntries returns the number of entries in the specified file. Empty
+// lines and lines beginning with a '#' character are not counted. A file
+// which does not exist has no entries.
+func CountEntries(filename string) (int, error) {
+
+	f, err := os.Open(filename)
+	switch {
+	case os.IsNotExist(err):
+		return 0, nil
+	case err != nil:
+		return 0, fmt.Errorf("failed to open %q: %w", filename, err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Errorf("digest: failed to close %q: %v", filename, err)
+		}
+	}()
+
+	var entries int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read %q: %w", filename, err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package digest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// weekdays maps the lowercase name of each day of the week to the
+// associated time.Weekday.
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// ParseWeekday returns the time.Weekday for the provided (case-insensitive)
+// name of a day of the week.
+func ParseWeekday(name string) (time.Weekday, error) {
+	weekday, ok := weekdays[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return time.Sunday, fmt.Errorf("invalid day of the week %q", name)
+	}
+	return weekday, nil
+}
+
+// ParseTimeOfDay returns the hour and minute for the provided time of day in
+// 24-hour HH:MM format.
+func ParseTimeOfDay(value string) (int, int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time of day %q; expected HH:MM format", value)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// NextRun returns the first time after now at the provided hour and minute
+// (in the location of now). If weekly is true, only times on the provided
+// day of the week are considered.
+func NextRun(now time.Time, hour int, minute int, weekday time.Weekday, weekly bool) time.Time {
+
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+
+	if weekly {
+		next = next.AddDate(0, 0, (int(weekday)-int(now.Weekday())+7)%7)
+	}
+
+	if !next.After(now) {
+		switch {
+		case weekly:
+			next = next.AddDate(0, 0, 7)
+		default:
+			next = next.AddDate(0, 0, 1)
+		}
+	}
+
+	return next
+}
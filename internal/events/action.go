@@ -0,0 +1,138 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import "sort"
+
+// ActionNameUnknown is the name used for any Action not present in
+// actionNames.
+const ActionNameUnknown string = "unknown"
+
+// actionNames maps each Action to a short, stable name. These names are used
+// where the Action text is not suitable, such as CloudEvents event types and
+// notification routing rules, and should not be changed once published.
+var actionNames = map[string]string{
+	ActionSuccessDisableRequestReceived: "disable_request.received",
+	ActionSuccessDisabledUsername:       "username.disabled",
+	ActionSuccessDuplicatedUsername:     "username.already_disabled",
+	ActionSuccessIgnoredUsername:        "username.ignored",
+	ActionSuccessIgnoredIPAddress:       "ip_address.ignored",
+	ActionSuccessTerminatedUserSession:  "sessions.terminated",
+	ActionSkippedTerminateUserSessions:  "sessions.termination_skipped",
+	ActionSkippedStaleAlert:             "username.disable_skipped",
+
+	ActionFailureDisableRequestReceived:   "disable_request.log_failed",
+	ActionFailureDisabledUsername:         "username.disable_failed",
+	ActionFailureDuplicatedUsername:       "username.already_disabled_failed",
+	ActionFailureIgnoredUsername:          "username.ignore_check_failed",
+	ActionFailureIgnoredIPAddress:         "ip_address.ignore_check_failed",
+	ActionFailureUserSessionLookupFailure: "sessions.lookup_failed",
+	ActionFailureTerminatedUserSession:    "sessions.termination_failed",
+}
+
+// failureActions is the set of Actions which note a failure.
+var failureActions = map[string]struct{}{
+	ActionFailureDisableRequestReceived:   {},
+	ActionFailureDisabledUsername:         {},
+	ActionFailureDuplicatedUsername:       {},
+	ActionFailureIgnoredUsername:          {},
+	ActionFailureIgnoredIPAddress:         {},
+	ActionFailureUserSessionLookupFailure: {},
+	ActionFailureTerminatedUserSession:    {},
+}
+
+// finalActions is the set of Actions which are the last recorded while
+// processing an alert.
+var finalActions = map[string]struct{}{
+	ActionSuccessIgnoredUsername:       {},
+	ActionSuccessIgnoredIPAddress:      {},
+	ActionSuccessTerminatedUserSession: {},
+	ActionSkippedTerminateUserSessions: {},
+	ActionSkippedStaleAlert:            {},
+	ActionFailureDisabledUsername:      {},
+	ActionFailureIgnoredUsername:       {},
+	ActionFailureIgnoredIPAddress:      {},
+	ActionFailureTerminatedUserSession: {},
+}
+
+// ActionName returns the short name for the provided Action or
+// ActionNameUnknown if the Action is not known.
+func ActionName(action string) string {
+	if name, ok := actionNames[action]; ok {
+		return name
+	}
+	return ActionNameUnknown
+}
+
+// ActionByName returns the Action for the provided short name and whether a
+// matching Action was found.
+func ActionByName(name string) (string, bool) {
+	for action, actionName := range actionNames {
+		if actionName == name {
+			return action, true
+		}
+	}
+	return "", false
+}
+
+// ActionNames returns the short names for all known Actions in sorted order.
+func ActionNames() []string {
+	names := make([]string, 0, len(actionNames))
+	for _, name := range actionNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Failed indicates whether the event Record notes a failure, either by way
+// of the Action or a recorded error. A consolidated event Record notes a
+// failure if any of the included steps note a failure.
+func (rc Record) Failed() bool {
+
+	if _, ok := failureActions[rc.Action]; ok || rc.Error != nil {
+		return true
+	}
+
+	for _, step := range rc.Steps {
+		if _, ok := failureActions[step.Action]; ok || step.Error != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Final indicates whether the event Record is the last recorded while
+// processing the associated alert.
+func (rc Record) Final() bool {
+	_, ok := finalActions[rc.Action]
+	return ok
+}
+
+// Actions returns the Action of the event Record followed by the Action of
+// each step included in a consolidated event Record.
+func (rc Record) Actions() []string {
+
+	actions := make([]string, 0, len(rc.Steps)+1)
+	actions = append(actions, rc.Action)
+	for _, step := range rc.Steps {
+		actions = append(actions, step.Action)
+	}
+
+	return actions
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ezproxyTimeLayout is the layout of the ezproxy_time field, matching the
+// timestamp recorded in the EZproxy log (e.g., 12/Feb/2020:08:32:15 -0600).
+const ezproxyTimeLayout string = "02/Jan/2006:15:04:05 -0700"
+
+// ErrEventTimeUnavailable indicates that none of the time fields in an alert
+// payload could be used to determine when the reported activity occurred.
+var ErrEventTimeUnavailable = errors.New("unable to determine event time from alert payload")
+
+// EventTime returns the time of the user activity which triggered the alert.
+// The Splunk _time field is used if available, followed by the ezproxy_time
+// field and then the individual date_* fields.
+func EventTime(payloadV2 SplunkAlertPayloadV2) (time.Time, error) {
+
+	var errs []error
+
+	t, err := parseEpochTime(payloadV2.Result.Time)
+	if err == nil {
+		return t, nil
+	}
+	errs = append(errs, fmt.Errorf("_time: %w", err))
+
+	t, err = time.Parse(ezproxyTimeLayout, strings.TrimSpace(payloadV2.Result.EzproxyTime))
+	if err == nil {
+		return t, nil
+	}
+	errs = append(errs, fmt.Errorf("ezproxy_time: %w", err))
+
+	t, err = parseDateFields(payloadV2)
+	if err == nil {
+		return t, nil
+	}
+	errs = append(errs, fmt.Errorf("date fields: %w", err))
+
+	return time.Time{}, fmt.Errorf("%w: %w", ErrEventTimeUnavailable, errors.Join(errs...))
+}
+
+// parseEpochTime parses a Unix timestamp in seconds, optionally with a
+// fractional component (e.g., 1581517935.000).
+func parseEpochTime(value string) (time.Time, error) {
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, errors.New("empty value")
+	}
+
+	secs, frac, _ := strings.Cut(value, ".")
+
+	sec, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var nsec int64
+	if frac != "" {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		nsec, err = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	return time.Unix(sec, nsec), nil
+}
+
+// parseDateFields assembles a time from the date_* fields extracted by
+// Splunk from the original EZproxy log entry. The date_zone field is an
+// offset in minutes from UTC.
+func parseDateFields(payloadV2 SplunkAlertPayloadV2) (time.Time, error) {
+
+	r := payloadV2.Result
+
+	// month names are matched without regard to case
+	month, err := time.Parse("January", strings.TrimSpace(r.DateMonth))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("date_month: %w", err)
+	}
+
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"date_year", r.DateYear},
+		{"date_mday", r.DateMday},
+		{"date_hour", r.DateHour},
+		{"date_minute", r.DateMinute},
+		{"date_second", r.DateSecond},
+		{"date_zone", r.DateZone},
+	}
+
+	values := make([]int, len(fields))
+	for i, field := range fields {
+		values[i], err = strconv.Atoi(strings.TrimSpace(field.value))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s: %w", field.name, err)
+		}
+	}
+
+	zone := time.FixedZone("", values[5]*60)
+
+	return time.Date(
+		values[0],
+		month.Month(),
+		values[1],
+		values[2],
+		values[3],
+		values[4],
+		0,
+		zone,
+	), nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/atc0005/go-ezproxy"
+)
+
+// jsonTerminationResult is the JSON representation of the results from an
+// attempt to terminate a single user session.
+type jsonTerminationResult struct {
+	SessionID string `json:"session_id"`
+	IPAddress string `json:"ip_address"`
+	Username  string `json:"username"`
+	ExitCode  int    `json:"exit_code"`
+	StdOut    string `json:"stdout"`
+	StdErr    string `json:"stderr"`
+	Error     string `json:"error,omitempty"`
+}
+
+// jsonStep is the JSON representation of a step included in a consolidated
+// Record.
+type jsonStep struct {
+	Action string `json:"action"`
+	Note   string `json:"note,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// jsonAlert is the JSON representation of the alert associated with a
+// Record. The alert request headers are intentionally excluded as they may
+// include credentials (e.g., Authorization) supplied by the alert sender.
+type jsonAlert struct {
+	Username         string  `json:"username"`
+	ReportedUsername string  `json:"reported_username"`
+	UserIP           string  `json:"user_ip"`
+	PayloadSenderIP  string  `json:"payload_sender_ip"`
+	ArrivalTime      string  `json:"arrival_time"`
+	LocalTime        string  `json:"local_time"`
+	EventTime        string  `json:"event_time,omitempty"`
+	LatencySeconds   float64 `json:"latency_seconds,omitempty"`
+	AlertName        string  `json:"alert_name"`
+	SearchID         string  `json:"search_id"`
+	EndpointPath     string  `json:"endpoint_path"`
+	HTTPMethod       string  `json:"http_method"`
+}
+
+// jsonRecord is the JSON representation of a Record.
+type jsonRecord struct {
+	Action                    string                  `json:"action"`
+	Note                      string                  `json:"note,omitempty"`
+	Error                     string                  `json:"error,omitempty"`
+	Alert                     jsonAlert               `json:"alert"`
+	SessionTerminationResults []jsonTerminationResult `json:"session_termination_results,omitempty"`
+	Steps                     []jsonStep              `json:"steps,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. Error values are
+// recorded using their string representation so that the JSON output is
+// useful to external tooling.
+func (rc Record) MarshalJSON() ([]byte, error) {
+
+	jr := jsonRecord{
+		Action: rc.Action,
+		Note:   rc.Note,
+		Alert: jsonAlert{
+			Username:         rc.Alert.Username,
+			ReportedUsername: rc.Alert.ReportedUsername,
+			UserIP:           rc.Alert.UserIP,
+			PayloadSenderIP:  rc.Alert.PayloadSenderIP,
+			ArrivalTime:      rc.Alert.ArrivalTime,
+			LocalTime:        rc.Alert.LocalTime,
+			EventTime:        rc.Alert.EventTime,
+			LatencySeconds:   rc.Alert.Latency.Seconds(),
+			AlertName:        rc.Alert.AlertName,
+			SearchID:         rc.Alert.SearchID,
+			EndpointPath:     rc.Alert.EndpointPath,
+			HTTPMethod:       rc.Alert.HTTPMethod,
+		},
+	}
+
+	if rc.Error != nil {
+		jr.Error = rc.Error.Error()
+	}
+
+	for _, result := range rc.SessionTerminationResults {
+		jtr := jsonTerminationResult{
+			SessionID: result.SessionID,
+			IPAddress: result.IPAddress,
+			Username:  result.Username,
+			ExitCode:  result.ExitCode,
+			StdOut:    result.StdOut,
+			StdErr:    result.StdErr,
+		}
+		if result.Error != nil {
+			jtr.Error = result.Error.Error()
+		}
+		jr.SessionTerminationResults = append(jr.SessionTerminationResults, jtr)
+	}
+
+	for _, step := range rc.Steps {
+		js := jsonStep{
+			Action: step.Action,
+			Note:   step.Note,
+		}
+		if step.Error != nil {
+			js.Error = step.Error.Error()
+		}
+		jr.Steps = append(jr.Steps, js)
+	}
+
+	return json.Marshal(jr)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. This allows event
+// Records recorded in JSON format (e.g., by the JSON events log) to be read
+// back for reporting purposes. Error values are restored using their string
+// representation and alert request headers are not available.
+func (rc *Record) UnmarshalJSON(data []byte) error {
+
+	var jr jsonRecord
+	if err := json.Unmarshal(data, &jr); err != nil {
+		return err
+	}
+
+	record := Record{
+		Action: jr.Action,
+		Note:   jr.Note,
+		Alert: SplunkAlertEvent{
+			Username:         jr.Alert.Username,
+			ReportedUsername: jr.Alert.ReportedUsername,
+			UserIP:           jr.Alert.UserIP,
+			PayloadSenderIP:  jr.Alert.PayloadSenderIP,
+			ArrivalTime:      jr.Alert.ArrivalTime,
+			LocalTime:        jr.Alert.LocalTime,
+			EventTime:        jr.Alert.EventTime,
+			Latency:          time.Duration(jr.Alert.LatencySeconds * float64(time.Second)),
+			AlertName:        jr.Alert.AlertName,
+			SearchID:         jr.Alert.SearchID,
+			EndpointPath:     jr.Alert.EndpointPath,
+			HTTPMethod:       jr.Alert.HTTPMethod,
+		},
+	}
+
+	if jr.Error != "" {
+		record.Error = errors.New(jr.Error)
+	}
+
+	for _, jtr := range jr.SessionTerminationResults {
+		result := ezproxy.TerminateUserSessionResult{
+			UserSession: ezproxy.UserSession{
+				SessionID: jtr.SessionID,
+				IPAddress: jtr.IPAddress,
+				Username:  jtr.Username,
+			},
+			ExitCode: jtr.ExitCode,
+			StdOut:   jtr.StdOut,
+			StdErr:   jtr.StdErr,
+		}
+		if jtr.Error != "" {
+			result.Error = errors.New(jtr.Error)
+		}
+		record.SessionTerminationResults = append(record.SessionTerminationResults, result)
+	}
+
+	for _, js := range jr.Steps {
+		step := Step{
+			Action: js.Action,
+			Note:   js.Note,
+		}
+		if js.Error != "" {
+			step.Error = errors.New(js.Error)
+		}
+		record.Steps = append(record.Steps, step)
+	}
+
+	*rc = record
+
+	return nil
+}
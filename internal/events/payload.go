@@ -16,7 +16,11 @@
 
 package events
 
-import "net/http"
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
 
 // SplunkSampleAlertPayload maps to the sample JSON payload provided by the
 // Splunk webhook documentation. This payload is submitted via webhook request
@@ -168,9 +172,15 @@ type SplunkAlertPayloadV2 struct {
 type SplunkAlertEvent struct {
 
 	// Username is the username reported by Splunk and represents a user logged
-	// into EZproxy.
+	// into EZproxy. This value has already been normalized using the
+	// configured username normalization settings and is the value used for
+	// all ignore checks, disabled status checks and file entries.
 	Username string
 
+	// ReportedUsername is the username as originally reported by Splunk,
+	// before any normalization was applied.
+	ReportedUsername string
+
 	// UserIP is the IP Address of the user logged into EZproxy.
 	UserIP string
 
@@ -180,6 +190,21 @@ type SplunkAlertEvent struct {
 	// ArrivalTime is the time when the Splunk alert was received.
 	ArrivalTime string
 
+	// EventTime is the time of the user activity which triggered the alert
+	// recorded in RFC3339 format. This is empty if the time could not be
+	// determined from the alert payload.
+	EventTime string
+
+	// Latency is the time between the user activity which triggered the
+	// alert and the arrival of the alert. This is zero if the time of the
+	// user activity could not be determined.
+	Latency time.Duration
+
+	// StaleReason explains why the alert was considered stale (or
+	// future-dated). If set, the alert is recorded and reported, but the
+	// user account is not disabled.
+	StaleReason string
+
 	// LocalTime is the time when the Splunk alert was received recorded in
 	// 24hr local time. This is a workaround for Teams choosing to ignore
 	// time.RFC3339 designation that I encountered while developing
@@ -200,6 +225,14 @@ type SplunkAlertEvent struct {
 	// the only supported HTTP method.
 	HTTPMethod string
 
-	// Headers is a set of HTTP headers sent with the alert payload.
-	Headers http.Header
+	// Headers is a set of HTTP headers sent with the alert payload. The
+	// headers are excluded when an alert is recorded in JSON format (e.g.,
+	// by the journal) as they may include credentials (e.g., Authorization)
+	// supplied by the alert sender. Headers is nil for alerts restored from
+	// that format (e.g., notifications loaded from the outbox at startup).
+	Headers http.Header `json:"-"`
+
+	// Payload is the alert payload (JSON) as received from the alert
+	// sender.
+	Payload json.RawMessage
 }
@@ -37,6 +37,7 @@ const (
 	ActionSuccessTerminatedUserSession  string = "User sessions terminated"
 
 	ActionSkippedTerminateUserSessions string = "User sessions termination not enabled; skipped"
+	ActionSkippedStaleAlert            string = "Username disable skipped due to stale alert"
 
 	ActionFailureDisableRequestReceived   string = "Disable user account request log failure"
 	ActionFailureDisabledUsername         string = "Username disable failure"
@@ -80,6 +81,11 @@ type Record struct {
 	// SessionTerminationResults is a collection of results from attempts to
 	// terminate sessions for the username specified in the alert payload.
 	SessionTerminationResults []ezproxy.TerminateUserSessionResult
+
+	// Steps is the collection of event Records, in the order recorded,
+	// combined to create a consolidated event Record. This field is empty
+	// for event Records which are not consolidated.
+	Steps []Step
 }
 
 // NewRecord is a factory function that creates a Record from provided
@@ -151,6 +157,7 @@ func (rc Record) Valid() (bool, error) {
 	case ActionSuccessIgnoredIPAddress:
 	case ActionSuccessTerminatedUserSession:
 	case ActionSkippedTerminateUserSessions:
+	case ActionSkippedStaleAlert:
 	case ActionFailureDisableRequestReceived:
 	case ActionFailureDisabledUsername:
 	case ActionFailureDuplicatedUsername:
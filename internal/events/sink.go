@@ -0,0 +1,28 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+// RecordSink is implemented by outputs which receive a copy of every Record
+// generated while processing a received alert. Unlike notifications, Records
+// are written to sinks immediately and are not subject to rate limits or
+// retries.
+type RecordSink interface {
+
+	// WriteRecord writes the provided Record to the sink. An error is
+	// returned if the Record could not be written.
+	WriteRecord(record Record) error
+}
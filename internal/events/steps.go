@@ -0,0 +1,69 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+// Step is a summary of a single event Record included in a consolidated
+// event Record.
+type Step struct {
+
+	// Action is the Action of the included event Record.
+	Action string
+
+	// Note is the Note of the included event Record.
+	Note string
+
+	// Error is the Error of the included event Record.
+	Error error
+}
+
+// Consolidate combines the provided event Records, recorded while
+// processing the same alert, into a single event Record which lists each as
+// a step. The Action and Note of the last event Record are used along with
+// the latest error and session termination results recorded.
+func Consolidate(records []Record) Record {
+
+	if len(records) == 0 {
+		return Record{}
+	}
+
+	last := records[len(records)-1]
+
+	consolidated := Record{
+		Alert:  last.Alert,
+		Note:   last.Note,
+		Action: last.Action,
+		Steps:  make([]Step, 0, len(records)),
+	}
+
+	for _, record := range records {
+		consolidated.Steps = append(consolidated.Steps, Step{
+			Action: record.Action,
+			Note:   record.Note,
+			Error:  record.Error,
+		})
+
+		if record.Error != nil {
+			consolidated.Error = record.Error
+		}
+
+		if record.SessionTerminationResults != nil {
+			consolidated.SessionTerminationResults = record.SessionTerminationResults
+		}
+	}
+
+	return consolidated
+}
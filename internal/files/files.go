@@ -19,11 +19,14 @@
 package files
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"strings"
 	"text/template"
 
 	"github.com/atc0005/brick/internal/events"
+	"github.com/atc0005/brick/internal/usernames"
 	"github.com/atc0005/go-ezproxy"
 )
 
@@ -37,15 +40,26 @@ type fileEntry struct {
 	IgnoredEntriesFile string
 }
 
+// execute renders the template using the entry. The HTTP headers sent with
+// the alert are removed first as they may include credentials (e.g.,
+// Authorization) and the flat-files are usually readable by other
+// applications (e.g., EZproxy, fail2ban).
+func (entry fileEntry) execute(w io.Writer, tmpl *template.Template) error {
+	entry.Alert.Headers = nil
+
+	return tmpl.Execute(w, entry)
+}
+
 // FlatFile represents a text file that this application is responsible for
 // populating. This includes the disable users file and the events log file
 // parsed by fail2ban.
 type FlatFile struct {
-	// FileOwner represents the OS user account that owns this file
+	// FileOwner represents the OS user account that owns this file. If
+	// specified, ownership is applied by Ensure.
 	FileOwner string
 
 	// FileGroup represents the OS user group with defined permissions for this
-	// file
+	// file. If specified, group ownership is applied by Ensure.
 	FileGroup string
 
 	// FilePermissions represents the classic POSIX read, write, execute bits
@@ -134,30 +148,88 @@ type IgnoredSources struct {
 	IgnoredUsersFile       string
 	IgnoredIPAddressesFile string
 	IgnoreLookupErrors     bool
+
+	// UsernameNormalizer is applied to entries in the ignored users file
+	// before comparing them against the (already normalized) username from
+	// an alert. Entries are compared as-is if not set.
+	UsernameNormalizer *usernames.Normalizer
+}
+
+// ReportedUserEventsTemplateFiles is the collection of optional template
+// files used in place of the built-in templates when writing entries to the
+// reported user events log. An empty value indicates that the built-in
+// template should be used.
+type ReportedUserEventsTemplateFiles struct {
+	Report        string
+	DisableFirst  string
+	DisableRepeat string
+	Ignored       string
+	Terminated    string
 }
 
 // NewReportedUserEventsLog constructs a ReportedUserEventsLog type with
-// parsed templates already set.
-func NewReportedUserEventsLog(path string, permissions os.FileMode) *ReportedUserEventsLog {
+// parsed templates already set. User-provided template files are used in
+// place of the built-in templates if specified. An error is returned if any
+// template fails to load, parse or render a sample entry. The owner and group
+// values are optional.
+func NewReportedUserEventsLog(
+	path string,
+	permissions os.FileMode,
+	owner string,
+	group string,
+	templateFiles ReportedUserEventsTemplateFiles,
+) (*ReportedUserEventsLog, error) {
 
 	// parse templates
-	reportedUserEventTemplate := template.Must(template.New(
-		"reportedUserEventTemplate").Parse(reportedUserEventTemplateText))
+	reportedUserEventTemplate, err := loadTemplate(
+		"reportedUserEventTemplate",
+		reportedUserEventTemplateText,
+		templateFiles.Report,
+	)
+	if err != nil {
+		return nil, err
+	}
 
-	disabledUserFirstEventTemplate := template.Must(template.New(
-		"disabledUserFirstEventTemplate").Parse(disabledUserFirstEventTemplateText))
+	disabledUserFirstEventTemplate, err := loadTemplate(
+		"disabledUserFirstEventTemplate",
+		disabledUserFirstEventTemplateText,
+		templateFiles.DisableFirst,
+	)
+	if err != nil {
+		return nil, err
+	}
 
-	disabledUserRepeatEventTemplate := template.Must(template.New(
-		"disabledUserRepeatEventTemplate").Parse(disabledUserRepeatEventTemplateText))
+	disabledUserRepeatEventTemplate, err := loadTemplate(
+		"disabledUserRepeatEventTemplate",
+		disabledUserRepeatEventTemplateText,
+		templateFiles.DisableRepeat,
+	)
+	if err != nil {
+		return nil, err
+	}
 
-	ignoredUserEventTemplate := template.Must(template.New(
-		"ignoredUserEventTemplate").Parse(ignoredUserEventTemplateText))
+	ignoredUserEventTemplate, err := loadTemplate(
+		"ignoredUserEventTemplate",
+		ignoredUserEventTemplateText,
+		templateFiles.Ignored,
+	)
+	if err != nil {
+		return nil, err
+	}
 
-	terminatedUserSessionEventTemplate := template.Must(template.New(
-		"terminatedUserSessionEventTemplate").Parse(terminatedUserEventTemplateText))
+	terminatedUserSessionEventTemplate, err := loadTemplate(
+		"terminatedUserSessionEventTemplate",
+		terminatedUserEventTemplateText,
+		templateFiles.Terminated,
+	)
+	if err != nil {
+		return nil, err
+	}
 
 	ruel := ReportedUserEventsLog{
 		FlatFile: FlatFile{
+			FileOwner:       owner,
+			FileGroup:       group,
 			FilePath:        path,
 			FilePermissions: permissions,
 		},
@@ -168,31 +240,44 @@ func NewReportedUserEventsLog(path string, permissions os.FileMode) *ReportedUse
 		TerminateUserSessionEventTemplate: terminatedUserSessionEventTemplate,
 	}
 
-	return &ruel
+	return &ruel, nil
 
 }
 
 // NewDisabledUsers constructs a DisabledUsers type with parsed template
-// already set.
-func NewDisabledUsers(path string, entrySuffix string, permissions os.FileMode) *DisabledUsers {
-
-	// parse template for disabled users file, provide a ToLower template
-	// function that can be used to case-fold values written to the disabled
-	// users file
-	disabledUsersFileTemplate := template.Must(
-		template.New(
-			"disabledUsersFileTemplate",
-		).Funcs(
-			template.FuncMap{
-				"ToLower": strings.ToLower,
-			},
-		).Parse(
-			disabledUsersFileTemplateText,
-		),
+// already set. A user-provided template file is used in place of the
+// built-in template if specified. An error is returned if the template fails
+// to load, parse or render a sample entry. The owner and group values are
+// optional.
+func NewDisabledUsers(
+	path string,
+	entrySuffix string,
+	permissions os.FileMode,
+	owner string,
+	group string,
+	templateFile string,
+) (*DisabledUsers, error) {
+
+	// parse template for disabled users file; the template helper functions
+	// (e.g., lower) can be used to further adjust values written to the
+	// disabled users file
+	disabledUsersFileTemplate, err := loadTemplate(
+		"disabledUsersFileTemplate",
+		disabledUsersFileTemplateText,
+		templateFile,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateDisabledUsersTemplate(disabledUsersFileTemplate, entrySuffix); err != nil {
+		return nil, err
+	}
 
 	du := DisabledUsers{
 		FlatFile: FlatFile{
+			FileOwner:       owner,
+			FileGroup:       group,
 			FilePath:        path,
 			FilePermissions: permissions,
 		},
@@ -200,22 +285,54 @@ func NewDisabledUsers(path string, entrySuffix string, permissions os.FileMode)
 		EntrySuffix: entrySuffix,
 	}
 
-	return &du
+	return &du, nil
 
 }
 
-// NewIgnoredSources constructs an IgnoredSources type
+// NewIgnoredSources constructs an IgnoredSources type. The optional username
+// normalizer is applied to entries in the ignored users file.
 func NewIgnoredSources(
 	ignoredUsersFile string,
 	ignoredIPAddressesFile string,
 	ignoreLookupErrors bool,
+	usernameNormalizer *usernames.Normalizer,
 ) IgnoredSources {
 
 	ignoredSources := IgnoredSources{
 		IgnoredUsersFile:       ignoredUsersFile,
 		IgnoredIPAddressesFile: ignoredIPAddressesFile,
 		IgnoreLookupErrors:     ignoreLookupErrors,
+		UsernameNormalizer:     usernameNormalizer,
 	}
 
 	return ignoredSources
 }
+
+// validateDisabledUsersTemplate confirms that the entry rendered by the
+// provided disabled users file template for a sample alert includes at least
+// one line other than comments and that the username is included. These lines
+// are used to determine whether a user account is already disabled.
+func validateDisabledUsersTemplate(tmpl *template.Template, entrySuffix string) error {
+
+	sample := sampleFileEntry()
+
+	lines, err := disabledUserEntryLines(sample.Alert, &DisabledUsers{
+		EntrySuffix: entrySuffix,
+		Template:    tmpl,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		if strings.Contains(strings.ToLower(line), sample.Alert.Username) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(
+		"disabled users file template must write a line other than a comment which includes the username; got %q for sample user %q",
+		lines,
+		sample.Alert.Username,
+	)
+}
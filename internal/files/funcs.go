@@ -0,0 +1,91 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/apex/log"
+
+	"github.com/atc0005/brick/internal/caller"
+	"github.com/atc0005/brick/internal/tmplutils"
+)
+
+// sampleFileEntry returns a fileEntry with all fields populated. This is
+// used to validate templates at startup so that errors are reported then
+// instead of when the first alert is received.
+func sampleFileEntry() fileEntry {
+	return fileEntry{
+		Alert:              tmplutils.SampleAlert(),
+		UserSession:        tmplutils.SampleUserSession(),
+		EntrySuffix:        "::deny",
+		IgnoredEntriesFile: "/usr/local/etc/brick/users.brick-ignored.txt",
+	}
+}
+
+// loadTemplate parses and validates the template used to generate entries
+// for one of the flat-files managed by this application. If templateFile is
+// specified its contents are used, otherwise the provided built-in template
+// text is used. The parsed template is executed against a sample entry to
+// catch errors (e.g., references to fields which do not exist) early.
+func loadTemplate(name string, builtinText string, templateFile string) (*template.Template, error) {
+
+	myFuncName := caller.GetFuncName()
+
+	tmplText := builtinText
+	if templateFile != "" {
+		log.Debugf("%s: Loading %s from %q", myFuncName, name, templateFile)
+
+		// #nosec G304
+		content, err := os.ReadFile(filepath.Clean(templateFile))
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%s: failed to read template file %q for %s: %w",
+				myFuncName,
+				templateFile,
+				name,
+				err,
+			)
+		}
+		tmplText = string(content)
+	}
+
+	tmpl, err := template.New(name).Funcs(tmplutils.Funcs()).Parse(tmplText)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%s: failed to parse %s: %w",
+			myFuncName,
+			name,
+			err,
+		)
+	}
+
+	if err := sampleFileEntry().execute(io.Discard, tmpl); err != nil {
+		return nil, fmt.Errorf(
+			"%s: failed to validate %s using sample entry: %w",
+			myFuncName,
+			name,
+			err,
+		)
+	}
+
+	return tmpl, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/apex/log"
+
+	"github.com/atc0005/brick/internal/caller"
+	"github.com/atc0005/brick/internal/events"
+)
+
+// JSONEventsLog represents an optional log file where this application
+// records every event Record as a single JSON object per line. Unlike the
+// ReportedUserEventsLog, this log file is not intended for fail2ban or
+// humans, but for external tooling (e.g., Filebeat, Splunk forwarders, jq)
+// which need to reliably parse the results of processing each alert.
+type JSONEventsLog struct {
+	FlatFile
+
+	// mu ensures that each JSON object is written as a complete line.
+	mu sync.Mutex
+}
+
+// NewJSONEventsLog constructs a JSONEventsLog type.
+func NewJSONEventsLog(path string, permissions os.FileMode) *JSONEventsLog {
+
+	jel := JSONEventsLog{
+		FlatFile: FlatFile{
+			FilePath:        path,
+			FilePermissions: permissions,
+		},
+	}
+
+	return &jel
+
+}
+
+// WriteRecord implements the events.RecordSink interface. The provided
+// Record is encoded as a single line of JSON and appended to the log file.
+func (jel *JSONEventsLog) WriteRecord(record events.Record) error {
+
+	myFuncName := caller.GetFuncName()
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf(
+			"%s: error encoding record for file %q: %w",
+			myFuncName,
+			jel.FilePath,
+			err,
+		)
+	}
+	line = append(line, '\n')
+
+	jel.mu.Lock()
+	defer jel.mu.Unlock()
+
+	// #nosec G304
+	f, opErr := os.OpenFile(filepath.Clean(jel.FilePath), os.O_APPEND|os.O_CREATE|os.O_WRONLY, jel.FilePermissions)
+	if opErr != nil {
+		return fmt.Errorf(
+			"%s: error encountered opening file %q: %w",
+			myFuncName,
+			jel.FilePath,
+			opErr,
+		)
+	}
+
+	// #nosec G307
+	// Believed to be a false-positive from recent gosec release
+	// https://github.com/securego/gosec/issues/714
+	defer func(filename string) {
+		if err := f.Close(); err != nil {
+			// Ignore "file already closed" errors
+			if !errors.Is(err, os.ErrClosed) {
+				log.Errorf(
+					"%s: failed to close file %q: %s",
+					myFuncName,
+					filename,
+					err.Error(),
+				)
+			}
+		}
+	}(jel.FilePath)
+
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf(
+			"%s: error writing to file %q: %w",
+			myFuncName,
+			jel.FilePath,
+			err,
+		)
+	}
+
+	return nil
+
+}
@@ -18,7 +18,6 @@ package files
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/apex/log"
 
@@ -34,12 +33,7 @@ import (
 // message to the reported user events log for potential automation.
 func logEventDisableRequestReceived(alert events.SplunkAlertEvent, reportedUserEventsLog *ReportedUserEventsLog) events.Record {
 
-	requestReceivedMessage := fmt.Sprintf(
-		"Disable request received from %q for username %q from IP %q",
-		alert.PayloadSenderIP,
-		alert.Username,
-		alert.UserIP,
-	)
+	requestReceivedMessage := DisableRequestReceivedNote(alert)
 
 	log.Debug(caller.GetFuncFileLineInfo())
 	log.Infof(requestReceivedMessage)
@@ -53,10 +47,9 @@ func logEventDisableRequestReceived(alert events.SplunkAlertEvent, reportedUserE
 		reportedUserEventsLog.FilePermissions,
 	); err != nil {
 		recordEventErr := fmt.Errorf(
-			"func %s: error updating events log file %q: %w",
+			"func %s: %w",
 			caller.GetFuncName(),
-			reportedUserEventsLog.FilePath,
-			err,
+			EventsLogError(reportedUserEventsLog.FilePath, err),
 		)
 
 		return events.NewRecord(
@@ -99,12 +92,7 @@ func logEventDisablingUsername(alert events.SplunkAlertEvent) {
 // templated message to the reported user events log for potential automation.
 func logEventDisabledUsername(alert events.SplunkAlertEvent, reportedUserEventsLog *ReportedUserEventsLog) events.Record {
 
-	disableSuccessMsg := fmt.Sprintf(
-		"Disabled username %q from IP %q per report from %q",
-		alert.Username,
-		alert.UserIP,
-		alert.PayloadSenderIP,
-	)
+	disableSuccessMsg := DisabledUsernameNote(alert)
 
 	log.Debug(caller.GetFuncFileLineInfo())
 
@@ -121,10 +109,9 @@ func logEventDisabledUsername(alert events.SplunkAlertEvent, reportedUserEventsL
 		reportedUserEventsLog.FilePermissions,
 	); err != nil {
 		recordEventErr := fmt.Errorf(
-			"func %s: error updating events log file %q: %w",
+			"func %s: %w",
 			caller.GetFuncName(),
-			reportedUserEventsLog.FilePath,
-			err,
+			EventsLogError(reportedUserEventsLog.FilePath, err),
 		)
 
 		return events.NewRecord(
@@ -161,12 +148,7 @@ func logEventUsernameAlreadyDisabled(alert events.SplunkAlertEvent, reportedUser
 	// 	alert.UserIP,
 	// )
 
-	alreadyDisabledMsg := fmt.Sprintf(
-		"Username %q already disabled (current IP %q per report from %q)",
-		alert.Username,
-		alert.UserIP,
-		alert.PayloadSenderIP,
-	)
+	alreadyDisabledMsg := UsernameAlreadyDisabledNote(alert)
 
 	log.Debug(caller.GetFuncFileLineInfo())
 	log.Info(alreadyDisabledMsg)
@@ -180,10 +162,9 @@ func logEventUsernameAlreadyDisabled(alert events.SplunkAlertEvent, reportedUser
 		reportedUserEventsLog.FilePermissions,
 	); err != nil {
 		recordEventErr := fmt.Errorf(
-			"func %s: error updating events log file %q: %w",
+			"func %s: %w",
 			caller.GetFuncName(),
-			reportedUserEventsLog.FilePath,
-			err,
+			EventsLogError(reportedUserEventsLog.FilePath, err),
 		)
 
 		return events.NewRecord(
@@ -213,13 +194,7 @@ func logEventUsernameAlreadyDisabled(alert events.SplunkAlertEvent, reportedUser
 // for potential automation.
 func logEventIgnoredIPAddress(alert events.SplunkAlertEvent, reportedUserEventsLog *ReportedUserEventsLog, ignoredEntriesFile string) events.Record {
 
-	ignoreIPAddressMsg := fmt.Sprintf(
-		"Ignored disable request from %q for user %q from IP %q due to presence in %q file.",
-		alert.PayloadSenderIP,
-		alert.Username,
-		alert.UserIP,
-		ignoredEntriesFile,
-	)
+	ignoreIPAddressMsg := IgnoredNote(alert, ignoredEntriesFile)
 
 	log.Debug(caller.GetFuncFileLineInfo())
 
@@ -235,10 +210,9 @@ func logEventIgnoredIPAddress(alert events.SplunkAlertEvent, reportedUserEventsL
 		reportedUserEventsLog.FilePermissions,
 	); err != nil {
 		recordEventErr := fmt.Errorf(
-			"func %s: error updating events log file %q: %w",
+			"func %s: %w",
 			caller.GetFuncName(),
-			reportedUserEventsLog.FilePath,
-			err,
+			EventsLogError(reportedUserEventsLog.FilePath, err),
 		)
 
 		return events.NewRecord(
@@ -267,13 +241,7 @@ func logEventIgnoredIPAddress(alert events.SplunkAlertEvent, reportedUserEventsL
 // for potential automation.
 func logEventIgnoredUsername(alert events.SplunkAlertEvent, reportedUserEventsLog *ReportedUserEventsLog, ignoredEntriesFile string) events.Record {
 
-	ignoreUsernameMsg := fmt.Sprintf(
-		"Ignored disable request from %q for user %q from IP %q due to presence in %q file.",
-		alert.PayloadSenderIP,
-		alert.Username,
-		alert.UserIP,
-		ignoredEntriesFile,
-	)
+	ignoreUsernameMsg := IgnoredNote(alert, ignoredEntriesFile)
 
 	log.Debug(caller.GetFuncFileLineInfo())
 
@@ -289,10 +257,9 @@ func logEventIgnoredUsername(alert events.SplunkAlertEvent, reportedUserEventsLo
 		reportedUserEventsLog.FilePermissions,
 	); err != nil {
 		recordEventErr := fmt.Errorf(
-			"func %s: error updating events log file %q: %w",
+			"func %s: %w",
 			caller.GetFuncName(),
-			reportedUserEventsLog.FilePath,
-			err,
+			EventsLogError(reportedUserEventsLog.FilePath, err),
 		)
 
 		return events.NewRecord(
@@ -410,10 +377,9 @@ func logEventTerminatedUserSessions(
 			); err != nil {
 
 				recordEventErr = fmt.Errorf(
-					"func %s: error updating events log file %q: %w",
+					"func %s: %w",
 					caller.GetFuncName(),
-					reportedUserEventsLog.FilePath,
-					recordEventErr,
+					EventsLogError(reportedUserEventsLog.FilePath, err),
 				)
 
 				return events.NewRecord(
@@ -442,17 +408,13 @@ func logEventTerminatedUserSessions(
 
 	if terminationResults.HasError() {
 
-		terminationResultsFailureMsg := fmt.Sprintf(
-			"%d errors occurred while terminating %d sessions for username %s",
+		terminationResultsFailureMsg := TerminationFailureNote(
+			alert,
 			failedTerminationsNum,
 			len(terminationResults),
-			alert.Username,
 		)
 
-		terminationResultsError := fmt.Errorf(
-			"failed to terminate sessions: %s",
-			strings.Join(failedTerminationsSessionIDs, ", "),
-		)
+		terminationResultsError := TerminationFailureError(failedTerminationsSessionIDs)
 
 		return events.NewRecord(
 			alert,
@@ -468,11 +430,7 @@ func logEventTerminatedUserSessions(
 	// *something* was terminated, but this may not be true (e.g., the recent
 	// test case was a complete failure and still that suffix is used)
 
-	sessionTerminationResultsSuccessMsg := fmt.Sprintf(
-		"Successfully terminated all %d user sessions for %q",
-		len(terminationResults),
-		alert.Username,
-	)
+	sessionTerminationResultsSuccessMsg := TerminationSuccessNote(alert, len(terminationResults))
 
 	return events.NewRecord(
 		alert,
@@ -0,0 +1,143 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/brick/internal/events"
+)
+
+// The functions in this file build the Note and Error values recorded for
+// each action. They are exported so that the same text is used when
+// previewing notifications.
+
+// DisableRequestReceivedNote returns the note recorded when a disable request
+// is received for the user specified in the provided alert.
+func DisableRequestReceivedNote(alert events.SplunkAlertEvent) string {
+	return fmt.Sprintf(
+		"Disable request received from %q for username %q from IP %q",
+		alert.PayloadSenderIP,
+		alert.Username,
+		alert.UserIP,
+	)
+}
+
+// DisabledUsernameNote returns the note recorded when the user specified in
+// the provided alert is disabled.
+func DisabledUsernameNote(alert events.SplunkAlertEvent) string {
+	return fmt.Sprintf(
+		"Disabled username %q from IP %q per report from %q",
+		alert.Username,
+		alert.UserIP,
+		alert.PayloadSenderIP,
+	)
+}
+
+// UsernameAlreadyDisabledNote returns the note recorded when the user
+// specified in the provided alert is already disabled.
+func UsernameAlreadyDisabledNote(alert events.SplunkAlertEvent) string {
+	return fmt.Sprintf(
+		"Username %q already disabled (current IP %q per report from %q)",
+		alert.Username,
+		alert.UserIP,
+		alert.PayloadSenderIP,
+	)
+}
+
+// IgnoredNote returns the note recorded when a disable request is ignored
+// due to an entry in the provided ignored users or IP Addresses file.
+func IgnoredNote(alert events.SplunkAlertEvent, ignoredEntriesFile string) string {
+	return fmt.Sprintf(
+		"Ignored disable request from %q for user %q from IP %q due to presence in %q file.",
+		alert.PayloadSenderIP,
+		alert.Username,
+		alert.UserIP,
+		ignoredEntriesFile,
+	)
+}
+
+// StaleAlertNote returns the note recorded when the user specified in the
+// provided stale (or future-dated) alert is not disabled.
+func StaleAlertNote(alert events.SplunkAlertEvent) string {
+	return fmt.Sprintf(
+		"Username %q from source IP %q not disabled: %s",
+		alert.Username,
+		alert.UserIP,
+		alert.StaleReason,
+	)
+}
+
+// SkippedSessionsNote returns the note recorded when termination of the
+// provided user sessions is skipped.
+func SkippedSessionsNote(sessionIDs []string) string {
+	return fmt.Sprintf(
+		`Skipping termination of sessions: "%s"`,
+		strings.Join(sessionIDs, `", "`),
+	)
+}
+
+// TerminationSuccessNote returns the note recorded when all of the provided
+// number of user sessions are terminated.
+func TerminationSuccessNote(alert events.SplunkAlertEvent, sessions int) string {
+	return fmt.Sprintf(
+		"Successfully terminated all %d user sessions for %q",
+		sessions,
+		alert.Username,
+	)
+}
+
+// TerminationFailureNote returns the note recorded when termination of some
+// of the provided number of user sessions fails.
+func TerminationFailureNote(alert events.SplunkAlertEvent, failed int, sessions int) string {
+	return fmt.Sprintf(
+		"%d errors occurred while terminating %d sessions for username %s",
+		failed,
+		sessions,
+		alert.Username,
+	)
+}
+
+// TerminationFailureError returns the error recorded when termination of the
+// provided user sessions fails.
+func TerminationFailureError(sessionIDs []string) error {
+	return fmt.Errorf(
+		"failed to terminate sessions: %s",
+		strings.Join(sessionIDs, ", "),
+	)
+}
+
+// SessionLookupError returns the error recorded when the user sessions for
+// the user specified in the provided alert cannot be retrieved.
+func SessionLookupError(alert events.SplunkAlertEvent, err error) error {
+	return fmt.Errorf(
+		"error retrieving matching user sessions associated with user %q: %w",
+		alert.Username,
+		err,
+	)
+}
+
+// EventsLogError returns the error recorded when the provided reported user
+// events log file cannot be updated.
+func EventsLogError(filePath string, err error) error {
+	return fmt.Errorf(
+		"error updating events log file %q: %w",
+		filePath,
+		err,
+	)
+}
@@ -0,0 +1,174 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+
+package files
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// Permission bits checked for the "other" class; shifted to check the owner
+// and group classes.
+const (
+	permRead   os.FileMode = 0o4
+	permSearch os.FileMode = 0o1
+)
+
+// chownFile applies the specified owner and group to the file or directory.
+// An empty owner or group value leaves that attribute unchanged.
+func chownFile(filename string, owner string, group string) error {
+
+	uid, gid := -1, -1
+
+	if owner != "" {
+		u, err := user.Lookup(owner)
+		if err != nil {
+			return fmt.Errorf("error looking up file owner %q: %w", owner, err)
+		}
+
+		if uid, err = strconv.Atoi(u.Uid); err != nil {
+			return fmt.Errorf("unexpected uid %q for file owner %q: %w", u.Uid, owner, err)
+		}
+	}
+
+	if group != "" {
+		g, err := user.LookupGroup(group)
+		if err != nil {
+			return fmt.Errorf("error looking up file group %q: %w", group, err)
+		}
+
+		if gid, err = strconv.Atoi(g.Gid); err != nil {
+			return fmt.Errorf("unexpected gid %q for file group %q: %w", g.Gid, group, err)
+		}
+	}
+
+	if err := os.Chown(filename, uid, gid); err != nil {
+		return fmt.Errorf(
+			"error applying ownership %s:%s to file %q: %w",
+			owner,
+			group,
+			filename,
+			err,
+		)
+	}
+
+	return nil
+}
+
+// readableBy confirms that the specified user account has search permission
+// on each parent directory and read permission on the file.
+func readableBy(filename string, username string) error {
+
+	u, err := user.Lookup(username)
+	if err != nil {
+		return fmt.Errorf("error looking up user %q: %w", username, err)
+	}
+
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return fmt.Errorf("unexpected uid %q for user %q: %w", u.Uid, username, err)
+	}
+
+	// root is not restricted by permission bits
+	if uid == 0 {
+		return nil
+	}
+
+	groupIDs, err := u.GroupIds()
+	if err != nil {
+		return fmt.Errorf("error looking up groups for user %q: %w", username, err)
+	}
+
+	gids := make(map[uint32]bool, len(groupIDs))
+	for _, groupID := range groupIDs {
+		gid, err := strconv.ParseUint(groupID, 10, 32)
+		if err != nil {
+			return fmt.Errorf("unexpected gid %q for user %q: %w", groupID, username, err)
+		}
+		gids[uint32(gid)] = true
+	}
+
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+
+	for dir := filepath.Dir(absPath); ; dir = filepath.Dir(dir) {
+		if err := permitted(dir, username, uint32(uid), gids, permSearch); err != nil {
+			return err
+		}
+
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	return permitted(absPath, username, uint32(uid), gids, permRead)
+}
+
+// permitted confirms that the specified user account is granted the
+// requested permission on the path by the owner, group or other class
+// permission bits (whichever applies to the user account).
+func permitted(path string, username string, uid uint32, gids map[uint32]bool, perm os.FileMode) error {
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("unable to determine ownership of %q", path)
+	}
+
+	mode := info.Mode().Perm()
+
+	var granted bool
+	switch {
+	case stat.Uid == uid:
+		granted = mode&(perm<<6) != 0
+	case gids[stat.Gid]:
+		granted = mode&(perm<<3) != 0
+	default:
+		granted = mode&perm != 0
+	}
+
+	if !granted {
+		permName := "read"
+		if perm == permSearch {
+			permName = "search"
+		}
+
+		return fmt.Errorf(
+			"user %q does not have %s permission on %q (owner: %d, group: %d, permissions: %v)",
+			username,
+			permName,
+			path,
+			stat.Uid,
+			stat.Gid,
+			mode,
+		)
+	}
+
+	return nil
+}
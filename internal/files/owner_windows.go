@@ -0,0 +1,42 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+
+package files
+
+import (
+	"errors"
+)
+
+// errOwnershipNotSupported is returned when file ownership is requested on a
+// platform where it is not supported by this application.
+var errOwnershipNotSupported = errors.New("file ownership settings are not supported on Windows")
+
+// chownFile is not supported on Windows. An error is returned if an owner or
+// group is specified.
+func chownFile(_ string, owner string, group string) error {
+	if owner != "" || group != "" {
+		return errOwnershipNotSupported
+	}
+
+	return nil
+}
+
+// readableBy is not supported on Windows.
+func readableBy(_ string, _ string) error {
+	return errOwnershipNotSupported
+}
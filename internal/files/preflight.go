@@ -0,0 +1,231 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apex/log"
+
+	"github.com/atc0005/brick/internal/caller"
+)
+
+// Ensure prepares the file for use by this application. Missing parent
+// directories are created, the file is created with the configured
+// permissions if it does not already exist and ownership is applied if an
+// owner or group is specified. An error is returned if any step fails or if
+// the file cannot be opened for writing.
+func (ff FlatFile) Ensure() error {
+
+	myFuncName := caller.GetFuncName()
+
+	if ff.FilePath == "" {
+		return fmt.Errorf("%s: missing file path", myFuncName)
+	}
+
+	filename := filepath.Clean(ff.FilePath)
+	dir := filepath.Dir(filename)
+
+	if err := ff.ensureDir(dir); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(filename)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if err := ff.create(); err != nil {
+			return err
+		}
+		log.Infof(
+			"%s: created file %q with permissions %v",
+			myFuncName,
+			filename,
+			ff.FilePermissions,
+		)
+
+	case err != nil:
+		return fmt.Errorf(
+			"%s: error checking file %q: %w",
+			myFuncName,
+			filename,
+			err,
+		)
+
+	case !info.Mode().IsRegular():
+		return fmt.Errorf("%s: %q is not a regular file", myFuncName, filename)
+	}
+
+	if ff.FileOwner != "" || ff.FileGroup != "" {
+		if err := chownFile(filename, ff.FileOwner, ff.FileGroup); err != nil {
+			return fmt.Errorf("%s: %w", myFuncName, err)
+		}
+		log.Debugf(
+			"%s: applied ownership %s:%s to %q",
+			myFuncName,
+			ff.FileOwner,
+			ff.FileGroup,
+			filename,
+		)
+	}
+
+	// #nosec G304
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, ff.FilePermissions)
+	if err != nil {
+		return fmt.Errorf(
+			"%s: file %q is not writable: %w",
+			myFuncName,
+			filename,
+			err,
+		)
+	}
+
+	return f.Close()
+}
+
+// ensureDir creates the specified directory and any missing parent
+// directories. Each created directory is granted search permission for each
+// class with read permission on the file and is assigned the same ownership
+// as the file.
+func (ff FlatFile) ensureDir(dir string) error {
+
+	myFuncName := caller.GetFuncName()
+
+	// collect missing directories, deepest first
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		_, err := os.Stat(d)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf(
+				"%s: error checking directory %q: %w",
+				myFuncName,
+				d,
+				err,
+			)
+		}
+		missing = append(missing, d)
+		if d == filepath.Dir(d) {
+			break
+		}
+	}
+
+	dirPerms := dirPermissions(ff.FilePermissions)
+	for i := len(missing) - 1; i >= 0; i-- {
+		d := missing[i]
+
+		if err := os.Mkdir(d, dirPerms); err != nil {
+			return fmt.Errorf(
+				"%s: error creating directory %q: %w",
+				myFuncName,
+				d,
+				err,
+			)
+		}
+
+		// apply permissions explicitly so that they are not reduced by the
+		// process umask
+		if err := os.Chmod(d, dirPerms); err != nil {
+			return fmt.Errorf(
+				"%s: error applying permissions %v to directory %q: %w",
+				myFuncName,
+				dirPerms,
+				d,
+				err,
+			)
+		}
+
+		if ff.FileOwner != "" || ff.FileGroup != "" {
+			if err := chownFile(d, ff.FileOwner, ff.FileGroup); err != nil {
+				return fmt.Errorf("%s: %w", myFuncName, err)
+			}
+		}
+
+		log.Infof("%s: created directory %q with permissions %v", myFuncName, d, dirPerms)
+	}
+
+	return nil
+}
+
+// create creates the empty file with the configured permissions. The
+// permissions are applied explicitly so that they are not reduced by the
+// process umask.
+func (ff FlatFile) create() error {
+
+	myFuncName := caller.GetFuncName()
+	filename := filepath.Clean(ff.FilePath)
+
+	// #nosec G304
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, ff.FilePermissions)
+	if err != nil {
+		return fmt.Errorf(
+			"%s: error creating file %q: %w",
+			myFuncName,
+			filename,
+			err,
+		)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf(
+			"%s: error closing file %q: %w",
+			myFuncName,
+			filename,
+			err,
+		)
+	}
+
+	if err := os.Chmod(filename, ff.FilePermissions); err != nil {
+		return fmt.Errorf(
+			"%s: error applying permissions %v to file %q: %w",
+			myFuncName,
+			ff.FilePermissions,
+			filename,
+			err,
+		)
+	}
+
+	return nil
+}
+
+// CheckReadableBy confirms that the specified OS user account is able to
+// read the file, including search (execute) permission on each parent
+// directory. Only the classic owner, group and other permission bits are
+// considered; access granted or denied by ACLs or security modules such as
+// SELinux is not evaluated.
+func (ff FlatFile) CheckReadableBy(username string) error {
+
+	myFuncName := caller.GetFuncName()
+
+	if err := readableBy(filepath.Clean(ff.FilePath), username); err != nil {
+		return fmt.Errorf("%s: %w", myFuncName, err)
+	}
+
+	return nil
+}
+
+// dirPermissions returns permissions suitable for a directory holding a file
+// with the provided permissions. Search (execute) permission is added for
+// each class that is granted read permission on the file.
+func dirPermissions(filePerms os.FileMode) os.FileMode {
+	perms := filePerms.Perm()
+	return perms | (perms&0o444)>>2
+}
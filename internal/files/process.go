@@ -17,6 +17,7 @@
 package files
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -24,6 +25,7 @@ import (
 	"strings"
 	"sync"
 	"text/template"
+	"time"
 
 	"github.com/apex/log"
 
@@ -33,19 +35,31 @@ import (
 	"github.com/atc0005/brick/internal/caller"
 	"github.com/atc0005/brick/internal/events"
 	"github.com/atc0005/brick/internal/fileutils"
+	"github.com/atc0005/brick/internal/metrics"
 )
 
-func processRecord(record events.Record, notifyWorkQueue chan<- events.Record) {
+// processRecord writes the provided event Record to each of the optional
+// record sinks and then hands it off for notification processing. Failures
+// writing to a sink are logged, but do not prevent notifications.
+func processRecord(record events.Record, notifyWorkQueue chan<- events.Record, recordSinks []events.RecordSink) {
 
 	if record.Error != nil {
 		log.Error(record.Error.Error())
 	}
 
-	// shouldn't encounter "loop variable XYZ captured by func literal" issue
-	// because we're not in a loop (record isn't changing)
-	go func() {
-		notifyWorkQueue <- record
-	}()
+	metrics.ObserveAction(record.Action)
+
+	for _, sink := range recordSinks {
+		if err := sink.WriteRecord(record); err != nil {
+			log.Errorf("failed to write record to sink: %s", err)
+		}
+	}
+
+	// NotifyMgr records each received record in the notifications outbox
+	// before handing it off for delivery, so the record is handed over
+	// directly instead of from a separate goroutine where it could be lost
+	// if this application stops.
+	notifyWorkQueue <- record
 
 }
 
@@ -56,14 +70,21 @@ func processRecord(record events.Record, notifyWorkQueue chan<- events.Record) {
 // alert and request to disable a user account (and disable the associated
 // sessions). This function returns a collection of
 //
+// The provided context is used to abandon session lookup and termination
+// steps which have not yet started if processing is cancelled (e.g., if the
+// shutdown drain timeout is reached). Records are still generated for any
+// abandoned steps so that the outcome is reported.
+//
 // TODO: This function and those called within are *badly* in need of
 // refactoring.
 func ProcessDisableEvent(
+	ctx context.Context,
 	alert events.SplunkAlertEvent,
 	disabledUsers *DisabledUsers,
 	reportedUserEventsLog *ReportedUserEventsLog,
 	ignoredSources IgnoredSources,
 	notifyWorkQueue chan<- events.Record,
+	recordSinks []events.RecordSink,
 	terminateSessions bool,
 	ezproxyActiveFilePath string,
 	ezproxySessionsSearchDelay int,
@@ -83,7 +104,25 @@ func ProcessDisableEvent(
 		reportedUserEventsLog,
 	)
 
-	processRecord(disableRequestReceivedResult, notifyWorkQueue)
+	processRecord(disableRequestReceivedResult, notifyWorkQueue, recordSinks)
+
+	// Stale (or future-dated) alerts which the sysadmin opted to report
+	// instead of reject are recorded, but not acted upon.
+	if alert.StaleReason != "" {
+		processRecord(
+			events.NewRecord(
+				alert,
+				nil,
+				StaleAlertNote(alert),
+				events.ActionSkippedStaleAlert,
+				nil,
+			),
+			notifyWorkQueue,
+			recordSinks,
+		)
+
+		return
+	}
 
 	// check whether username or IP Address is ignored, return early if true
 	// or if there is an error looking up the status which the sysadmin did
@@ -104,7 +143,7 @@ func ProcessDisableEvent(
 		}
 
 		// send record for notification
-		processRecord(ignoredEntryResults, notifyWorkQueue)
+		processRecord(ignoredEntryResults, notifyWorkQueue, recordSinks)
 
 		// exit after sending notification
 		return
@@ -114,7 +153,7 @@ func ProcessDisableEvent(
 
 		// Note: `logEventIgnoredUsername()` is called within `isIgnored()`,
 		// so we refrain from calling it again explicitly here.
-		processRecord(ignoredEntryResults, notifyWorkQueue)
+		processRecord(ignoredEntryResults, notifyWorkQueue, recordSinks)
 
 		// exit after sending notification
 		return
@@ -122,12 +161,7 @@ func ProcessDisableEvent(
 	}
 
 	// check to see if username has already been disabled
-	disabledUserEntry := alert.Username + disabledUsers.EntrySuffix
-	disableEntryFound, disableEntryLookupErr := fileutils.HasLine(
-		disabledUserEntry,
-		"#",
-		disabledUsers.FilePath,
-	)
+	disableEntryFound, disableEntryLookupErr := isDisabled(alert, disabledUsers)
 
 	// Handle logic for disabling user account
 	switch {
@@ -164,7 +198,7 @@ func ProcessDisableEvent(
 			nil,
 		)
 
-		processRecord(result, notifyWorkQueue)
+		processRecord(result, notifyWorkQueue, recordSinks)
 
 		return
 
@@ -184,19 +218,19 @@ func ProcessDisableEvent(
 				nil,
 			)
 
-			processRecord(result, notifyWorkQueue)
+			processRecord(result, notifyWorkQueue, recordSinks)
 
 			return
 		}
 
 		// log success (file, notifications, etc.)
 		disableUsernameResult := logEventDisabledUsername(alert, reportedUserEventsLog)
-		processRecord(disableUsernameResult, notifyWorkQueue)
+		processRecord(disableUsernameResult, notifyWorkQueue, recordSinks)
 
 	case disableEntryFound:
 
 		usernameAlreadyDisabledResult := logEventUsernameAlreadyDisabled(alert, reportedUserEventsLog)
-		processRecord(usernameAlreadyDisabledResult, notifyWorkQueue)
+		processRecord(usernameAlreadyDisabledResult, notifyWorkQueue, recordSinks)
 
 	}
 
@@ -210,6 +244,7 @@ func ProcessDisableEvent(
 		log.Warn("Sessions termination is disabled via configuration setting. Sessions will persist until they timeout.")
 
 		userSessions, userSessionsLookupErr := getUserSessions(
+			ctx,
 			alert,
 			ezproxyActiveFilePath,
 			ezproxySessionsSearchDelay,
@@ -225,7 +260,7 @@ func ProcessDisableEvent(
 				nil,
 			)
 
-			processRecord(record, notifyWorkQueue)
+			processRecord(record, notifyWorkQueue, recordSinks)
 
 		}
 
@@ -234,12 +269,7 @@ func ProcessDisableEvent(
 			userSessionIDs = append(userSessionIDs, session.SessionID)
 		}
 
-		sessionsSkipped := strings.Join(userSessionIDs, `", "`)
-
-		sessionsSkippedMsg := fmt.Sprintf(
-			`Skipping termination of sessions: "%s"`,
-			sessionsSkipped,
-		)
+		sessionsSkippedMsg := SkippedSessionsNote(userSessionIDs)
 
 		log.Warn(sessionsSkippedMsg)
 
@@ -251,11 +281,12 @@ func ProcessDisableEvent(
 			nil,
 		)
 
-		processRecord(record, notifyWorkQueue)
+		processRecord(record, notifyWorkQueue, recordSinks)
 
 	case terminateSessions:
 
 		userSessions, userSessionsLookupErr := getUserSessions(
+			ctx,
 			alert,
 			ezproxyActiveFilePath,
 			ezproxySessionsSearchDelay,
@@ -271,7 +302,7 @@ func ProcessDisableEvent(
 				nil,
 			)
 
-			processRecord(record, notifyWorkQueue)
+			processRecord(record, notifyWorkQueue, recordSinks)
 
 		}
 
@@ -280,6 +311,7 @@ func ProcessDisableEvent(
 		// logEventTerminatedUserSessions is called at the end of the function
 		// to provide a summary of the results.
 		terminateUserSessionsResult := terminateUserSessions(
+			ctx,
 			alert,
 			reportedUserEventsLog,
 			userSessions,
@@ -287,7 +319,7 @@ func ProcessDisableEvent(
 			ezproxyExecutable,
 		)
 
-		processRecord(terminateUserSessionsResult, notifyWorkQueue)
+		processRecord(terminateUserSessionsResult, notifyWorkQueue, recordSinks)
 
 	}
 
@@ -304,10 +336,18 @@ func isIgnored(
 	ignoredSources IgnoredSources,
 ) (bool, events.Record) {
 
-	ignoredUserEntryFound, ignoredUserLookupErr := fileutils.HasLine(
-		alert.Username,
+	// Entries are normalized in the same way as the username from the alert
+	// so that existing entries (e.g., jdoe@example.edu) continue to match
+	// once normalization is enabled.
+	ignoredUserEntryFound, ignoredUserLookupErr := fileutils.HasLineFunc(
 		"#",
 		ignoredSources.IgnoredUsersFile,
+		func(entry string) bool {
+			if ignoredSources.UsernameNormalizer != nil {
+				entry = ignoredSources.UsernameNormalizer.Normalize(entry)
+			}
+			return strings.EqualFold(entry, alert.Username)
+		},
 	)
 
 	if ignoredUserLookupErr != nil {
@@ -398,12 +438,21 @@ func isIgnored(
 }
 
 func getUserSessions(
+	ctx context.Context,
 	alert events.SplunkAlertEvent,
 	ezproxyActiveFilePath string,
 	ezproxySessionsSearchDelay int,
 	ezproxySessionSearchRetries int,
 ) (ezproxy.UserSessions, error) {
 
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf(
+			"session lookup for user %q abandoned: %w",
+			alert.Username,
+			ctx.Err(),
+		)
+	}
+
 	reader, readerErr := activefile.NewReader(alert.Username, ezproxyActiveFilePath)
 	if readerErr != nil {
 		activeFileReaderErr := fmt.Errorf(
@@ -447,11 +496,7 @@ func getUserSessions(
 
 	activeSessions, userSessionsLookupErr := reader.MatchingUserSessions()
 	if userSessionsLookupErr != nil {
-		userSessionsRetrievalErr := fmt.Errorf(
-			"error retrieving matching user sessions associated with user %q: %w",
-			alert.Username,
-			userSessionsLookupErr,
-		)
+		userSessionsRetrievalErr := SessionLookupError(alert, userSessionsLookupErr)
 
 		return nil, userSessionsRetrievalErr
 	}
@@ -460,6 +505,7 @@ func getUserSessions(
 }
 
 func terminateUserSessions(
+	ctx context.Context,
 	alert events.SplunkAlertEvent,
 	reportedUserEventsLog *ReportedUserEventsLog,
 	activeSessions ezproxy.UserSessions,
@@ -514,7 +560,31 @@ func terminateUserSessions(
 		logEventTerminatingUserSession(alert, session)
 	}
 
-	terminationResults := activeSessions.Terminate(ezproxyExecutable)
+	// Terminate each session individually so that the duration of each call
+	// to the EZproxy binary can be recorded. Sessions not yet terminated when
+	// processing is cancelled are recorded as failed attempts.
+	terminationResults := make(ezproxy.TerminateUserSessionResults, 0, len(activeSessions))
+	for _, session := range activeSessions {
+		if ctx.Err() != nil {
+			terminationResults = append(terminationResults, ezproxy.TerminateUserSessionResult{
+				UserSession: session,
+				ExitCode:    -1,
+				Error: fmt.Errorf(
+					"termination of session %q abandoned: %w",
+					session.SessionID,
+					ctx.Err(),
+				),
+			})
+			continue
+		}
+
+		start := time.Now()
+		terminationResults = append(
+			terminationResults,
+			ezproxy.TerminateUserSession(ezproxyExecutable, session)...,
+		)
+		metrics.EZproxyKillDuration.Observe(time.Since(start).Seconds())
+	}
 
 	// User sessions *should* now be terminated; results of the attempts
 	// are recorded for further review to confirm.
@@ -557,6 +627,65 @@ func disableUser(alert events.SplunkAlertEvent, disabledUsers *DisabledUsers) er
 
 }
 
+// disabledUserEntryLines returns the lines of the disabled users file entry
+// rendered for the provided alert, excluding blank lines and comments. These
+// lines are what EZproxy acts upon and are used to determine whether a user
+// account is already disabled.
+func disabledUserEntryLines(alert events.SplunkAlertEvent, disabledUsers *DisabledUsers) ([]string, error) {
+
+	var rendered strings.Builder
+	entry := fileEntry{
+		Alert:       alert,
+		EntrySuffix: disabledUsers.EntrySuffix,
+	}
+	if err := entry.execute(&rendered, disabledUsers.Template); err != nil {
+		return nil, fmt.Errorf(
+			"error rendering disabled users file entry for user %q: %w",
+			alert.Username,
+			err,
+		)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(rendered.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, disabledUsersCommentPrefix) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+// isDisabled indicates whether the user account specified in the provided
+// alert is already disabled. The user account is considered disabled if each
+// of the lines of the disabled users file entry rendered for the alert is
+// already present in the file.
+func isDisabled(alert events.SplunkAlertEvent, disabledUsers *DisabledUsers) (bool, error) {
+
+	lines, err := disabledUserEntryLines(alert, disabledUsers)
+	if err != nil {
+		return false, err
+	}
+
+	if len(lines) == 0 {
+		return false, fmt.Errorf(
+			"disabled users file entry for user %q has no lines other than comments",
+			alert.Username,
+		)
+	}
+
+	for _, line := range lines {
+		found, err := fileutils.HasLine(line, disabledUsersCommentPrefix, disabledUsers.FilePath)
+		if err != nil || !found {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
 // appendToFile is a helper function that accepts a new message, a destination
 // filename and intended permissions for the filename if it does not already
 // exist. All leading and trailing whitespace is removed from the new message
@@ -614,7 +743,7 @@ func appendToFile(entry fileEntry, tmpl *template.Template, filename string, per
 	}()
 
 	log.Debugf("%s: Executing template to update %q", myFuncName, filename)
-	if tmplErr := tmpl.Execute(f, entry); tmplErr != nil {
+	if tmplErr := entry.execute(f, tmpl); tmplErr != nil {
 
 		// if there were template execution errors, go ahead and try to close
 		// the file before returning the template write error
@@ -0,0 +1,321 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atc0005/brick/internal/events"
+	"github.com/atc0005/brick/internal/usernames"
+)
+
+// processTestFiles holds the files used by ProcessDisableEvent tests.
+type processTestFiles struct {
+	disabledUsers         *DisabledUsers
+	reportedUserEventsLog *ReportedUserEventsLog
+	ignoredSources        IgnoredSources
+}
+
+// newProcessTestFiles creates the files used by ProcessDisableEvent using the
+// provided optional disabled users file template.
+func newProcessTestFiles(t *testing.T, disabledUsersTemplate string) processTestFiles {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	var templateFile string
+	if disabledUsersTemplate != "" {
+		templateFile = filepath.Join(dir, "disabled-users.tmpl")
+		if err := os.WriteFile(templateFile, []byte(disabledUsersTemplate), 0o600); err != nil {
+			t.Fatalf("failed to write template file: %v", err)
+		}
+	}
+
+	disabledUsers, err := NewDisabledUsers(
+		filepath.Join(dir, "users.brick-disabled.txt"),
+		"::deny",
+		0o600,
+		"",
+		"",
+		templateFile,
+	)
+	if err != nil {
+		t.Fatalf("failed to create disabled users file: %v", err)
+	}
+
+	reportedUserEventsLog, err := NewReportedUserEventsLog(
+		filepath.Join(dir, "users.brick-reported.log"),
+		0o600,
+		"",
+		"",
+		ReportedUserEventsTemplateFiles{},
+	)
+	if err != nil {
+		t.Fatalf("failed to create reported user events log: %v", err)
+	}
+
+	ignoredSources := NewIgnoredSources(
+		filepath.Join(dir, "users.brick-ignored.txt"),
+		filepath.Join(dir, "ips.brick-ignored.txt"),
+		false,
+		nil,
+	)
+
+	for _, path := range []string{
+		disabledUsers.FilePath,
+		ignoredSources.IgnoredUsersFile,
+		ignoredSources.IgnoredIPAddressesFile,
+	} {
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+	}
+
+	return processTestFiles{
+		disabledUsers:         disabledUsers,
+		reportedUserEventsLog: reportedUserEventsLog,
+		ignoredSources:        ignoredSources,
+	}
+}
+
+// processTestAlert returns an alert for the provided username.
+func processTestAlert(username string, userIP string) events.SplunkAlertEvent {
+	return events.SplunkAlertEvent{
+		Username:         username,
+		ReportedUsername: username,
+		UserIP:           userIP,
+		PayloadSenderIP:  "192.0.2.20",
+		ArrivalTime:      time.Now().Format(time.RFC3339),
+		AlertName:        "test alert",
+		SearchID:         "test-search-id",
+	}
+}
+
+// processActions runs ProcessDisableEvent for the provided alert and returns
+// the actions recorded. Session lookup is abandoned by providing a cancelled
+// context.
+func processActions(t *testing.T, files processTestFiles, alert events.SplunkAlertEvent) []string {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	records := make(chan events.Record, 10)
+	ProcessDisableEvent(
+		ctx,
+		alert,
+		files.disabledUsers,
+		files.reportedUserEventsLog,
+		files.ignoredSources,
+		records,
+		nil,
+		false,
+		"",
+		0,
+		0,
+		"",
+	)
+	close(records)
+
+	var actions []string
+	for record := range records {
+		actions = append(actions, record.Action)
+	}
+
+	return actions
+}
+
+// hasAction indicates whether the provided action is present.
+func hasAction(actions []string, action string) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+func TestProcessDisableEventAlreadyDisabled(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  string
+		wantEntry string
+	}{
+		{
+			name:      "built-in template",
+			wantEntry: "jdoe::deny",
+		},
+		{
+			name: "custom template",
+			template: "# {{ .Alert.Username }} disabled at {{ .Alert.ArrivalTime }} from {{ .Alert.UserIP }}\n" +
+				"{{ .Alert.Username }}::Group=Restricted\n",
+			wantEntry: "jdoe::Group=Restricted",
+		},
+		{
+			name: "custom template with multiple directives",
+			template: "# {{ now }}\n" +
+				"{{ .Alert.Username | upper }}::Group=Restricted\n" +
+				"{{ .Alert.Username }}{{ .EntrySuffix }}\n",
+			wantEntry: "JDOE::Group=Restricted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := newProcessTestFiles(t, tt.template)
+
+			first := processActions(t, files, processTestAlert("jdoe", "192.0.2.10"))
+			if !hasAction(first, events.ActionSuccessDisabledUsername) {
+				t.Fatalf("first alert: got actions %q, want %q", first, events.ActionSuccessDisabledUsername)
+			}
+
+			// The same user reported again from another IP Address.
+			second := processActions(t, files, processTestAlert("jdoe", "192.0.2.11"))
+			if !hasAction(second, events.ActionSuccessDuplicatedUsername) {
+				t.Fatalf("second alert: got actions %q, want %q", second, events.ActionSuccessDuplicatedUsername)
+			}
+			if hasAction(second, events.ActionSuccessDisabledUsername) {
+				t.Fatalf("second alert: user disabled again: %q", second)
+			}
+
+			// A different user is not considered disabled.
+			other := processActions(t, files, processTestAlert("jdoe2", "192.0.2.12"))
+			if !hasAction(other, events.ActionSuccessDisabledUsername) {
+				t.Fatalf("other user: got actions %q, want %q", other, events.ActionSuccessDisabledUsername)
+			}
+
+			content, err := os.ReadFile(files.disabledUsers.FilePath)
+			if err != nil {
+				t.Fatalf("failed to read disabled users file: %v", err)
+			}
+			if got := strings.Count(string(content), tt.wantEntry+"\n"); got != 1 {
+				t.Errorf("got %d entries %q, want 1:\n%s", got, tt.wantEntry, content)
+			}
+		})
+	}
+}
+
+func TestNewDisabledUsersTemplateValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{
+			name:     "directive includes username",
+			template: "{{ .Alert.Username }}::Group=Restricted\n",
+		},
+		{
+			name:     "comments only",
+			template: "# {{ .Alert.Username }} disabled\n",
+			wantErr:  true,
+		},
+		{
+			name:     "directive without username",
+			template: "# {{ .Alert.Username }} disabled\nGroup=Restricted\n",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templateFile := filepath.Join(t.TempDir(), "disabled-users.tmpl")
+			if err := os.WriteFile(templateFile, []byte(tt.template), 0o600); err != nil {
+				t.Fatalf("failed to write template file: %v", err)
+			}
+
+			_, err := NewDisabledUsers("users.brick-disabled.txt", "::deny", 0o600, "", "", templateFile)
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatal("expected error, got nil")
+			case !tt.wantErr && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestProcessDisableEventIgnoredUsers(t *testing.T) {
+	normalizer, err := usernames.NewNormalizer(true, true, true, "")
+	if err != nil {
+		t.Fatalf("failed to create username normalizer: %v", err)
+	}
+
+	tests := []struct {
+		entry    string
+		username string
+		ignored  bool
+	}{
+		{entry: "jdoe", username: "jdoe", ignored: true},
+		{entry: "JDoe", username: "jdoe", ignored: true},
+		{entry: "jdoe@example.edu", username: "jdoe", ignored: true},
+		{entry: "EXAMPLE\\JDoe", username: "jdoe", ignored: true},
+		{entry: "  jdoe@example.edu  ", username: "jdoe", ignored: true},
+		{entry: "# jdoe", username: "jdoe", ignored: false},
+		{entry: "jdoe2@example.edu", username: "jdoe", ignored: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			files := newProcessTestFiles(t, "")
+			files.ignoredSources.UsernameNormalizer = normalizer
+
+			if err := os.WriteFile(files.ignoredSources.IgnoredUsersFile, []byte(tt.entry+"\n"), 0o600); err != nil {
+				t.Fatalf("failed to write ignored users file: %v", err)
+			}
+
+			actions := processActions(t, files, processTestAlert(tt.username, "192.0.2.10"))
+			if got := hasAction(actions, events.ActionSuccessIgnoredUsername); got != tt.ignored {
+				t.Errorf("ignored: got %t, want %t (actions %q)", got, tt.ignored, actions)
+			}
+			if got := hasAction(actions, events.ActionSuccessDisabledUsername); got == tt.ignored {
+				t.Errorf("disabled: got %t, want %t (actions %q)", got, !tt.ignored, actions)
+			}
+		})
+	}
+}
+
+func TestFileTemplatesOmitAlertHeaders(t *testing.T) {
+	files := newProcessTestFiles(t, "# {{ .Alert.Headers }}\n{{ .Alert.Username }}::deny\n")
+
+	alert := processTestAlert("jdoe", "192.0.2.10")
+	alert.Headers = http.Header{"Authorization": {"Bearer sample-secret"}}
+
+	actions := processActions(t, files, alert)
+	if !hasAction(actions, events.ActionSuccessDisabledUsername) {
+		t.Fatalf("got actions %q, want %q", actions, events.ActionSuccessDisabledUsername)
+	}
+
+	content, err := os.ReadFile(files.disabledUsers.FilePath)
+	if err != nil {
+		t.Fatalf("failed to read disabled users file: %v", err)
+	}
+
+	if strings.Contains(string(content), "sample-secret") {
+		t.Errorf("alert headers written to disabled users file:\n%s", content)
+	}
+
+	if alert.Headers.Get("Authorization") == "" {
+		t.Error("alert headers removed from the caller's alert")
+	}
+}
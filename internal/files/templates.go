@@ -19,9 +19,17 @@ package files
 // NOTE: time.RFC3339 format should be used for flat-file log messages in
 // order to increase fail2ban parsing reliability
 
+// NOTE: The username is normalized (e.g., case-folded) when the alert is
+// received, so it is written as-is to the disabled users file.
+
+// disabledUsersCommentPrefix marks lines in the disabled users file which are
+// ignored by EZproxy and when determining whether a user account is already
+// disabled.
+const disabledUsersCommentPrefix string = "#"
+
 const disabledUsersFileTemplateText string = `
 # Username "{{ .Alert.Username }}" from source IP "{{ .Alert.UserIP }}" disabled at "{{ .Alert.ArrivalTime }}" per alert "{{ .Alert.AlertName }}" received from "{{ .Alert.PayloadSenderIP }}" (SearchID: "{{ .Alert.SearchID }}")
-{{ ToLower .Alert.Username }}{{ .EntrySuffix }}
+{{ .Alert.Username }}{{ .EntrySuffix }}
 `
 
 // This is a standard message and only indicates that a report was received,
@@ -35,15 +35,30 @@ import (
 // Leading and trailing whitespace per line is ignored.
 func HasLine(searchTerm string, ignorePrefix string, filename string) (bool, error) {
 
+	log.Debugf("%s: Searching for: %q", caller.GetFuncName(), searchTerm)
+
+	return HasLineFunc(ignorePrefix, filename, func(line string) bool {
+		return strings.EqualFold(line, searchTerm)
+	})
+}
+
+// HasLineFunc accepts an optional pattern to ignore, a fully-qualified path
+// to a file containing a list of entries (e.g., commonly usernames or single
+// IP Addresses), one per line, and a function used to determine whether an
+// entry is a match. Lines beginning with the optional ignore pattern (e.g., a
+// `#` character) are ignored. Leading and trailing whitespace per line is
+// removed before the match function is called.
+func HasLineFunc(ignorePrefix string, filename string, match func(line string) bool) (bool, error) {
+
 	myFuncName := caller.GetFuncName()
 
 	log.Debugf("%s: Request to open %q received", myFuncName, filename)
 	log.Debugf("%s: Attempting to open sanitized version of file %q",
 		myFuncName, filepath.Clean(filename))
 
-	// TODO: How do we handle the situation where the file does not exist
-	// ahead of time? Since this application will manage the file, it should
-	// be able to create it with the desired permissions?
+	// NOTE: Files managed by this application are created with the desired
+	// permissions by the startup preflight checks. A missing managed file
+	// here indicates that it was removed after startup.
 	f, err := os.Open(filepath.Clean(filename))
 	if err != nil {
 		return false, fmt.Errorf(
@@ -71,8 +86,6 @@ func HasLine(searchTerm string, ignorePrefix string, filename string) (bool, err
 		}
 	}()
 
-	log.Debugf("%s: Searching for: %q", myFuncName, searchTerm)
-
 	s := bufio.NewScanner(f)
 	var lineno int
 
@@ -112,13 +125,12 @@ func HasLine(searchTerm string, ignorePrefix string, filename string) (bool, err
 		}
 
 		log.Debugf(
-			"%s: Checking whether line %d is a match for %q: %q",
+			"%s: Checking whether line %d is a match: %q",
 			myFuncName,
 			lineno,
-			searchTerm,
 			currentLine,
 		)
-		if strings.EqualFold(currentLine, searchTerm) {
+		if match(currentLine) {
 			log.Debugf(
 				"%s: Match found on line %d, returning true to indicate this",
 				myFuncName,
@@ -146,7 +158,7 @@ func HasLine(searchTerm string, ignorePrefix string, filename string) (bool, err
 		)
 	}
 
-	// otherwise, report that the requested searchTerm was not found
+	// otherwise, report that no match was found
 	return false, nil
 
 }
@@ -0,0 +1,123 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/atc0005/go-ezproxy/activefile"
+)
+
+// activeFileCheckUsername is a placeholder username used to create an active
+// file reader. Only the ability to parse all sessions is checked, so the
+// value is not matched against file contents.
+const activeFileCheckUsername string = "brick-readiness-check"
+
+// FileWritable confirms that the specified file can be appended to. If the
+// file does not yet exist, the parent directory is checked instead by
+// creating and removing a temporary file. The specified file is not created
+// or modified.
+func FileWritable(filename string) error {
+
+	info, err := os.Stat(filename)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return dirWritable(filepath.Dir(filename))
+
+	case err != nil:
+		return err
+
+	case info.IsDir():
+		return fmt.Errorf("%q is a directory", filename)
+	}
+
+	// #nosec G304
+	f, err := os.OpenFile(filepath.Clean(filename), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// dirWritable confirms that files can be created in the specified directory.
+func dirWritable(dir string) error {
+
+	f, err := os.CreateTemp(dir, ".brick-readiness-*")
+	if err != nil {
+		return fmt.Errorf("file does not exist and directory %q is not writable: %w", dir, err)
+	}
+
+	name := f.Name()
+	closeErr := f.Close()
+	removeErr := os.Remove(name)
+
+	return errors.Join(closeErr, removeErr)
+}
+
+// FileReadable confirms that the specified file can be opened for reading.
+func FileReadable(filename string) error {
+
+	// #nosec G304
+	f, err := os.Open(filepath.Clean(filename))
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// Executable confirms that the specified file exists, is a regular file and
+// (on platforms other than Windows) has at least one execute permission bit
+// set.
+func Executable(filename string) error {
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%q is not a regular file", filename)
+	}
+
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("%q is not executable", filename)
+	}
+
+	return nil
+}
+
+// ActiveFile confirms that the specified EZproxy active users and hosts file
+// exists and that all user sessions recorded in it can be parsed.
+func ActiveFile(filename string) error {
+
+	reader, err := activefile.NewReader(activeFileCheckUsername, filename)
+	if err != nil {
+		return err
+	}
+
+	if _, err := reader.AllUserSessions(); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Status is the outcome of a single check or of a full set of checks.
+type Status string
+
+// Valid Status values.
+const (
+	// StatusOK indicates that a check passed.
+	StatusOK Status = "ok"
+
+	// StatusWarn indicates that an optional check failed. Optional check
+	// failures do not affect overall readiness.
+	StatusWarn Status = "warn"
+
+	// StatusFail indicates that a check failed.
+	StatusFail Status = "fail"
+)
+
+// Check is a single named readiness check.
+type Check struct {
+
+	// Name identifies the check in results.
+	Name string
+
+	// Optional indicates whether a failure of this check should be reported
+	// as a warning instead of affecting overall readiness.
+	Optional bool
+
+	// Run performs the check, returning an error if the check fails.
+	Run func() error
+}
+
+// Result is the outcome of a single Check.
+type Result struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the collected outcome of a set of checks.
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// OK indicates whether all required checks in the Report passed.
+func (r Report) OK() bool {
+	return r.Status != StatusFail
+}
+
+// Run performs each of the provided checks in order and returns a Report of
+// the results. The overall status is StatusFail if any required check fails.
+func Run(checks ...Check) Report {
+
+	report := Report{
+		Status: StatusOK,
+		Checks: make([]Result, 0, len(checks)),
+	}
+
+	for _, check := range checks {
+		result := Result{
+			Name:   check.Name,
+			Status: StatusOK,
+		}
+
+		if err := run(check); err != nil {
+			result.Error = err.Error()
+
+			switch {
+			case check.Optional:
+				result.Status = StatusWarn
+			default:
+				result.Status = StatusFail
+				report.Status = StatusFail
+			}
+		}
+
+		report.Checks = append(report.Checks, result)
+	}
+
+	return report
+}
+
+// run performs the check, converting a panic into an error so that a single
+// misbehaving check does not take down the caller.
+func run(check Check) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("check panicked: %v", r)
+		}
+	}()
+
+	return check.Run()
+}
+
+// Tracker records whether long-running components of this application (e.g.,
+// persistent goroutines) are currently running.
+type Tracker struct {
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// NewTracker returns a Tracker with no expected components.
+func NewTracker() *Tracker {
+	return &Tracker{
+		running: make(map[string]bool),
+	}
+}
+
+// Expect registers a component which is expected to be running. The
+// component is reported as not running until Started is called.
+func (t *Tracker) Expect(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.running[name]; !ok {
+		t.running[name] = false
+	}
+}
+
+// Started records that the named component is running.
+func (t *Tracker) Started(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.running[name] = true
+}
+
+// Stopped records that the named component is no longer running.
+func (t *Tracker) Stopped(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.running[name] = false
+}
+
+// Check returns an error listing each expected component which is not
+// currently running.
+func (t *Tracker) Check() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var stopped []string
+	for name, running := range t.running {
+		if !running {
+			stopped = append(stopped, name)
+		}
+	}
+
+	if len(stopped) > 0 {
+		sort.Strings(stopped)
+		return fmt.Errorf("not running: %s", strings.Join(stopped, ", "))
+	}
+
+	return nil
+}
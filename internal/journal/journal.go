@@ -0,0 +1,246 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/atc0005/brick/internal/events"
+	"github.com/atc0005/brick/internal/jsonlines"
+)
+
+// Valid Entry status values.
+const (
+	// StatusAccepted indicates that a disable request was accepted for
+	// processing.
+	StatusAccepted string = "accepted"
+
+	// StatusCompleted indicates that processing of a previously accepted
+	// disable request is complete.
+	StatusCompleted string = "completed"
+)
+
+// Entry is a single line in the journal. Entries with StatusAccepted include
+// the alert; entries with StatusCompleted only reference the ID of the
+// accepted entry.
+type Entry struct {
+	ID     string                   `json:"id"`
+	Status string                   `json:"status"`
+	Time   time.Time                `json:"time"`
+	Alert  *events.SplunkAlertEvent `json:"alert,omitempty"`
+}
+
+// Journal is an append-only file recording accepted disable requests and
+// their completion. Each write is synced to disk before returning. Journal is
+// safe for concurrent use.
+type Journal struct {
+	mu      sync.Mutex
+	file    *jsonlines.File
+	pending map[string]Entry
+}
+
+// Open opens (creating if needed) the journal at the specified path and
+// returns it along with any accepted entries which were not completed, in
+// the order they were accepted. The journal is rewritten to hold only those
+// entries.
+func Open(path string, permissions os.FileMode) (*Journal, []Entry, error) {
+
+	j := Journal{
+		file:    jsonlines.New(path, permissions, "journal"),
+		pending: make(map[string]Entry),
+	}
+
+	unfinished, err := j.read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := j.file.Rewrite(values(unfinished)); err != nil {
+		return nil, nil, err
+	}
+
+	for _, entry := range unfinished {
+		j.pending[entry.ID] = entry
+	}
+
+	return &j, unfinished, nil
+}
+
+// read returns the accepted entries in the journal which were not completed.
+// A missing journal is treated as empty. Lines which cannot be parsed are
+// skipped; a partially written final line is expected if the application
+// stopped while appending, in which case the payload sender was not told
+// that the payload was received.
+func (j *Journal) read() ([]Entry, error) {
+
+	accepted := make(map[string]Entry)
+	var order []string
+
+	err := j.file.Read(func(lineno int, line []byte) error {
+
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return err
+		}
+
+		switch entry.Status {
+		case StatusAccepted:
+			if entry.Alert == nil {
+				return fmt.Errorf("accepted entry %q has no alert", entry.ID)
+			}
+			if _, ok := accepted[entry.ID]; !ok {
+				order = append(order, entry.ID)
+			}
+			accepted[entry.ID] = entry
+
+		case StatusCompleted:
+			delete(accepted, entry.ID)
+
+		default:
+			return fmt.Errorf("unknown status %q", entry.Status)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	unfinished := make([]Entry, 0, len(accepted))
+	for _, id := range order {
+		if entry, ok := accepted[id]; ok {
+			unfinished = append(unfinished, entry)
+		}
+	}
+
+	return unfinished, nil
+}
+
+// Append records the alert as accepted and returns the ID used to mark it
+// complete. The entry is synced to disk before Append returns.
+func (j *Journal) Append(alert events.SplunkAlertEvent) (string, error) {
+
+	id, err := newID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate journal entry ID: %w", err)
+	}
+
+	entry := Entry{
+		ID:     id,
+		Status: StatusAccepted,
+		Time:   time.Now(),
+		Alert:  &alert,
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.file.Append(entry); err != nil {
+		return "", err
+	}
+
+	j.pending[id] = entry
+
+	return id, nil
+}
+
+// Complete records that processing of the accepted entry with the specified
+// ID is complete. The journal is rewritten to hold only the pending entries
+// once it holds many more lines than there are pending entries.
+func (j *Journal) Complete(id string) error {
+
+	entry := Entry{
+		ID:     id,
+		Status: StatusCompleted,
+		Time:   time.Now(),
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.file.Append(entry); err != nil {
+		return err
+	}
+
+	delete(j.pending, id)
+
+	if j.file.NeedsCompaction(len(j.pending)) {
+		j.compact()
+	}
+
+	return nil
+}
+
+// Pending returns the number of accepted entries not yet completed.
+func (j *Journal) Pending() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return len(j.pending)
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.file.Close()
+}
+
+// compact rewrites the journal to hold only the pending entries, in the
+// order they were accepted. The caller is responsible for holding the mutex.
+func (j *Journal) compact() {
+
+	entries := make([]Entry, 0, len(j.pending))
+	for _, entry := range j.pending {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].Time.Before(entries[b].Time)
+	})
+
+	j.file.Compact(values(entries))
+}
+
+// values returns the entries for writing to the journal file.
+func values(entries []Entry) []interface{} {
+
+	values := make([]interface{}, len(entries))
+	for i := range entries {
+		values[i] = entries[i]
+	}
+
+	return values
+}
+
+// newID returns a random identifier for a journal entry.
+func newID() (string, error) {
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
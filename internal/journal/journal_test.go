@@ -0,0 +1,205 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/atc0005/brick/internal/events"
+)
+
+// openJournal opens the journal at the specified path, failing the test on
+// error.
+func openJournal(t *testing.T, path string) (*Journal, []Entry) {
+	t.Helper()
+
+	j, unfinished, err := Open(path, 0o600)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	t.Cleanup(func() { _ = j.Close() })
+
+	return j, unfinished
+}
+
+// countLines returns the number of lines in the specified file.
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %q: %v", path, err)
+	}
+
+	return bytes.Count(b, []byte("\n"))
+}
+
+func TestReplayUnfinished(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "brick.journal")
+
+	j, unfinished := openJournal(t, path)
+	if len(unfinished) != 0 {
+		t.Fatalf("new journal returned %d unfinished entries", len(unfinished))
+	}
+
+	unfinishedID, err := j.Append(events.SplunkAlertEvent{Username: "jdoe", UserIP: "192.0.2.10"})
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	completedID, err := j.Append(events.SplunkAlertEvent{Username: "asmith", UserIP: "192.0.2.11"})
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := j.Complete(completedID); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	// Simulate a crash: the journal is not closed cleanly and the first
+	// entry is never completed.
+	_, unfinished = openJournal(t, path)
+
+	if len(unfinished) != 1 {
+		t.Fatalf("got %d unfinished entries, want 1", len(unfinished))
+	}
+
+	got := unfinished[0]
+	if got.ID != unfinishedID || got.Status != StatusAccepted {
+		t.Errorf("got entry %q with status %q, want %q with status %q",
+			got.ID, got.Status, unfinishedID, StatusAccepted)
+	}
+	if got.Alert == nil || got.Alert.Username != "jdoe" || got.Alert.UserIP != "192.0.2.10" {
+		t.Errorf("got alert %+v, want alert for jdoe from 192.0.2.10", got.Alert)
+	}
+}
+
+func TestReplaySkipsPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "brick.journal")
+
+	j, _ := openJournal(t, path)
+	id, err := j.Append(events.SplunkAlertEvent{Username: "jdoe"})
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Simulate a crash part way through appending a second entry.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	if _, err := f.WriteString(`{"id":"partial","status":"accep`); err != nil {
+		t.Fatalf("failed to write partial line: %v", err)
+	}
+	_ = f.Close()
+
+	_, unfinished := openJournal(t, path)
+	if len(unfinished) != 1 || unfinished[0].ID != id {
+		t.Fatalf("got %+v, want only entry %q", unfinished, id)
+	}
+}
+
+func TestCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "brick.journal")
+
+	j, _ := openJournal(t, path)
+
+	var pendingIDs []string
+	for i := 0; i < 3; i++ {
+		id, err := j.Append(events.SplunkAlertEvent{Username: "pending"})
+		if err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+		pendingIDs = append(pendingIDs, id)
+	}
+
+	// With entries always pending, the journal must still be compacted.
+	for i := 0; i < 500; i++ {
+		id, err := j.Append(events.SplunkAlertEvent{Username: "completed"})
+		if err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+		if err := j.Complete(id); err != nil {
+			t.Fatalf("Complete failed: %v", err)
+		}
+	}
+
+	if lines, limit := countLines(t, path), 2*len(pendingIDs)+100+2; lines > limit {
+		t.Errorf("journal holds %d lines, want at most %d", lines, limit)
+	}
+
+	if got := j.Pending(); got != len(pendingIDs) {
+		t.Errorf("got %d pending entries, want %d", got, len(pendingIDs))
+	}
+
+	_, unfinished := openJournal(t, path)
+	if len(unfinished) != len(pendingIDs) {
+		t.Fatalf("got %d unfinished entries, want %d", len(unfinished), len(pendingIDs))
+	}
+	for i, entry := range unfinished {
+		if entry.ID != pendingIDs[i] {
+			t.Errorf("unfinished entry %d is %q, want %q", i, entry.ID, pendingIDs[i])
+		}
+	}
+}
+
+func TestCompactionFailureReopens(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "brick.journal")
+
+	j, _ := openJournal(t, path)
+
+	pendingID, err := j.Append(events.SplunkAlertEvent{Username: "pending"})
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	// A directory in place of the temporary file makes compaction fail.
+	if err := os.Mkdir(path+".tmp", 0o700); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	for i := 0; i < 150; i++ {
+		id, err := j.Append(events.SplunkAlertEvent{Username: "completed"})
+		if err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+		if err := j.Complete(id); err != nil {
+			t.Fatalf("Complete failed: %v", err)
+		}
+	}
+
+	// Entries written after the failed compaction must still be recorded.
+	laterID, err := j.Append(events.SplunkAlertEvent{Username: "later"})
+	if err != nil {
+		t.Fatalf("Append after failed compaction failed: %v", err)
+	}
+
+	if err := os.Remove(path + ".tmp"); err != nil {
+		t.Fatalf("failed to remove directory: %v", err)
+	}
+
+	_, unfinished := openJournal(t, path)
+	if len(unfinished) != 2 || unfinished[0].ID != pendingID || unfinished[1].ID != laterID {
+		t.Fatalf("got %+v, want entries %q and %q", unfinished, pendingID, laterID)
+	}
+}
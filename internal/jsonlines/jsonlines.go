@@ -0,0 +1,261 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonlines
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/apex/log"
+)
+
+// maxLineSize is the largest line accepted when reading the file. Lines
+// typically hold a single alert along with the payload received with it.
+const maxLineSize int = 1024 * 1024
+
+// File is an append-only file holding one JSON value per line. Each append
+// is synced to disk before returning. File is not safe for concurrent use;
+// callers are responsible for synchronizing access.
+type File struct {
+	path        string
+	permissions os.FileMode
+	description string
+
+	file    *os.File
+	written int
+}
+
+// New returns a File for the specified path. The description (e.g.,
+// "journal") is used to identify the file in error and log messages. The
+// file is not opened for appending until Rewrite (or Append) is called.
+func New(path string, permissions os.FileMode, description string) *File {
+	return &File{
+		path:        path,
+		permissions: permissions,
+		description: description,
+	}
+}
+
+// Read calls fn for each line in the file, in order. A missing file is
+// treated as empty. If fn returns an error the line is logged and skipped; a
+// partially written final line is expected if the application stopped while
+// appending.
+func (f *File) Read(fn func(lineno int, line []byte) error) error {
+
+	// #nosec G304
+	r, err := os.Open(f.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to open %s %q: %w", f.description, f.path, err)
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			log.Errorf("failed to close %s %q: %v", f.description, f.path, err)
+		}
+	}()
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var lineno int
+	for s.Scan() {
+		lineno++
+
+		if err := fn(lineno, s.Bytes()); err != nil {
+			log.Warnf("skipping line %d in %s %q: %v", lineno, f.description, f.path, err)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("failed to read %s %q: %w", f.description, f.path, err)
+	}
+
+	return nil
+}
+
+// Rewrite replaces the file with one holding only the provided values and
+// opens it for appending. The replacement is written to a temporary file
+// which is renamed over the file once synced to disk.
+func (f *File) Rewrite(values []interface{}) error {
+
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			log.Errorf("failed to close %s %q: %v", f.description, f.path, err)
+		}
+		f.file = nil
+	}
+
+	tmpPath := f.path + ".tmp"
+
+	// #nosec G304
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, f.permissions)
+	if err != nil {
+		return fmt.Errorf("failed to create %s %q: %w", f.description, tmpPath, err)
+	}
+
+	w := bufio.NewWriter(tmp)
+	for _, v := range values {
+		if err := writeLine(w, v); err != nil {
+			_ = tmp.Close()
+			return fmt.Errorf("failed to write %s %q: %w", f.description, tmpPath, err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s %q: %w", f.description, tmpPath, err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync %s %q: %w", f.description, tmpPath, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s %q: %w", f.description, tmpPath, err)
+	}
+
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		return fmt.Errorf("failed to replace %s %q: %w", f.description, f.path, err)
+	}
+
+	syncDir(filepath.Dir(f.path))
+
+	// #nosec G304
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_WRONLY, f.permissions)
+	if err != nil {
+		return fmt.Errorf("failed to open %s %q: %w", f.description, f.path, err)
+	}
+
+	f.file = file
+	f.written = len(values)
+
+	return nil
+}
+
+// Append writes the value as a single line at the end of the file and syncs
+// it to disk.
+func (f *File) Append(v interface{}) error {
+
+	// The file is closed if it could not be reopened after a failed
+	// compaction. Try again so that values are not silently held in memory
+	// only.
+	if f.file == nil {
+		if err := f.reopen(); err != nil {
+			return err
+		}
+	}
+
+	if err := writeLine(f.file, v); err != nil {
+		return fmt.Errorf("failed to write %s %q: %w", f.description, f.path, err)
+	}
+	f.written++
+
+	if err := f.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %s %q: %w", f.description, f.path, err)
+	}
+
+	return nil
+}
+
+// NeedsCompaction reports whether the file holds many more lines than the
+// specified number of values still in use.
+func (f *File) NeedsCompaction(live int) bool {
+	return f.written > 2*live+100
+}
+
+// Compact rewrites the file to hold only the provided values. Values already
+// appended are synced to disk; if compaction fails the existing file is
+// reopened and the error is logged so that compaction can be attempted
+// again later.
+func (f *File) Compact(values []interface{}) {
+
+	if err := f.Rewrite(values); err != nil {
+		log.Errorf("failed to compact %s: %v", f.description, err)
+
+		if err := f.reopen(); err != nil {
+			log.Errorf("%s not persisted until it can be reopened: %v", f.description, err)
+		}
+	}
+}
+
+// Close closes the file, if open.
+func (f *File) Close() error {
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
+
+// reopen opens the existing file for appending.
+func (f *File) reopen() error {
+
+	// #nosec G304
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, f.permissions)
+	if err != nil {
+		return fmt.Errorf("failed to open %s %q: %w", f.description, f.path, err)
+	}
+
+	f.file = file
+
+	return nil
+}
+
+// writeLine writes the value as a single line of JSON.
+func writeLine(w io.Writer, v interface{}) error {
+
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(line, '\n'))
+
+	return err
+}
+
+// syncDir syncs the directory so that a rename within it is durable. Errors
+// are logged; not all platforms support syncing a directory.
+func syncDir(dir string) {
+
+	// #nosec G304
+	d, err := os.Open(dir)
+	if err != nil {
+		log.Debugf("failed to open directory %q for sync: %v", dir, err)
+		return
+	}
+
+	if err := d.Sync(); err != nil {
+		log.Debugf("failed to sync directory %q: %v", dir, err)
+	}
+
+	if err := d.Close(); err != nil {
+		log.Debugf("failed to close directory %q: %v", dir, err)
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonlines
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type testValue struct {
+	N int `json:"n"`
+}
+
+// readValues returns the values held in the file, failing the test on any
+// unreadable line.
+func readValues(t *testing.T, f *File) []int {
+	t.Helper()
+
+	var values []int
+	err := f.Read(func(lineno int, line []byte) error {
+		var v testValue
+		if err := json.Unmarshal(line, &v); err != nil {
+			t.Errorf("line %d is unreadable: %v", lineno, err)
+			return err
+		}
+		values = append(values, v.N)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	return values
+}
+
+func TestReadMissingFile(t *testing.T) {
+	f := New(filepath.Join(t.TempDir(), "missing.jsonl"), 0o600, "test file")
+
+	called := false
+	err := f.Read(func(int, []byte) error {
+		called = true
+		return nil
+	})
+	if err != nil || called {
+		t.Errorf("Read of missing file returned %v (called: %t); want nil without lines", err, called)
+	}
+}
+
+func TestReadSkipsRejectedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.jsonl")
+	content := "{\"n\":1}\nnot json\n{\"n\":2}\n{\"n\":3"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	f := New(path, 0o600, "test file")
+
+	var lines []int
+	var values []int
+	err := f.Read(func(lineno int, line []byte) error {
+		lines = append(lines, lineno)
+
+		var v testValue
+		if err := json.Unmarshal(line, &v); err != nil {
+			return err
+		}
+		values = append(values, v.N)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if want := []int{1, 2, 3, 4}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("got lines %v, want %v", lines, want)
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(values, want) {
+		t.Errorf("got values %v, want %v", values, want)
+	}
+}
+
+func TestAppendAndRewrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.jsonl")
+	f := New(path, 0o600, "test file")
+	t.Cleanup(func() { _ = f.Close() })
+
+	if err := f.Rewrite([]interface{}{testValue{N: 1}}); err != nil {
+		t.Fatalf("Rewrite failed: %v", err)
+	}
+
+	for n := 2; n <= 3; n++ {
+		if err := f.Append(testValue{N: n}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	if got, want := readValues(t, f), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if err := f.Rewrite([]interface{}{testValue{N: 3}}); err != nil {
+		t.Fatalf("Rewrite failed: %v", err)
+	}
+	if err := f.Append(testValue{N: 4}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	if got, want := readValues(t, f), []int{3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if info, err := os.Stat(path); err != nil {
+		t.Fatalf("failed to stat file: %v", err)
+	} else if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("got permissions %o, want %o", perm, 0o600)
+	}
+}
+
+func TestCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.jsonl")
+	f := New(path, 0o600, "test file")
+	t.Cleanup(func() { _ = f.Close() })
+
+	if err := f.Rewrite(nil); err != nil {
+		t.Fatalf("Rewrite failed: %v", err)
+	}
+
+	const live = 5
+	for n := 0; n < 2*live+100; n++ {
+		if err := f.Append(testValue{N: n}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	if f.NeedsCompaction(live) {
+		t.Fatalf("compaction needed after %d lines with %d live values", 2*live+100, live)
+	}
+
+	if err := f.Append(testValue{N: -1}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	if !f.NeedsCompaction(live) {
+		t.Fatalf("compaction not needed after %d lines with %d live values", 2*live+101, live)
+	}
+
+	values := make([]interface{}, live)
+	want := make([]int, live)
+	for i := range values {
+		values[i] = testValue{N: i}
+		want[i] = i
+	}
+
+	f.Compact(values)
+
+	if f.NeedsCompaction(live) {
+		t.Errorf("compaction still needed after compacting")
+	}
+
+	if err := f.Append(testValue{N: live}); err != nil {
+		t.Fatalf("Append after compaction failed: %v", err)
+	}
+	want = append(want, live)
+
+	if got := readValues(t, f); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+}
+
+func TestCompactionFailureReopens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.jsonl")
+	f := New(path, 0o600, "test file")
+	t.Cleanup(func() { _ = f.Close() })
+
+	if err := f.Rewrite([]interface{}{testValue{N: 1}}); err != nil {
+		t.Fatalf("Rewrite failed: %v", err)
+	}
+
+	// A directory in place of the temporary file makes compaction fail.
+	if err := os.Mkdir(path+".tmp", 0o700); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	f.Compact([]interface{}{testValue{N: 99}})
+
+	// The existing file is kept and reopened so that later values are
+	// still recorded.
+	if err := f.Append(testValue{N: 2}); err != nil {
+		t.Fatalf("Append after failed compaction failed: %v", err)
+	}
+
+	if got, want := readValues(t, f), []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if err := os.Remove(path + ".tmp"); err != nil {
+		t.Fatalf("failed to remove directory: %v", err)
+	}
+
+	f.Compact([]interface{}{testValue{N: 2}})
+
+	if got, want := readValues(t, f), []int{2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after retrying compaction got %v, want %v", got, want)
+	}
+}
+
+func TestAppendReopensClosedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.jsonl")
+	f := New(path, 0o600, "test file")
+	t.Cleanup(func() { _ = f.Close() })
+
+	// Append opens (creating if needed) the file if it is not open.
+	if err := f.Append(testValue{N: 1}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if err := f.Append(testValue{N: 2}); err != nil {
+		t.Fatalf("Append after Close failed: %v", err)
+	}
+
+	if got, want := readValues(t, f), []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
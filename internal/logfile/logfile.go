@@ -0,0 +1,364 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logfile
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is used to generate the timestamp inserted into the name
+// of rotated log files. Colons are avoided for portability.
+const backupTimeFormat string = "2006-01-02T15-04-05.000"
+
+// compressSuffix is appended to the name of compressed backups.
+const compressSuffix string = ".gz"
+
+// Options controls when a log file is rotated and how many rotated backups
+// are retained. A zero value for any setting disables the associated
+// behavior.
+type Options struct {
+
+	// MaxSize is the size in bytes a log file may reach before it is
+	// rotated.
+	MaxSize int64
+
+	// RotateInterval is how long a log file is written to before it is
+	// rotated. The interval begins when the log file is opened.
+	RotateInterval time.Duration
+
+	// MaxAge is how long rotated backups are retained.
+	MaxAge time.Duration
+
+	// MaxBackups is the number of rotated backups retained.
+	MaxBackups int
+
+	// Compress controls whether rotated backups are compressed using gzip.
+	Compress bool
+}
+
+// File is a log file which is rotated based on the provided Options. File
+// implements io.WriteCloser and is safe for concurrent use.
+type File struct {
+	path        string
+	permissions os.FileMode
+	opts        Options
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// millMu serializes compression and removal of rotated backups, which
+	// is performed in the background after each rotation.
+	millMu sync.Mutex
+}
+
+// Open opens (creating if needed) the log file at the specified path for
+// appending and returns a File which rotates it based on the provided
+// Options.
+func Open(path string, permissions os.FileMode, opts Options) (*File, error) {
+
+	f := File{
+		path:        path,
+		permissions: permissions,
+		opts:        opts,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// open opens the log file for appending. The caller is responsible for
+// holding the mutex.
+func (f *File) open() error {
+
+	// #nosec G304
+	file, err := os.OpenFile(filepath.Clean(f.path), os.O_APPEND|os.O_CREATE|os.O_WRONLY, f.permissions)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %q: %w", f.path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat log file %q: %w", f.path, err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+
+	return nil
+}
+
+// closeFile closes the log file if open. The caller is responsible for
+// holding the mutex.
+func (f *File) closeFile() error {
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
+
+// Write implements the io.Writer interface. The log file is rotated before
+// writing if the write would exceed the maximum size or if the rotation
+// interval has elapsed.
+func (f *File) Write(p []byte) (int, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	switch {
+	case f.opts.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.opts.MaxSize:
+		fallthrough
+	case f.opts.RotateInterval > 0 && time.Since(f.openedAt) >= f.opts.RotateInterval:
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+// Reopen closes and reopens the log file. This is intended to be used after
+// the log file has been renamed by an external tool such as logrotate.
+func (f *File) Reopen() error {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.closeFile(); err != nil && !errors.Is(err, os.ErrClosed) {
+		return fmt.Errorf("failed to close log file %q: %w", f.path, err)
+	}
+
+	return f.open()
+}
+
+// Rotate forces rotation of the log file.
+func (f *File) Rotate() error {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.rotate()
+}
+
+// Close implements the io.Closer interface.
+func (f *File) Close() error {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.closeFile()
+}
+
+// rotate renames the current log file using a timestamp and opens a new log
+// file in its place. Removal and compression of rotated backups is performed
+// in the background. The caller is responsible for holding the mutex.
+func (f *File) rotate() error {
+
+	if err := f.closeFile(); err != nil && !errors.Is(err, os.ErrClosed) {
+		return fmt.Errorf("failed to close log file %q: %w", f.path, err)
+	}
+
+	backupPath := f.backupName(time.Now())
+	if err := os.Rename(f.path, backupPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf(
+			"failed to rename log file %q to %q: %w",
+			f.path,
+			backupPath,
+			err,
+		)
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	go f.mill()
+
+	return nil
+}
+
+// nameParts returns the log file name without its extension and the
+// extension.
+func (f *File) nameParts() (string, string) {
+	name := filepath.Base(f.path)
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext), ext
+}
+
+// backupName returns the path used for a backup rotated at the specified
+// time.
+func (f *File) backupName(t time.Time) string {
+	prefix, ext := f.nameParts()
+	return filepath.Join(
+		filepath.Dir(f.path),
+		prefix+"-"+t.Format(backupTimeFormat)+ext,
+	)
+}
+
+// backup is a rotated log file.
+type backup struct {
+	path      string
+	rotatedAt time.Time
+}
+
+// backups returns the rotated log files, newest first.
+func (f *File) backups() ([]backup, error) {
+
+	dir := filepath.Dir(f.path)
+	prefix, ext := f.nameParts()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []backup
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := strings.TrimSuffix(entry.Name(), compressSuffix)
+		if !strings.HasPrefix(name, prefix+"-") || !strings.HasSuffix(name, ext) {
+			continue
+		}
+
+		ts := strings.TrimSuffix(strings.TrimPrefix(name, prefix+"-"), ext)
+		rotatedAt, err := time.ParseInLocation(backupTimeFormat, ts, time.Local)
+		if err != nil {
+			continue
+		}
+
+		found = append(found, backup{
+			path:      filepath.Join(dir, entry.Name()),
+			rotatedAt: rotatedAt,
+		})
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].rotatedAt.After(found[j].rotatedAt)
+	})
+
+	return found, nil
+}
+
+// mill removes rotated backups which exceed the retention settings and
+// compresses the remaining backups if enabled. Errors are reported to
+// stderr as the log file itself may be the only configured log output.
+func (f *File) mill() {
+
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	backups, err := f.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to list rotated log files for %q: %s\n", f.path, err)
+		return
+	}
+
+	for i, b := range backups {
+		expired := f.opts.MaxAge > 0 && time.Since(b.rotatedAt) > f.opts.MaxAge
+		excess := f.opts.MaxBackups > 0 && i >= f.opts.MaxBackups
+
+		switch {
+		case expired || excess:
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "failed to remove rotated log file %q: %s\n", b.path, err)
+			}
+
+		case f.opts.Compress && !strings.HasSuffix(b.path, compressSuffix):
+			if err := compressFile(b.path, f.permissions); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to compress rotated log file %q: %s\n", b.path, err)
+			}
+		}
+	}
+}
+
+// compressFile compresses the specified file using gzip and removes the
+// original once complete.
+func compressFile(path string, permissions os.FileMode) error {
+
+	// #nosec G304
+	src, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	dstPath := path + compressSuffix
+
+	// #nosec G304
+	dst, err := os.OpenFile(filepath.Clean(dstPath), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, permissions)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		_ = gz.Close()
+		_ = dst.Close()
+		_ = os.Remove(dstPath)
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(dstPath)
+		return err
+	}
+
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(dstPath)
+		return err
+	}
+
+	// explicitly close source before removal; required on Windows
+	_ = src.Close()
+
+	return os.Remove(path)
+}
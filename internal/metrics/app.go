@@ -0,0 +1,221 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/atc0005/brick/internal/events"
+)
+
+// Label values used to identify notification services.
+const (
+	ServiceTeams string = "teams"
+	ServiceEmail string = "email"
+	ServiceSlack string = "slack"
+)
+
+// Label values used to identify the reason a payload was rejected.
+const (
+	RejectReasonInvalidRemoteAddress string = "invalid_remote_address"
+	RejectReasonUntrustedSender      string = "untrusted_sender"
+	RejectReasonMethodNotAllowed     string = "method_not_allowed"
+	RejectReasonReadError            string = "read_error"
+	RejectReasonDecodeError          string = "decode_error"
+	RejectReasonValidationFailed     string = "validation_failed"
+	RejectReasonEmptyUsername        string = "empty_username"
+	RejectReasonQueueFull            string = "queue_full"
+	RejectReasonJournalError         string = "journal_error"
+	RejectReasonStaleAlert           string = "stale_alert"
+	RejectReasonFutureAlert          string = "future_alert"
+)
+
+// Label values used to identify the outcome of disable, ignore and session
+// termination actions.
+const (
+	OutcomeSuccess         string = "success"
+	OutcomeFailure         string = "failure"
+	OutcomeAlreadyDisabled string = "already_disabled"
+	OutcomeIgnoredUsername string = "ignored_username"
+	OutcomeIgnoredIP       string = "ignored_ip_address"
+	OutcomeSkipped         string = "skipped"
+	OutcomeLookupFailure   string = "lookup_failure"
+)
+
+// Default is the Registry holding all metrics collected by this application.
+var Default = NewRegistry()
+
+// Metrics collected by this application.
+var (
+	PayloadsReceived = Default.NewCounterVec(
+		"brick_payloads_received_total",
+		"Number of payloads received on the disable user endpoint.",
+	)
+
+	PayloadsRejected = Default.NewCounterVec(
+		"brick_payloads_rejected_total",
+		"Number of payloads rejected, by reason.",
+		"reason",
+	)
+
+	PayloadsDuplicate = Default.NewCounterVec(
+		"brick_payloads_duplicate_total",
+		"Number of payloads ignored as repeated deliveries of an alert already received.",
+	)
+
+	Events = Default.NewCounterVec(
+		"brick_events_total",
+		"Number of event records generated, by action.",
+		"action",
+	)
+
+	UserDisables = Default.NewCounterVec(
+		"brick_user_disables_total",
+		"Number of attempts to disable a user account, by outcome.",
+		"outcome",
+	)
+
+	UserIgnores = Default.NewCounterVec(
+		"brick_user_ignores_total",
+		"Number of reported user accounts checked against the ignore lists and ignored, by outcome.",
+		"outcome",
+	)
+
+	SessionTerminations = Default.NewCounterVec(
+		"brick_session_terminations_total",
+		"Number of attempts to terminate the sessions for a user account, by outcome.",
+		"outcome",
+	)
+
+	NotificationAttempts = Default.NewCounterVec(
+		"brick_notification_attempts_total",
+		"Number of notification delivery attempts, including retries, by service.",
+		"service",
+	)
+
+	NotificationSuccesses = Default.NewCounterVec(
+		"brick_notification_successes_total",
+		"Number of notifications successfully delivered, by service.",
+		"service",
+	)
+
+	NotificationFailures = Default.NewCounterVec(
+		"brick_notification_failures_total",
+		"Number of notifications which could not be delivered, by service.",
+		"service",
+	)
+
+	NotificationDeadLetters = Default.NewCounterVec(
+		"brick_notification_dead_letters_total",
+		"Number of notifications moved to the dead-letter list after the final delivery attempt failed, by service.",
+		"service",
+	)
+
+	NotifyOutboxItems = Default.NewGaugeVec(
+		"brick_notify_outbox_items",
+		"Number of notifications held in the outbox, by status.",
+		"status",
+	)
+
+	NotifyQueueDepth = Default.NewGaugeVec(
+		"brick_notify_queue_depth",
+		"Number of items currently waiting in each notification queue.",
+		"queue",
+	)
+
+	DisableQueueDepth = Default.NewGaugeVec(
+		"brick_disable_queue_depth",
+		"Number of accepted disable requests waiting for an available worker.",
+		"queue",
+	)
+
+	PayloadProcessingDuration = Default.NewHistogram(
+		"brick_payload_processing_duration_seconds",
+		"Time taken to fully process a received payload, including session termination.",
+		DefaultBuckets,
+	)
+
+	AlertLatency = Default.NewHistogram(
+		"brick_alert_latency_seconds",
+		"Time between the user activity reported by an alert and the arrival of the alert.",
+		LatencyBuckets,
+	)
+
+	EZproxyKillDuration = Default.NewHistogram(
+		"brick_ezproxy_kill_duration_seconds",
+		"Time taken by each call to the EZproxy binary to terminate a user session.",
+		DefaultBuckets,
+	)
+)
+
+// actionOutcomes maps each event Record action to the counter and outcome
+// label value which should be incremented alongside the per-action counter.
+var actionOutcomes = map[string]struct {
+	counter *CounterVec
+	outcome string
+}{
+	events.ActionSuccessDisabledUsername:         {UserDisables, OutcomeSuccess},
+	events.ActionSuccessDuplicatedUsername:       {UserDisables, OutcomeAlreadyDisabled},
+	events.ActionFailureDisabledUsername:         {UserDisables, OutcomeFailure},
+	events.ActionFailureDuplicatedUsername:       {UserDisables, OutcomeFailure},
+	events.ActionSuccessIgnoredUsername:          {UserIgnores, OutcomeIgnoredUsername},
+	events.ActionSuccessIgnoredIPAddress:         {UserIgnores, OutcomeIgnoredIP},
+	events.ActionFailureIgnoredUsername:          {UserIgnores, OutcomeFailure},
+	events.ActionFailureIgnoredIPAddress:         {UserIgnores, OutcomeFailure},
+	events.ActionSuccessTerminatedUserSession:    {SessionTerminations, OutcomeSuccess},
+	events.ActionFailureTerminatedUserSession:    {SessionTerminations, OutcomeFailure},
+	events.ActionFailureUserSessionLookupFailure: {SessionTerminations, OutcomeLookupFailure},
+	events.ActionSkippedTerminateUserSessions:    {SessionTerminations, OutcomeSkipped},
+	events.ActionSkippedStaleAlert:               {UserDisables, OutcomeSkipped},
+}
+
+// ObserveAction increments the per-action event counter and, if applicable,
+// the disable, ignore or session termination counter for the given event
+// Record action.
+func ObserveAction(action string) {
+	Events.Inc(action)
+
+	if ao, ok := actionOutcomes[action]; ok {
+		ao.counter.Inc(ao.outcome)
+	}
+}
+
+func init() {
+	// Report known series with a zero value until first incremented so
+	// that they are visible to queries and alerting rules from startup.
+	PayloadsReceived.Add(0)
+	PayloadsDuplicate.Add(0)
+
+	for action, ao := range actionOutcomes {
+		Events.Add(0, action)
+		ao.counter.Add(0, ao.outcome)
+	}
+	Events.Add(0, events.ActionSuccessDisableRequestReceived)
+	Events.Add(0, events.ActionFailureDisableRequestReceived)
+
+	for _, service := range []string{ServiceTeams, ServiceEmail, ServiceSlack} {
+		InitService(service)
+	}
+}
+
+// InitService reports the notification series for the specified service
+// with a zero value until first incremented. This is used for services whose
+// names are not known until startup (e.g., webhook targets).
+func InitService(service string) {
+	NotificationAttempts.Add(0, service)
+	NotificationSuccesses.Add(0, service)
+	NotificationFailures.Add(0, service)
+	NotificationDeadLetters.Add(0, service)
+}
@@ -0,0 +1,385 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the HTTP Content-Type value for the Prometheus text
+// exposition format written by Registry.WriteTo.
+const ContentType string = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are histogram bucket upper bounds (in seconds) suitable for
+// timing the short-lived operations performed by this application.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// LatencyBuckets are histogram bucket upper bounds (in seconds) suitable for
+// measuring the delay between user activity and the arrival of the alert
+// reporting it; from one minute up to one day.
+var LatencyBuckets = []float64{60, 120, 300, 600, 900, 1800, 3600, 7200, 14400, 43200, 86400}
+
+// labelSeparator joins label values into a single key. This value is not
+// expected to appear in label values.
+const labelSeparator string = "\xff"
+
+// collector is implemented by each metric type held by a Registry.
+type collector interface {
+	write(w io.Writer) error
+}
+
+// Registry is a collection of metrics which are written together in the
+// Prometheus text exposition format.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteTo writes all metrics held by the Registry to w in the Prometheus
+// text exposition format. Metrics are written in the order that they were
+// registered.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+
+	r.mu.Lock()
+	collectors := make([]collector, len(r.collectors))
+	copy(collectors, r.collectors)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+	for _, c := range collectors {
+		if err := c.write(cw); err != nil {
+			return cw.n, err
+		}
+	}
+
+	return cw.n, bw.Flush()
+}
+
+// CounterVec is a collection of counters sharing a name and set of label
+// names; one counter is kept for each unique set of label values. A
+// CounterVec without label names holds a single counter.
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewCounterVec creates and registers a CounterVec with the given name, help
+// text and label names.
+func (r *Registry) NewCounterVec(name string, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]float64),
+	}
+	r.register(c)
+
+	return c
+}
+
+// Inc increments the counter for the given label values by 1.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter for the given label values by v. Negative
+// values are ignored as counters may only increase. Adding 0 ensures that
+// the counter is reported even if it has not yet been incremented.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+
+	key := labelKey(c.labelNames, labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] += v
+}
+
+func (c *CounterVec) write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := writeHeader(w, c.name, c.help, "counter"); err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(c.values) {
+		if err := writeSample(w, c.name, c.labelNames, splitKey(key), c.values[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GaugeVec is a collection of gauges sharing a name and a single label name.
+// The value of each gauge is retrieved from the provided function when the
+// metrics are written.
+type GaugeVec struct {
+	name      string
+	help      string
+	labelName string
+
+	mu    sync.Mutex
+	funcs map[string]func() float64
+}
+
+// NewGaugeVec creates and registers a GaugeVec with the given name, help
+// text and label name.
+func (r *Registry) NewGaugeVec(name string, help string, labelName string) *GaugeVec {
+	g := &GaugeVec{
+		name:      name,
+		help:      help,
+		labelName: labelName,
+		funcs:     make(map[string]func() float64),
+	}
+	r.register(g)
+
+	return g
+}
+
+// SetFunc sets the function used to retrieve the current value of the gauge
+// for the given label value, replacing any function previously set.
+func (g *GaugeVec) SetFunc(labelValue string, fn func() float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.funcs[labelValue] = fn
+}
+
+func (g *GaugeVec) write(w io.Writer) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := writeHeader(w, g.name, g.help, "gauge"); err != nil {
+		return err
+	}
+
+	for _, labelValue := range sortedKeys(g.funcs) {
+		err := writeSample(
+			w,
+			g.name,
+			[]string{g.labelName},
+			[]string{labelValue},
+			g.funcs[labelValue](),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Histogram counts observed values in cumulative buckets and tracks the sum
+// and count of all observed values.
+type Histogram struct {
+	name    string
+	help    string
+	buckets []float64
+
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram creates and registers a Histogram with the given name, help
+// text and bucket upper bounds. The buckets are sorted if needed; the
+// implicit +Inf bucket is always included.
+func (r *Registry) NewHistogram(name string, help string, buckets []float64) *Histogram {
+	b := make([]float64, len(buckets))
+	copy(b, buckets)
+	sort.Float64s(b)
+
+	h := &Histogram{
+		name:    name,
+		help:    help,
+		buckets: b,
+		counts:  make([]uint64, len(b)),
+	}
+	r.register(h)
+
+	return h
+}
+
+// Observe adds a single observed value to the histogram.
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, upperBound := range h.buckets {
+		if v <= upperBound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := writeHeader(w, h.name, h.help, "histogram"); err != nil {
+		return err
+	}
+
+	bucketName := h.name + "_bucket"
+	for i, upperBound := range h.buckets {
+		err := writeSample(
+			w,
+			bucketName,
+			[]string{"le"},
+			[]string{formatFloat(upperBound)},
+			float64(h.counts[i]),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := writeSample(w, bucketName, []string{"le"}, []string{"+Inf"}, float64(h.count)); err != nil {
+		return err
+	}
+
+	if err := writeSample(w, h.name+"_sum", nil, nil, h.sum); err != nil {
+		return err
+	}
+
+	return writeSample(w, h.name+"_count", nil, nil, float64(h.count))
+}
+
+// labelKey joins the given label values into a map key, padding or
+// truncating to match the number of label names.
+func labelKey(labelNames []string, labelValues []string) string {
+	values := make([]string, len(labelNames))
+	copy(values, labelValues)
+
+	return strings.Join(values, labelSeparator)
+}
+
+func splitKey(key string) []string {
+	return strings.Split(key, labelSeparator)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func writeHeader(w io.Writer, name string, help string, metricType string) error {
+	_, err := fmt.Fprintf(
+		w,
+		"# HELP %s %s\n# TYPE %s %s\n",
+		name,
+		escapeHelp(help),
+		name,
+		metricType,
+	)
+
+	return err
+}
+
+func writeSample(w io.Writer, name string, labelNames []string, labelValues []string, value float64) error {
+	var labels string
+	if len(labelNames) > 0 {
+		pairs := make([]string, len(labelNames))
+		for i, labelName := range labelNames {
+			var labelValue string
+			if i < len(labelValues) {
+				labelValue = labelValues[i]
+			}
+			pairs[i] = fmt.Sprintf(`%s="%s"`, labelName, escapeLabelValue(labelValue))
+		}
+		labels = "{" + strings.Join(pairs, ",") + "}"
+	}
+
+	_, err := fmt.Fprintf(w, "%s%s %s\n", name, labels, formatFloat(value))
+
+	return err
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// countingWriter tracks the number of bytes written to the wrapped writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+
+	return n, err
+}
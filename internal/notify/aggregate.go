@@ -0,0 +1,179 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/atc0005/brick/internal/events"
+)
+
+// incident is the collection of event Records recorded so far while
+// processing a single alert.
+type incident struct {
+	seq      int
+	deadline time.Time
+	timer    *time.Timer
+	records  []events.Record
+}
+
+// Aggregator collects the event Records recorded while processing each alert
+// so that they may be sent as one consolidated event Record. Event Records
+// for an alert are collected until processing of the alert finishes or the
+// aggregation window expires, whichever occurs first. Aggregator is safe for
+// concurrent use.
+type Aggregator struct {
+	window time.Duration
+
+	mu        sync.Mutex
+	seq       int
+	incidents map[string]*incident
+
+	expired chan string
+	done    chan struct{}
+}
+
+// NewAggregator creates an Aggregator which collects event Records for an
+// alert for at most the provided window.
+func NewAggregator(window time.Duration) *Aggregator {
+	return &Aggregator{
+		window:    window,
+		incidents: make(map[string]*incident),
+		expired:   make(chan string),
+		done:      make(chan struct{}),
+	}
+}
+
+// aggregationKey returns the key used to collect event Records associated
+// with the provided alert. Alerts do not carry a unique ID, so the details
+// which are the same for each event Record recorded for an alert are used.
+func aggregationKey(alert events.SplunkAlertEvent) string {
+	return strings.Join([]string{
+		alert.SearchID,
+		alert.Username,
+		alert.UserIP,
+		alert.PayloadSenderIP,
+		alert.ArrivalTime,
+	}, "\x00")
+}
+
+// Add collects the provided event Record. If the event Record is the last
+// recorded while processing the associated alert, the consolidated event
+// Record for the alert is returned along with true. Otherwise, an empty event
+// Record is returned along with false.
+func (a *Aggregator) Add(record events.Record) (events.Record, bool) {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := aggregationKey(record.Alert)
+
+	inc, ok := a.incidents[key]
+	if !ok {
+		a.seq++
+		inc = &incident{
+			seq:      a.seq,
+			deadline: time.Now().Add(a.window),
+		}
+		inc.timer = time.AfterFunc(a.window, func() {
+			select {
+			case a.expired <- key:
+			case <-a.done:
+			}
+		})
+		a.incidents[key] = inc
+
+		log.Debugf(
+			"Aggregator: collecting event records for alert %q (username %q) for up to %v",
+			record.Alert.SearchID,
+			record.Alert.Username,
+			a.window,
+		)
+	}
+
+	inc.records = append(inc.records, record)
+
+	if !record.Final() {
+		return events.Record{}, false
+	}
+
+	inc.timer.Stop()
+	delete(a.incidents, key)
+
+	return events.Consolidate(inc.records), true
+}
+
+// Expired returns the channel used to provide the key for each alert whose
+// aggregation window has expired. Keys received from this channel should be
+// provided to Expire.
+func (a *Aggregator) Expired() <-chan string {
+	return a.expired
+}
+
+// Expire returns the consolidated event Record for the alert associated with
+// the provided key along with true if the aggregation window for the alert
+// has expired. Otherwise, an empty event Record is returned along with false.
+// This occurs if processing of the alert finished just before the aggregation
+// window expired.
+func (a *Aggregator) Expire(key string) (events.Record, bool) {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	inc, ok := a.incidents[key]
+	if !ok || time.Now().Before(inc.deadline) {
+		return events.Record{}, false
+	}
+
+	delete(a.incidents, key)
+
+	return events.Consolidate(inc.records), true
+}
+
+// Flush stops collection of event Records and returns the consolidated event
+// Record for each alert whose processing has not finished, in the order the
+// alerts were first seen. This is intended to be called once when shutting
+// down the application.
+func (a *Aggregator) Flush() []events.Record {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	close(a.done)
+
+	pending := make([]*incident, 0, len(a.incidents))
+	for key, inc := range a.incidents {
+		inc.timer.Stop()
+		pending = append(pending, inc)
+		delete(a.incidents, key)
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].seq < pending[j].seq
+	})
+
+	records := make([]events.Record, 0, len(pending))
+	for _, inc := range pending {
+		records = append(records, events.Consolidate(inc.records))
+	}
+
+	return records
+}
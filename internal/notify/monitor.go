@@ -0,0 +1,222 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// Stats is an update to the notification stats collected by StatsMonitor.
+type Stats struct {
+
+	// Received is the number of incoming notification requests.
+	Received int
+
+	// Service is the name of the notification service that the Sent,
+	// Success and Failure fields apply to.
+	Service string
+
+	// Sent is the number of notifications handed off for delivery.
+	Sent int
+
+	// Success is the number of notifications successfully delivered.
+	Success int
+
+	// Failure is the number of failed notification attempts.
+	Failure int
+}
+
+// serviceStats is the running total of stats for a notification service.
+type serviceStats struct {
+	sent    int
+	success int
+	failure int
+}
+
+// pending is the number of notifications handed off for delivery which have
+// not yet succeeded or failed.
+func (ss serviceStats) pending() int {
+	return ss.sent - (ss.success + ss.failure)
+}
+
+// StatsMonitor accepts a context, a delay, the names of the notification
+// services to report and a channel for Stats values in order to collect and
+// emit summary information for notifications. This function is intended to
+// be run as a goroutine.
+func StatsMonitor(ctx context.Context, delay time.Duration, services []string, statsQueue <-chan Stats) {
+
+	log.Debug("StatsMonitor: Running")
+
+	// these will be populated using values received on statsQueue
+	var received int
+	stats := make(map[string]serviceStats, len(services))
+
+	for {
+		t := time.NewTimer(delay)
+
+		// block until:
+		//	- context cancellation
+		//	- timer fires
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			log.Debugf(
+				"StatsMonitor: Received Done signal: %v, shutting down ...",
+				ctx.Err().Error(),
+			)
+
+			return
+
+		// emit stats summary here
+		case <-t.C:
+
+			ctxLog := log.WithFields(log.Fields{
+				"timestamp":  time.Now().Format("15:04:05"),
+				"emit_stats": delay,
+			})
+
+			var total serviceStats
+			for _, ss := range stats {
+				total.sent += ss.sent
+				total.success += ss.success
+				total.failure += ss.failure
+			}
+
+			ctxLog.Infof(
+				"StatsMonitor: Total: "+
+					"[%d received, %d pending, %d success, %d failure]",
+				received,
+				total.pending(),
+				total.success,
+				total.failure,
+			)
+
+			for _, service := range services {
+				ss := stats[service]
+				ctxLog.Infof(
+					"StatsMonitor: %s: "+
+						"[%d total, %d pending, %d success, %d failure]",
+					service,
+					ss.sent,
+					ss.pending(),
+					ss.success,
+					ss.failure,
+				)
+			}
+
+		// received stats update; update our totals
+		case statsUpdate := <-statsQueue:
+
+			t.Stop()
+
+			received += statsUpdate.Received
+
+			if statsUpdate.Service != "" {
+				ss := stats[statsUpdate.Service]
+				ss.sent += statsUpdate.Sent
+				ss.success += statsUpdate.Success
+				ss.failure += statsUpdate.Failure
+				stats[statsUpdate.Service] = ss
+			}
+
+		}
+	}
+}
+
+// Queue represents a channel used to queue input data and responses between
+// the main application, the notifications manager and notification services.
+type Queue struct {
+
+	// The name of a queue. This is intended for display in log messages or
+	// other output to identify queues with pending items.
+	Name string
+
+	// Channel is a channel used to transport input data and responses. Any
+	// channel type may be used.
+	Channel interface{}
+}
+
+// Len returns the number of items currently in the queue.
+func (q Queue) Len() int {
+	return reflect.ValueOf(q.Channel).Len()
+}
+
+// Cap returns the maximum number of items allowed in the queue.
+func (q Queue) Cap() int {
+	return reflect.ValueOf(q.Channel).Cap()
+}
+
+// QueueMonitor accepts a context, a delay and one or many Queue values to
+// monitor for items yet to be processed. This function is intended to be run
+// as a goroutine.
+func QueueMonitor(ctx context.Context, delay time.Duration, queues ...Queue) {
+
+	if len(queues) == 0 {
+		log.Error("received empty list of queues to monitor, exiting")
+		return
+	}
+
+	log.Debug("QueueMonitor: Running")
+
+	for {
+
+		t := time.NewTimer(delay)
+
+		// block until:
+		//	- context cancellation
+		//	- timer fires
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			log.Debugf(
+				"QueueMonitor: Received Done signal: %v, shutting down ...",
+				ctx.Err().Error(),
+			)
+			return
+
+		case <-t.C:
+
+			var itemsFound bool
+			for _, queue := range queues {
+
+				// Show stats only for queues with content
+				if count := queue.Len(); count > 0 {
+					itemsFound = true
+
+					log.WithField("timestamp", time.Now().Format("15:04:05")).Debugf(
+						"QueueMonitor: %d/%d items in %s, %d goroutines running",
+						count,
+						queue.Cap(),
+						queue.Name,
+						runtime.NumGoroutine(),
+					)
+				}
+			}
+
+			if !itemsFound {
+				log.WithField("timestamp", time.Now().Format("15:04:05")).Debugf(
+					"QueueMonitor: 0 items queued, %d goroutines running",
+					runtime.NumGoroutine())
+			}
+		}
+	}
+}
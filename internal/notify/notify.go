@@ -0,0 +1,348 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/atc0005/brick/internal/events"
+	"github.com/atc0005/brick/internal/metrics"
+	"github.com/atc0005/brick/internal/outbox"
+)
+
+// Notifier is implemented by each notification service (e.g., Microsoft
+// Teams, email). A Notifier is only responsible for creating and submitting a
+// notification; scheduling, retries and shutdown are handled by the Service
+// used to run it.
+type Notifier interface {
+
+	// Name returns the name of the notification service. This name is used
+	// to identify the service in log messages, metrics and the outbox.
+	Name() string
+
+	// Send makes a single attempt to create and submit a notification for
+	// the provided event Record.
+	Send(ctx context.Context, record events.Record) error
+}
+
+// Filter is optionally implemented by a Notifier which only submits
+// notifications for some event Records (e.g., a webhook target configured
+// for specific event types).
+type Filter interface {
+
+	// Accepts indicates whether a notification should be submitted for the
+	// provided event Record.
+	Accepts(record events.Record) bool
+}
+
+// RecipientNotifier is optionally implemented by a Notifier which supports
+// overriding its default recipients (e.g., email addresses) for a single
+// notification.
+type RecipientNotifier interface {
+
+	// SendTo makes a single attempt to create and submit a notification for
+	// the provided event Record to the specified recipients.
+	SendTo(ctx context.Context, record events.Record, recipients []string) error
+}
+
+// Settings controls how a Service schedules and retries notification
+// attempts.
+type Settings struct {
+
+	// Timeout is the time allowed for each notification attempt.
+	Timeout time.Duration
+
+	// RateLimit is the minimum delay between notifications. This is
+	// intended to help prevent unintentional abuse of remote services.
+	RateLimit time.Duration
+
+	// Retries is the number of attempts made after a failed notification
+	// attempt before giving up.
+	Retries int
+
+	// RetryDelay is the delay between notification attempts.
+	RetryDelay time.Duration
+}
+
+// Result wraps the results of notification operations to make it easier
+// to inspect the status of various tasks so that we can take action on either
+// error or success conditions
+type Result struct {
+
+	// Val is the non-error condition message to return from a notification
+	// operation
+	Val string
+
+	// Err is the error condition message to return from a notification
+	// operation
+	Err error
+
+	// Success indicates whether the notification attempt succeeded or if it
+	// failed for one reason or another (remote API, timeout, cancellation,
+	// etc)
+	Success bool
+
+	// Service is the name of the notification service which made the
+	// notification attempt.
+	Service string
+
+	// ItemID identifies the outbox item associated with the notification
+	// attempt.
+	ItemID string
+}
+
+// Service runs a Notifier, delivering each outbox item handed off to it
+// using the provided settings.
+type Service struct {
+	notifier Notifier
+	settings Settings
+	queue    chan outbox.Item
+	done     chan struct{}
+}
+
+// NewService returns a Service for the provided Notifier. Outbox items are
+// accepted into a queue of the specified depth; senders block once the queue
+// is full.
+func NewService(notifier Notifier, settings Settings, queueDepth int) *Service {
+	return &Service{
+		notifier: notifier,
+		settings: settings,
+		queue:    make(chan outbox.Item, queueDepth),
+		done:     make(chan struct{}),
+	}
+}
+
+// Name returns the name of the notification service.
+func (s *Service) Name() string {
+	return s.notifier.Name()
+}
+
+// Accepts indicates whether the Service should deliver a notification for
+// the provided event Record. All event Records are accepted unless the
+// Notifier implements the Filter interface.
+func (s *Service) Accepts(record events.Record) bool {
+	if filter, ok := s.notifier.(Filter); ok {
+		return filter.Accepts(record)
+	}
+	return true
+}
+
+// Queue returns the channel used to hand off outbox items to the Service for
+// delivery.
+func (s *Service) Queue() chan<- outbox.Item {
+	return s.queue
+}
+
+// Done returns a channel which is closed once the Service has stopped.
+func (s *Service) Done() <-chan struct{} {
+	return s.done
+}
+
+// Run receives outbox items and delivers each of them in a separate
+// goroutine, sending the result of each delivery on the provided results
+// channel. Deliveries are scheduled in order to respect the configured rate
+// limit. Once the provided context is cancelled, Run waits for deliveries in
+// progress to return their results and then closes the Done channel. This
+// method is intended to be run as a goroutine.
+func (s *Service) Run(ctx context.Context, results chan<- Result) {
+
+	defer close(s.done)
+
+	log.Debugf("%s notifier: Running", s.Name())
+
+	// Setup new scheduler that we can use to add an intentional delay between
+	// notification attempts. This delay is added in order to rate limit our
+	// outgoing messages to comply with remote API or server limits.
+	notifyScheduler := newScheduler(s.settings.RateLimit)
+
+	var deliveries sync.WaitGroup
+
+	for {
+
+		select {
+
+		case <-ctx.Done():
+			log.Debugf(
+				"%s notifier: Received Done signal: %v, waiting on deliveries in progress",
+				s.Name(),
+				ctx.Err().Error(),
+			)
+			deliveries.Wait()
+
+			log.Debugf("%s notifier: shutting down", s.Name())
+			return
+
+		case item := <-s.queue:
+
+			log.Debugf("%s notifier: Request received at %v: %#v",
+				s.Name(), time.Now(), item.Record)
+
+			nextScheduledNotification := notifyScheduler()
+
+			log.Debugf("%s notifier: Now: %v, Next scheduled notification: %v",
+				s.Name(),
+				time.Now().Format("15:04:05"),
+				nextScheduledNotification.Format("15:04:05"),
+			)
+
+			// launch task in separate goroutine, each with its own schedule
+			deliveries.Add(1)
+			go func(item outbox.Item, schedule time.Time) {
+				defer deliveries.Done()
+				results <- s.deliver(ctx, item, schedule)
+			}(item, nextScheduledNotification)
+
+		}
+	}
+}
+
+// deliver waits until the provided schedule and then attempts to send a
+// notification for the outbox item, retrying failed attempts up to the
+// configured number of retries.
+func (s *Service) deliver(ctx context.Context, item outbox.Item, schedule time.Time) Result {
+
+	result := Result{
+		Service: s.Name(),
+		ItemID:  item.ID,
+	}
+
+	// Delay the first attempt until the scheduled time; this will *always*
+	// delay, regardless of whether the notification is the first one or not
+	if err := wait(ctx, time.Until(schedule)); err != nil {
+		result.Val = fmt.Sprintf(
+			"%s notifier: context expired or cancelled at %v: %v, aborting notification %s",
+			s.Name(),
+			time.Now().Format("15:04:05"),
+			err,
+			item.ID,
+		)
+		log.Debug(result.Val)
+
+		return result
+	}
+
+	// initial attempt + number of specified retries
+	attemptsAllowed := 1 + s.settings.Retries
+
+	var sendErr error
+	for attempt := 1; attempt <= attemptsAllowed; attempt++ {
+
+		if attempt > 1 {
+			if err := wait(ctx, s.settings.RetryDelay); err != nil {
+				result.Err = fmt.Errorf(
+					"%s notifier: context cancelled or expired: %v; aborting notification %s after %d of %d attempts: %w",
+					s.Name(),
+					err,
+					item.ID,
+					attempt-1,
+					attemptsAllowed,
+					sendErr,
+				)
+				log.Error(result.Err.Error())
+
+				return result
+			}
+		}
+
+		// Each attempt (including retries) is counted so that the number
+		// of attempts can be compared with the number of notifications
+		// delivered or not.
+		metrics.NotificationAttempts.Inc(item.Service)
+
+		attemptCtx, cancel := context.WithTimeout(ctx, s.settings.Timeout)
+		sendErr = s.send(attemptCtx, item)
+		cancel()
+
+		if sendErr == nil {
+			result.Success = true
+			result.Val = fmt.Sprintf(
+				"%s notifier: notification %s successfully sent at %v after %d of %d attempts",
+				s.Name(),
+				item.ID,
+				time.Now().Format("15:04:05"),
+				attempt,
+				attemptsAllowed,
+			)
+			log.Debug(result.Val)
+
+			return result
+		}
+
+		log.Errorf(
+			"%s notifier: Attempt %d of %d to send notification %s failed: %v",
+			s.Name(),
+			attempt,
+			attemptsAllowed,
+			item.ID,
+			sendErr,
+		)
+	}
+
+	result.Err = fmt.Errorf(
+		"%s notifier: failed to send notification %s after %d attempts: %w",
+		s.Name(),
+		item.ID,
+		attemptsAllowed,
+		sendErr,
+	)
+
+	return result
+}
+
+// send makes a single attempt to deliver the outbox item. Recipients
+// recorded for the item are used in place of the default recipients if the
+// Notifier supports doing so; otherwise they are ignored.
+func (s *Service) send(ctx context.Context, item outbox.Item) error {
+
+	if len(item.Recipients) > 0 {
+		if notifier, ok := s.notifier.(RecipientNotifier); ok {
+			return notifier.SendTo(ctx, item.Record, item.Recipients)
+		}
+
+		log.Warnf(
+			"%s notifier: recipients for notification %s are not supported; using default recipients",
+			s.Name(),
+			item.ID,
+		)
+	}
+
+	return s.notifier.Send(ctx, item.Record)
+}
+
+// wait blocks for the specified delay or until the provided context is
+// cancelled, whichever comes first. The context error is returned if the
+// context is cancelled before or during the delay.
+func wait(ctx context.Context, delay time.Duration) error {
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"time"
+
+	"github.com/apex/log"
+)
+
+// newScheduler takes a time.Duration value as a delay and returns a function
+// that can be used to generate a new notification schedule. Each call to this
+// function will produce a new schedule incremented by the time.Duration delay
+// value. The intent is to provide an easy to use mechanism for delaying
+// notifications to remote systems (e.g., in order to respect remote API
+// limits).
+func newScheduler(delay time.Duration) func() time.Time {
+
+	log.Debugf("newScheduler: Initializing lastNotificationSchedule at %s",
+		time.Now().Format("15:04:05"),
+	)
+	lastNotificationSchedule := time.Now()
+
+	return func() time.Time {
+
+		// if we haven't sent a message in a while we should make ensure
+		// that we do not return a "next schedule" that has already passed
+		if !lastNotificationSchedule.After(time.Now()) {
+
+			expiredSchedule := lastNotificationSchedule.Add(delay)
+
+			log.Debugf(
+				"Expired next schedule: [Now: %v, Last: %v, Next: %v]",
+				time.Now().Format("15:04:05.000"),
+				lastNotificationSchedule.Format("15.04:05.000"),
+				expiredSchedule.Format("15:04:05.000"),
+			)
+
+			replacementSchedule := time.Now().Add(delay)
+
+			log.Debugf(
+				"Replace expired schedule (%v) by resetting the schedule to now (%v) + delay (%v): %v",
+				expiredSchedule.Format("15:04:05.000"),
+				time.Now().Format("15:04:05.000"),
+				delay,
+				replacementSchedule.Format("15:04:05"),
+			)
+
+			lastNotificationSchedule = replacementSchedule
+
+			return replacementSchedule
+		}
+
+		nextSchedule := lastNotificationSchedule.Add(delay)
+
+		log.Debugf(
+			"Next schedule not expired: [Last: %v, Now: %v, Next: %v]",
+			lastNotificationSchedule.Format("15:04:05"),
+			time.Now().Format("15:04:05"),
+			nextSchedule.Format("15:04:05"),
+		)
+
+		lastNotificationSchedule = nextSchedule
+
+		return nextSchedule
+	}
+}
@@ -0,0 +1,479 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/atc0005/brick/internal/events"
+	"github.com/atc0005/brick/internal/jsonlines"
+)
+
+// Valid Item status values.
+const (
+	// StatusPending indicates that the notification is waiting to be
+	// delivered.
+	StatusPending string = "pending"
+
+	// StatusDead indicates that the notification could not be delivered
+	// within the allowed number of attempts.
+	StatusDead string = "dead"
+
+	// statusDelivered is only recorded in the outbox file and indicates that
+	// an earlier entry with the same ID should be forgotten.
+	statusDelivered string = "delivered"
+)
+
+// ErrNotFound is returned when an item with the requested ID is not in the
+// outbox.
+var ErrNotFound = errors.New("outbox item not found")
+
+// Item is a notification waiting to be delivered (or which could not be
+// delivered) by a single notification service.
+type Item struct {
+	ID          string    `json:"id"`
+	Service     string    `json:"service"`
+	Status      string    `json:"status"`
+	Attempts    int       `json:"attempts"`
+	Queued      time.Time `json:"queued"`
+	LastAttempt time.Time `json:"last_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+
+	// Recipients optionally overrides the default recipients of the
+	// notification service (e.g., email addresses) for this item.
+	Recipients []string `json:"recipients,omitempty"`
+
+	// Record is the event Record to deliver.
+	Record events.Record `json:"-"`
+}
+
+// entry is a single line in the outbox file. Each entry records the current
+// state of an item; the last entry for an ID wins. The event Record is
+// stored using its JSON representation, which excludes the alert request
+// headers as they may include credentials. The alert payload is not part of
+// that representation and is stored alongside it.
+type entry struct {
+	Item
+	Stored  *events.Record  `json:"record,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Outbox holds notifications until they are delivered. Items which fail
+// delivery are offered again (via Due) after the retry delay until the
+// maximum number of attempts is reached, at which point they are moved to
+// the dead-letter list. If a path is provided, items are recorded in the
+// file at that path so that they survive a restart. Outbox is safe for
+// concurrent use.
+type Outbox struct {
+	maxAttempts int
+	retryDelay  time.Duration
+
+	mu    sync.Mutex
+	items map[string]*Item
+	file  *jsonlines.File
+
+	due  chan Item
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Open creates an Outbox which allows the specified number of delivery
+// attempts for each item, waiting retryDelay between attempts. If a path is
+// provided, items are loaded from and recorded to the file at that path
+// (creating it if needed).
+func Open(path string, permissions os.FileMode, maxAttempts int, retryDelay time.Duration) (*Outbox, error) {
+
+	o := Outbox{
+		maxAttempts: maxAttempts,
+		retryDelay:  retryDelay,
+		items:       make(map[string]*Item),
+		due:         make(chan Item),
+		done:        make(chan struct{}),
+	}
+
+	if path == "" {
+		return &o, nil
+	}
+
+	o.file = jsonlines.New(path, permissions, "outbox")
+
+	if err := o.load(); err != nil {
+		return nil, err
+	}
+
+	if err := o.file.Rewrite(o.entries()); err != nil {
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+// Add records a new pending item for delivery of the event Record by the
+// specified notification service. If provided, recipients override the
+// default recipients of the notification service. The item is synced to disk
+// before Add returns.
+func (o *Outbox) Add(service string, record events.Record, recipients ...string) (Item, error) {
+
+	id, err := newID()
+	if err != nil {
+		return Item{}, fmt.Errorf("failed to generate outbox item ID: %w", err)
+	}
+
+	item := Item{
+		ID:         id,
+		Service:    service,
+		Status:     StatusPending,
+		Queued:     time.Now(),
+		Recipients: recipients,
+		Record:     record,
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.items[id] = &item
+
+	return item, o.write(item)
+}
+
+// Delivered removes the item with the specified ID from the outbox. Once no
+// items remain, the outbox file is truncated.
+func (o *Outbox) Delivered(id string) error {
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	item, ok := o.items[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	delete(o.items, id)
+
+	// Once no items remain, the outbox file is truncated to keep it from
+	// growing without bound.
+	if len(o.items) == 0 && o.file != nil {
+		return o.file.Rewrite(nil)
+	}
+
+	delivered := *item
+	delivered.Status = statusDelivered
+
+	return o.write(delivered)
+}
+
+// Failed records a failed delivery attempt for the item with the specified
+// ID. If attempts remain, the item is offered again (via Due) after the
+// retry delay, otherwise it is moved to the dead-letter list. The updated
+// item is returned.
+func (o *Outbox) Failed(id string, deliveryErr error) (Item, error) {
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	item, ok := o.items[id]
+	if !ok {
+		return Item{}, ErrNotFound
+	}
+
+	item.Attempts++
+	item.LastAttempt = time.Now()
+	if deliveryErr != nil {
+		item.LastError = deliveryErr.Error()
+	}
+
+	if item.Attempts >= o.maxAttempts {
+		item.Status = StatusDead
+	}
+
+	if item.Status == StatusPending {
+		o.schedule(id, o.retryDelay)
+	}
+
+	return *item, o.write(*item)
+}
+
+// DeadLetter moves the item with the specified ID directly to the
+// dead-letter list, regardless of the number of attempts remaining. This is
+// intended for use when delivery is not possible (e.g., the notification
+// service is no longer enabled).
+func (o *Outbox) DeadLetter(id string, reason error) (Item, error) {
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	item, ok := o.items[id]
+	if !ok {
+		return Item{}, ErrNotFound
+	}
+
+	item.Status = StatusDead
+	if reason != nil {
+		item.LastError = reason.Error()
+	}
+
+	return *item, o.write(*item)
+}
+
+// Retry moves the dead-letter items with the specified IDs (or all
+// dead-letter items if none are specified) back to the pending list with a
+// fresh set of delivery attempts. The items are offered for delivery (via
+// Due) right away. The retried items are returned.
+func (o *Outbox) Retry(ids ...string) ([]Item, error) {
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(ids) == 0 {
+		for id, item := range o.items {
+			if item.Status == StatusDead {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	retried := make([]Item, 0, len(ids))
+	for _, id := range ids {
+		item, ok := o.items[id]
+		if !ok || item.Status != StatusDead {
+			return retried, fmt.Errorf("%w: no dead-letter item with ID %q", ErrNotFound, id)
+		}
+
+		item.Status = StatusPending
+		item.Attempts = 0
+
+		if err := o.write(*item); err != nil {
+			return retried, err
+		}
+
+		o.schedule(id, 0)
+		retried = append(retried, *item)
+	}
+
+	sortItems(retried)
+
+	return retried, nil
+}
+
+// Pending returns the items waiting to be delivered, oldest first.
+func (o *Outbox) Pending() []Item {
+	return o.list(StatusPending)
+}
+
+// DeadLetters returns the items which could not be delivered, oldest
+// first.
+func (o *Outbox) DeadLetters() []Item {
+	return o.list(StatusDead)
+}
+
+// Len returns the number of items with the specified status.
+func (o *Outbox) Len(status string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var n int
+	for _, item := range o.items {
+		if item.Status == status {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Due returns a channel on which pending items are offered again for
+// delivery once their retry delay has passed or after they are retried from
+// the dead-letter list.
+func (o *Outbox) Due() <-chan Item {
+	return o.due
+}
+
+// Close stops offering items for delivery and closes the outbox file, if
+// any. Pending items remain in the outbox file for the next startup.
+func (o *Outbox) Close() error {
+
+	close(o.done)
+	o.wg.Wait()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.file == nil {
+		return nil
+	}
+
+	return o.file.Close()
+}
+
+// list returns the items with the specified status, oldest first.
+func (o *Outbox) list(status string) []Item {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	items := make([]Item, 0, len(o.items))
+	for _, item := range o.items {
+		if item.Status == status {
+			items = append(items, *item)
+		}
+	}
+
+	sortItems(items)
+
+	return items
+}
+
+// schedule offers the item with the specified ID on the Due channel once
+// the delay has passed, provided that the item is still pending.
+func (o *Outbox) schedule(id string, delay time.Duration) {
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-o.done:
+			return
+		}
+
+		o.mu.Lock()
+		item, ok := o.items[id]
+		var pending Item
+		if ok {
+			pending = *item
+		}
+		o.mu.Unlock()
+
+		if !ok || pending.Status != StatusPending {
+			return
+		}
+
+		select {
+		case o.due <- pending:
+		case <-o.done:
+		}
+	}()
+}
+
+// load reads items from the outbox file. A missing file is treated as
+// empty. Lines which cannot be parsed are skipped.
+func (o *Outbox) load() error {
+
+	return o.file.Read(func(lineno int, line []byte) error {
+
+		var e entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+
+		switch e.Status {
+		case statusDelivered:
+			delete(o.items, e.ID)
+
+		case StatusPending, StatusDead:
+			if e.Stored == nil {
+				return fmt.Errorf("entry %q has no record", e.ID)
+			}
+			item := e.Item
+			item.Record = *e.Stored
+			item.Record.Alert.Payload = e.Payload
+			o.items[item.ID] = &item
+
+		default:
+			return fmt.Errorf("unknown status %q", e.Status)
+		}
+
+		return nil
+	})
+}
+
+// entries returns the current items for writing to the outbox file. The
+// caller is responsible for holding the mutex (if needed).
+func (o *Outbox) entries() []interface{} {
+
+	entries := make([]interface{}, 0, len(o.items))
+	for _, item := range o.items {
+		entries = append(entries, newEntry(*item))
+	}
+
+	return entries
+}
+
+// write appends the current state of the item to the outbox file, if any,
+// and syncs it to disk. The outbox file is rewritten once it holds many more
+// lines than there are items. The caller is responsible for holding the
+// mutex.
+func (o *Outbox) write(item Item) error {
+
+	if o.file == nil {
+		return nil
+	}
+
+	if err := o.file.Append(newEntry(item)); err != nil {
+		return err
+	}
+
+	// The item is already synced to disk; if compaction fails it is
+	// attempted again on a later write.
+	if o.file.NeedsCompaction(len(o.items)) {
+		o.file.Compact(o.entries())
+	}
+
+	return nil
+}
+
+// newEntry returns the outbox file entry recording the current state of the
+// item.
+func newEntry(item Item) entry {
+
+	e := entry{Item: item}
+	if item.Status != statusDelivered {
+		e.Stored = &item.Record
+		e.Payload = item.Record.Alert.Payload
+	}
+
+	return e
+}
+
+// sortItems sorts the items by the time they were queued, oldest first.
+func sortItems(items []Item) {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Queued.Before(items[j].Queued)
+	})
+}
+
+// newID returns a random identifier for an outbox item.
+func newID() (string, error) {
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,277 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siem
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/brick/internal/events"
+)
+
+// Format is the output format used to render event records.
+type Format string
+
+// Supported output formats.
+const (
+	FormatCEF  Format = "cef"
+	FormatLEEF Format = "leef"
+)
+
+// Vendor and product values used in CEF and LEEF headers.
+const (
+	deviceVendor  string = "atc0005"
+	deviceProduct string = "brick"
+)
+
+// Outcome values used to indicate the result of the recorded action.
+const (
+	outcomeSuccess string = "success"
+	outcomeFailure string = "failure"
+	outcomeSkipped string = "skipped"
+)
+
+// signature is the SIEM classification of a Record Action. The severity
+// uses the CEF scale of 0 (lowest) to 10 (highest).
+type signature struct {
+	id       string
+	severity int
+	outcome  string
+}
+
+// unknownSignature is used for any Action not present in signatures.
+var unknownSignature = signature{id: "999", severity: 5, outcome: outcomeFailure}
+
+// signatures maps each Record Action to a stable signature ID and severity.
+// Signature IDs are part of the output "contract" with SIEM rules; existing
+// IDs should not be changed.
+var signatures = map[string]signature{
+	events.ActionSuccessDisableRequestReceived: {id: "100", severity: 3, outcome: outcomeSuccess},
+	events.ActionSuccessDisabledUsername:       {id: "101", severity: 7, outcome: outcomeSuccess},
+	events.ActionSuccessDuplicatedUsername:     {id: "102", severity: 5, outcome: outcomeSuccess},
+	events.ActionSuccessIgnoredUsername:        {id: "103", severity: 3, outcome: outcomeSuccess},
+	events.ActionSuccessIgnoredIPAddress:       {id: "104", severity: 3, outcome: outcomeSuccess},
+	events.ActionSuccessTerminatedUserSession:  {id: "105", severity: 8, outcome: outcomeSuccess},
+	events.ActionSkippedTerminateUserSessions:  {id: "106", severity: 4, outcome: outcomeSkipped},
+	events.ActionSkippedStaleAlert:             {id: "107", severity: 5, outcome: outcomeSkipped},
+
+	events.ActionFailureDisableRequestReceived:   {id: "200", severity: 6, outcome: outcomeFailure},
+	events.ActionFailureDisabledUsername:         {id: "201", severity: 9, outcome: outcomeFailure},
+	events.ActionFailureDuplicatedUsername:       {id: "202", severity: 8, outcome: outcomeFailure},
+	events.ActionFailureIgnoredUsername:          {id: "203", severity: 8, outcome: outcomeFailure},
+	events.ActionFailureIgnoredIPAddress:         {id: "204", severity: 8, outcome: outcomeFailure},
+	events.ActionFailureUserSessionLookupFailure: {id: "205", severity: 7, outcome: outcomeFailure},
+	events.ActionFailureTerminatedUserSession:    {id: "206", severity: 9, outcome: outcomeFailure},
+}
+
+// lookupSignature returns the signature for the provided Action.
+func lookupSignature(action string) signature {
+	if sig, ok := signatures[action]; ok {
+		return sig
+	}
+	return unknownSignature
+}
+
+// field is a single key/value pair in the CEF extension or LEEF attributes
+// section of a rendered line. Fields are kept in a slice to provide a stable
+// output order.
+type field struct {
+	key   string
+	value string
+}
+
+// recordFields returns the values common to both formats using the
+// provided key names. Empty values are omitted.
+func recordFields(record events.Record, keys map[string]string) []field {
+
+	var errMsg string
+	if record.Error != nil {
+		errMsg = record.Error.Error()
+	}
+
+	var sessionsTerminated int
+	for _, result := range record.SessionTerminationResults {
+		if result.Error == nil && result.ExitCode == 0 {
+			sessionsTerminated++
+		}
+	}
+
+	values := []field{
+		{key: "action", value: record.Action},
+		{key: "outcome", value: lookupSignature(record.Action).outcome},
+		{key: "user", value: record.Alert.Username},
+		{key: "src", value: record.Alert.UserIP},
+		{key: "alertName", value: record.Alert.AlertName},
+		{key: "searchID", value: record.Alert.SearchID},
+		{key: "payloadSender", value: record.Alert.PayloadSenderIP},
+		{key: "msg", value: record.Note},
+		{key: "reason", value: errMsg},
+	}
+
+	if len(record.SessionTerminationResults) > 0 {
+		values = append(values, field{
+			key:   "sessionsTerminated",
+			value: strconv.Itoa(sessionsTerminated),
+		})
+	}
+
+	fields := make([]field, 0, len(values))
+	for _, v := range values {
+		key, ok := keys[v.key]
+		if !ok || v.value == "" {
+			continue
+		}
+		fields = append(fields, field{key: key, value: v.value})
+	}
+
+	return fields
+}
+
+// arrivalTime parses the RFC3339 formatted alert arrival time. The zero
+// value is returned if the value cannot be parsed.
+func arrivalTime(record events.Record) time.Time {
+	t, err := time.Parse(time.RFC3339, record.Alert.ArrivalTime)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// cefExtensionKeys maps common values to CEF extension keys. Values without
+// a dedicated key use the custom string/number fields along with a label.
+var cefExtensionKeys = map[string]string{
+	"action":             "act",
+	"outcome":            "outcome",
+	"user":               "suser",
+	"src":                "src",
+	"alertName":          "cs1",
+	"searchID":           "cs2",
+	"payloadSender":      "cs3",
+	"msg":                "msg",
+	"reason":             "reason",
+	"sessionsTerminated": "cn1",
+}
+
+// cefLabels provides the labels for the custom CEF fields in use.
+var cefLabels = map[string]string{
+	"cs1": "cs1Label=AlertName",
+	"cs2": "cs2Label=SearchID",
+	"cs3": "cs3Label=PayloadSenderIP",
+	"cn1": "cn1Label=SessionsTerminated",
+}
+
+// cefHeaderEscaper escapes values used in the pipe-delimited CEF header.
+var cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+
+// cefExtensionEscaper escapes values used in the CEF extension section.
+var cefExtensionEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`=`, `\=`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
+// formatCEF renders the provided Record as a CEF line.
+func formatCEF(record events.Record, productVersion string) string {
+
+	sig := lookupSignature(record.Action)
+
+	extension := make([]string, 0, 16)
+
+	if t := arrivalTime(record); !t.IsZero() {
+		extension = append(extension, "rt="+strconv.FormatInt(t.UnixMilli(), 10))
+	}
+
+	for _, f := range recordFields(record, cefExtensionKeys) {
+		if label, ok := cefLabels[f.key]; ok {
+			extension = append(extension, label)
+		}
+		extension = append(extension, f.key+"="+cefExtensionEscaper.Replace(f.value))
+	}
+
+	return fmt.Sprintf(
+		"CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		cefHeaderEscaper.Replace(deviceVendor),
+		cefHeaderEscaper.Replace(deviceProduct),
+		cefHeaderEscaper.Replace(productVersion),
+		cefHeaderEscaper.Replace(sig.id),
+		cefHeaderEscaper.Replace(record.Action),
+		sig.severity,
+		strings.Join(extension, " "),
+	)
+}
+
+// leefAttributeKeys maps common values to LEEF attribute keys. Predefined
+// LEEF keys are used where available.
+var leefAttributeKeys = map[string]string{
+	"outcome":            "outcome",
+	"user":               "usrName",
+	"src":                "src",
+	"alertName":          "alertName",
+	"searchID":           "searchID",
+	"payloadSender":      "payloadSender",
+	"msg":                "msg",
+	"reason":             "reason",
+	"sessionsTerminated": "sessionsTerminated",
+}
+
+// leefDevTimeLayout is the Go equivalent of the default LEEF devTime format
+// ("MMM dd yyyy HH:mm:ss.SSS zzz") which avoids the need to also specify a
+// devTimeFormat attribute.
+const leefDevTimeLayout string = "Jan 02 2006 15:04:05.000 MST"
+
+// leefHeaderEscaper escapes values used in the pipe-delimited LEEF header.
+var leefHeaderEscaper = strings.NewReplacer(`|`, `\|`)
+
+// leefAttributeEscaper replaces characters which cannot appear in LEEF
+// attribute values when using the default tab delimiter.
+var leefAttributeEscaper = strings.NewReplacer(
+	"\t", " ",
+	"\r\n", " ",
+	"\n", " ",
+	"\r", " ",
+)
+
+// formatLEEF renders the provided Record as a LEEF 1.0 line.
+func formatLEEF(record events.Record, productVersion string) string {
+
+	sig := lookupSignature(record.Action)
+
+	attributes := make([]string, 0, 16)
+	attributes = append(attributes, "cat="+leefAttributeEscaper.Replace(record.Action))
+	attributes = append(attributes, "sev="+strconv.Itoa(sig.severity))
+
+	if t := arrivalTime(record); !t.IsZero() {
+		attributes = append(attributes, "devTime="+t.Format(leefDevTimeLayout))
+	}
+
+	for _, f := range recordFields(record, leefAttributeKeys) {
+		attributes = append(attributes, f.key+"="+leefAttributeEscaper.Replace(f.value))
+	}
+
+	return fmt.Sprintf(
+		"LEEF:1.0|%s|%s|%s|%s|%s",
+		leefHeaderEscaper.Replace(deviceVendor),
+		leefHeaderEscaper.Replace(deviceProduct),
+		leefHeaderEscaper.Replace(productVersion),
+		leefHeaderEscaper.Replace(sig.id),
+		strings.Join(attributes, "\t"),
+	)
+}
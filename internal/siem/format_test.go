@@ -0,0 +1,157 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siem
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/atc0005/brick/internal/events"
+)
+
+// testRecord returns a Record whose values include the characters which
+// require escaping in CEF and LEEF output.
+func testRecord(action string) events.Record {
+	return events.Record{
+		Action: action,
+		Alert: events.SplunkAlertEvent{
+			Username:        "alice",
+			UserIP:          "192.0.2.10",
+			AlertName:       "a|b=c",
+			SearchID:        `sid\1`,
+			PayloadSenderIP: "192.0.2.20",
+			ArrivalTime:     "2024-01-02T03:04:05Z",
+		},
+		Note:  "line1\nline2=x\tend",
+		Error: errors.New("path C:\\x\r\nfailed"),
+	}
+}
+
+func TestFormatCEF(t *testing.T) {
+	tests := []struct {
+		name           string
+		action         string
+		productVersion string
+		want           string
+	}{
+		{
+			name:           "known action",
+			action:         events.ActionFailureDisabledUsername,
+			productVersion: `1.0|beta\x`,
+			want: `CEF:0|atc0005|brick|1.0\|beta\\x|201|Username disable failure|9|` +
+				`rt=1704164645000 act=Username disable failure outcome=failure suser=alice src=192.0.2.10 ` +
+				`cs1Label=AlertName cs1=a|b\=c cs2Label=SearchID cs2=sid\\1 ` +
+				`cs3Label=PayloadSenderIP cs3=192.0.2.20 ` +
+				"msg=line1\\nline2\\=x\tend reason=path C:\\\\x\\nfailed",
+		},
+		{
+			name:           "unknown action",
+			action:         `custom|action\x`,
+			productVersion: "1",
+			want: `CEF:0|atc0005|brick|1|999|custom\|action\\x|5|` +
+				`rt=1704164645000 act=custom|action\\x outcome=failure suser=alice src=192.0.2.10 ` +
+				`cs1Label=AlertName cs1=a|b\=c cs2Label=SearchID cs2=sid\\1 ` +
+				`cs3Label=PayloadSenderIP cs3=192.0.2.20 ` +
+				"msg=line1\\nline2\\=x\tend reason=path C:\\\\x\\nfailed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatCEF(testRecord(tt.action), tt.productVersion); got != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLEEF(t *testing.T) {
+	tests := []struct {
+		name           string
+		action         string
+		productVersion string
+		want           string
+	}{
+		{
+			name:           "known action",
+			action:         events.ActionFailureDisabledUsername,
+			productVersion: `1.0|beta\x`,
+			want: `LEEF:1.0|atc0005|brick|1.0\|beta\x|201|` +
+				"cat=Username disable failure\tsev=9\tdevTime=Jan 02 2024 03:04:05.000 UTC\t" +
+				"outcome=failure\tusrName=alice\tsrc=192.0.2.10\talertName=a|b=c\t" +
+				"searchID=sid\\1\tpayloadSender=192.0.2.20\t" +
+				"msg=line1 line2=x end\treason=path C:\\x failed",
+		},
+		{
+			name:           "unknown action",
+			action:         "custom\taction",
+			productVersion: "1",
+			want: `LEEF:1.0|atc0005|brick|1|999|` +
+				"cat=custom action\tsev=5\tdevTime=Jan 02 2024 03:04:05.000 UTC\t" +
+				"outcome=failure\tusrName=alice\tsrc=192.0.2.10\talertName=a|b=c\t" +
+				"searchID=sid\\1\tpayloadSender=192.0.2.20\t" +
+				"msg=line1 line2=x end\treason=path C:\\x failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatLEEF(testRecord(tt.action), tt.productVersion); got != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatOmitsEmptyValues(t *testing.T) {
+	action := events.ActionSuccessDisableRequestReceived
+
+	record := events.Record{
+		Action: action,
+		Alert: events.SplunkAlertEvent{
+			Username: "alice",
+		},
+	}
+
+	want := "CEF:0|atc0005|brick|1|100|" + action + "|3|" +
+		"act=" + action + " outcome=success suser=alice"
+	if got := formatCEF(record, "1"); got != want {
+		t.Errorf("\ngot:  %q\nwant: %q", got, want)
+	}
+
+	want = "LEEF:1.0|atc0005|brick|1|100|" +
+		"cat=" + action + "\tsev=3\toutcome=success\tusrName=alice"
+	if got := formatLEEF(record, "1"); got != want {
+		t.Errorf("\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+// TestSignatures confirms that signature IDs, which SIEM rules rely upon,
+// are unique.
+func TestSignatures(t *testing.T) {
+	seen := make(map[string]string, len(signatures))
+	for action, sig := range signatures {
+		if other, ok := seen[sig.id]; ok {
+			t.Errorf("signature ID %s used by both %q and %q", sig.id, action, other)
+		}
+		seen[sig.id] = action
+
+		if sig.severity < 0 || sig.severity > 10 {
+			t.Errorf("signature %s for %q: severity %d outside of 0-10", sig.id, action, sig.severity)
+		}
+	}
+}
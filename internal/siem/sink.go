@@ -0,0 +1,168 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/apex/log"
+
+	"github.com/atc0005/brick/internal/caller"
+	"github.com/atc0005/brick/internal/events"
+	"github.com/atc0005/brick/internal/syslog"
+)
+
+// SyslogTag is the APP-NAME used for events sent to a syslog daemon. A tag
+// distinct from the one used for application log messages allows SIEM events
+// to be routed separately.
+const SyslogTag string = "brick-siem"
+
+// lineWriter is implemented by the supported outputs for rendered lines.
+type lineWriter interface {
+	writeLine(line string, severity int) error
+}
+
+// Sink renders each event Record as a CEF or LEEF line and writes it to a
+// file or syslog daemon. Sink implements the events.RecordSink interface.
+type Sink struct {
+	format         Format
+	productVersion string
+	out            lineWriter
+}
+
+// NewFileSink constructs a Sink which appends rendered lines to the
+// specified file, creating it with the provided permissions if needed.
+func NewFileSink(format Format, productVersion string, path string, permissions os.FileMode) *Sink {
+	return &Sink{
+		format:         format,
+		productVersion: productVersion,
+		out: &fileWriter{
+			path:        path,
+			permissions: permissions,
+		},
+	}
+}
+
+// NewSyslogSink constructs a Sink which sends rendered lines to a syslog
+// daemon using the provided syslog Writer. The syslog severity of each
+// message is derived from the signature severity of the recorded action.
+func NewSyslogSink(format Format, productVersion string, w *syslog.Writer) *Sink {
+	return &Sink{
+		format:         format,
+		productVersion: productVersion,
+		out: &syslogWriter{
+			w: w,
+		},
+	}
+}
+
+// WriteRecord implements the events.RecordSink interface.
+func (s *Sink) WriteRecord(record events.Record) error {
+
+	var line string
+	switch s.format {
+	case FormatCEF:
+		line = formatCEF(record, s.productVersion)
+	case FormatLEEF:
+		line = formatLEEF(record, s.productVersion)
+	default:
+		return fmt.Errorf("unsupported SIEM output format %q", s.format)
+	}
+
+	return s.out.writeLine(line, lookupSignature(record.Action).severity)
+}
+
+// fileWriter appends lines to a file.
+type fileWriter struct {
+	path        string
+	permissions os.FileMode
+	mu          sync.Mutex
+}
+
+func (fw *fileWriter) writeLine(line string, _ int) error {
+
+	myFuncName := caller.GetFuncName()
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	// #nosec G304
+	f, opErr := os.OpenFile(filepath.Clean(fw.path), os.O_APPEND|os.O_CREATE|os.O_WRONLY, fw.permissions)
+	if opErr != nil {
+		return fmt.Errorf(
+			"%s: error encountered opening file %q: %w",
+			myFuncName,
+			fw.path,
+			opErr,
+		)
+	}
+
+	// #nosec G307
+	// Believed to be a false-positive from recent gosec release
+	// https://github.com/securego/gosec/issues/714
+	defer func(filename string) {
+		if err := f.Close(); err != nil {
+			// Ignore "file already closed" errors
+			if !errors.Is(err, os.ErrClosed) {
+				log.Errorf(
+					"%s: failed to close file %q: %s",
+					myFuncName,
+					filename,
+					err.Error(),
+				)
+			}
+		}
+	}(fw.path)
+
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		return fmt.Errorf(
+			"%s: error writing to file %q: %w",
+			myFuncName,
+			fw.path,
+			err,
+		)
+	}
+
+	return nil
+}
+
+// syslogWriter sends lines to a syslog daemon.
+type syslogWriter struct {
+	w *syslog.Writer
+}
+
+func (sw *syslogWriter) writeLine(line string, severity int) error {
+	return sw.w.Write(syslogSeverity(severity), line)
+}
+
+// syslogSeverity maps a CEF severity (0-10) to the closest syslog severity.
+func syslogSeverity(severity int) syslog.Severity {
+	switch {
+	case severity >= 9:
+		return syslog.SeverityErr
+	case severity >= 7:
+		return syslog.SeverityWarning
+	case severity >= 4:
+		return syslog.SeverityNotice
+	default:
+		return syslog.SeverityInfo
+	}
+}
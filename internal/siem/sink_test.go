@@ -0,0 +1,147 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siem
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atc0005/brick/internal/events"
+	"github.com/atc0005/brick/internal/syslog"
+)
+
+func TestSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start listener: %v", err)
+	}
+	defer conn.Close()
+
+	w, err := syslog.Dial(syslog.NetworkUDP, conn.LocalAddr().String(), syslog.FacilityDaemon, SyslogTag)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer w.Close()
+
+	tests := []struct {
+		format   Format
+		action   string
+		severity syslog.Severity
+		line     func(events.Record) string
+	}{
+		{
+			format:   FormatCEF,
+			action:   events.ActionFailureDisabledUsername,
+			severity: syslog.SeverityErr,
+			line:     func(r events.Record) string { return formatCEF(r, "1.0") },
+		},
+		{
+			format:   FormatLEEF,
+			action:   events.ActionSuccessDisabledUsername,
+			severity: syslog.SeverityWarning,
+			line:     func(r events.Record) string { return formatLEEF(r, "1.0") },
+		},
+		{
+			format:   FormatCEF,
+			action:   events.ActionSkippedStaleAlert,
+			severity: syslog.SeverityNotice,
+			line:     func(r events.Record) string { return formatCEF(r, "1.0") },
+		},
+		{
+			format:   FormatLEEF,
+			action:   events.ActionSuccessDisableRequestReceived,
+			severity: syslog.SeverityInfo,
+			line:     func(r events.Record) string { return formatLEEF(r, "1.0") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s", tt.format, tt.action), func(t *testing.T) {
+			record := testRecord(tt.action)
+
+			sink := NewSyslogSink(tt.format, "1.0", w)
+			if err := sink.WriteRecord(record); err != nil {
+				t.Fatalf("failed to write record: %v", err)
+			}
+
+			if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+				t.Fatalf("failed to set deadline: %v", err)
+			}
+
+			buf := make([]byte, 4096)
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				t.Fatalf("failed to read message: %v", err)
+			}
+			message := string(buf[:n])
+
+			// The escaped line is sent as the MSG part of a RFC 5424
+			// message using the SIEM tag as APP-NAME; newlines within the
+			// record values must not split the message.
+			wantPrefix := fmt.Sprintf("<%d>1 ", int(syslog.FacilityDaemon)<<3|int(tt.severity))
+			if !strings.HasPrefix(message, wantPrefix) {
+				t.Errorf("message %q does not start with %q", message, wantPrefix)
+			}
+
+			wantSuffix := fmt.Sprintf(" %s %d - - %s", SyslogTag, os.Getpid(), tt.line(record))
+			if !strings.HasSuffix(message, wantSuffix) {
+				t.Errorf("message %q does not end with %q", message, wantSuffix)
+			}
+
+			if strings.ContainsAny(message, "\r\n") {
+				t.Errorf("message %q contains a line break", message)
+			}
+		})
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "siem.log")
+	sink := NewFileSink(FormatCEF, "1.0", path, 0o600)
+
+	records := []events.Record{
+		testRecord(events.ActionSuccessDisabledUsername),
+		testRecord(events.ActionFailureTerminatedUserSession),
+	}
+	for _, record := range records {
+		if err := sink.WriteRecord(record); err != nil {
+			t.Fatalf("failed to write record: %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+
+	want := formatCEF(records[0], "1.0") + "\n" + formatCEF(records[1], "1.0") + "\n"
+	if string(content) != want {
+		t.Errorf("\ngot:  %q\nwant: %q", content, want)
+	}
+}
+
+func TestSinkUnsupportedFormat(t *testing.T) {
+	sink := NewFileSink(Format("syslog"), "1.0", filepath.Join(t.TempDir(), "siem.log"), 0o600)
+	if err := sink.WriteRecord(testRecord(events.ActionSuccessDisabledUsername)); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+)
+
+// ansiEscapes matches the color codes emitted by the text and cli apex/log
+// handlers which are not useful in syslog messages.
+var ansiEscapes = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// Handler is an apex/log Handler which sends log entries to a syslog daemon.
+// The message body of each entry is rendered by a formatting handler (e.g.,
+// text, json, logfmt) and the entry level is mapped to a syslog severity.
+type Handler struct {
+	w         *Writer
+	formatter log.Handler
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// NewHandler constructs a Handler which sends log entries to the syslog
+// daemon using the provided Writer. The newFormatter function is used to
+// construct the formatting handler responsible for rendering message bodies;
+// the constructors provided by the apex/log handler packages (e.g., text.New)
+// may be used as-is.
+func NewHandler(w *Writer, newFormatter func(io.Writer) log.Handler) *Handler {
+	h := Handler{
+		w: w,
+	}
+	h.formatter = newFormatter(&h.buf)
+
+	return &h
+}
+
+// HandleLog implements the log.Handler interface.
+func (h *Handler) HandleLog(e *log.Entry) error {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buf.Reset()
+	if err := h.formatter.HandleLog(e); err != nil {
+		return err
+	}
+
+	msg := strings.TrimSpace(ansiEscapes.ReplaceAllString(h.buf.String(), ""))
+
+	if err := h.w.Write(levelSeverity(e.Level), msg); err != nil {
+		// There is nowhere else to log this failure; fall back to stderr so
+		// that the message is not lost.
+		fmt.Fprintf(os.Stderr, "failed to send log message to syslog: %s: %s\n", err, msg)
+		return err
+	}
+
+	return nil
+}
+
+// levelSeverity maps an apex/log level to the equivalent syslog severity.
+func levelSeverity(level log.Level) Severity {
+	switch level {
+	case log.DebugLevel:
+		return SeverityDebug
+	case log.InfoLevel:
+		return SeverityInfo
+	case log.WarnLevel:
+		return SeverityWarning
+	case log.ErrorLevel:
+		return SeverityErr
+	case log.FatalLevel:
+		return SeverityCrit
+	default:
+		return SeverityNotice
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syslog
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severity is the severity level of a syslog message as defined by RFC 5424.
+type Severity int
+
+// Syslog message severity levels.
+const (
+	SeverityEmerg Severity = iota
+	SeverityAlert
+	SeverityCrit
+	SeverityErr
+	SeverityWarning
+	SeverityNotice
+	SeverityInfo
+	SeverityDebug
+)
+
+// Facility is the facility code of a syslog message as defined by RFC 5424.
+type Facility int
+
+// Syslog message facility codes.
+const (
+	FacilityKern Facility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	_ // NTP
+	_ // log audit
+	_ // log alert
+	_ // clock daemon
+	FacilityLocal0
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// Supported network types used to connect to the syslog daemon.
+const (
+	NetworkUDP      string = "udp"
+	NetworkTCP      string = "tcp"
+	NetworkUnix     string = "unix"
+	NetworkUnixgram string = "unixgram"
+)
+
+// dialTimeout is applied to each attempt to connect to the syslog daemon.
+const dialTimeout time.Duration = 5 * time.Second
+
+var facilityNames = map[string]Facility{
+	"kern":     FacilityKern,
+	"user":     FacilityUser,
+	"mail":     FacilityMail,
+	"daemon":   FacilityDaemon,
+	"auth":     FacilityAuth,
+	"syslog":   FacilitySyslog,
+	"lpr":      FacilityLPR,
+	"news":     FacilityNews,
+	"uucp":     FacilityUUCP,
+	"cron":     FacilityCron,
+	"authpriv": FacilityAuthPriv,
+	"ftp":      FacilityFTP,
+	"local0":   FacilityLocal0,
+	"local1":   FacilityLocal1,
+	"local2":   FacilityLocal2,
+	"local3":   FacilityLocal3,
+	"local4":   FacilityLocal4,
+	"local5":   FacilityLocal5,
+	"local6":   FacilityLocal6,
+	"local7":   FacilityLocal7,
+}
+
+// ParseFacility returns the Facility associated with the provided name
+// (e.g., "daemon", "local0") or an error if the name is not recognized.
+func ParseFacility(name string) (Facility, error) {
+	facility, ok := facilityNames[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown syslog facility %q", name)
+	}
+	return facility, nil
+}
+
+// ValidNetwork indicates whether the provided network type is supported.
+func ValidNetwork(network string) bool {
+	switch network {
+	case NetworkUDP, NetworkTCP, NetworkUnix, NetworkUnixgram:
+		return true
+	default:
+		return false
+	}
+}
+
+// Writer is a connection to a syslog daemon. A Writer is safe for concurrent
+// use by multiple goroutines.
+type Writer struct {
+	network  string
+	address  string
+	facility Facility
+	hostname string
+	tag      string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Dial establishes a connection to the syslog daemon at the specified address
+// using the specified network type (one of udp, tcp, unix or unixgram). Each
+// message is sent using the provided facility and tag (APP-NAME).
+func Dial(network string, address string, facility Facility, tag string) (*Writer, error) {
+
+	if !ValidNetwork(network) {
+		return nil, fmt.Errorf("unsupported syslog network type %q", network)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	w := Writer{
+		network:  network,
+		address:  address,
+		facility: facility,
+		hostname: hostname,
+		tag:      tag,
+	}
+
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+// connect (re)establishes the connection to the syslog daemon. The caller is
+// responsible for holding the mutex.
+func (w *Writer) connect() error {
+
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+
+	conn, err := net.DialTimeout(w.network, w.address, dialTimeout)
+	if err != nil {
+		return fmt.Errorf(
+			"failed to connect to syslog daemon at %q using %s: %w",
+			w.address,
+			w.network,
+			err,
+		)
+	}
+	w.conn = conn
+
+	return nil
+}
+
+// format generates a RFC 5424 formatted message. Messages sent over stream
+// connections are terminated with a newline (non-transparent framing).
+func (w *Writer) format(severity Severity, msg string) string {
+
+	msg = strings.TrimRight(msg, "\r\n")
+
+	line := fmt.Sprintf(
+		"<%d>1 %s %s %s %d - - %s",
+		int(w.facility)<<3|int(severity),
+		time.Now().Format("2006-01-02T15:04:05.000000Z07:00"),
+		w.hostname,
+		w.tag,
+		os.Getpid(),
+		msg,
+	)
+
+	switch w.network {
+	case NetworkTCP, NetworkUnix:
+		line += "\n"
+	}
+
+	return line
+}
+
+// Write sends the provided message to the syslog daemon with the specified
+// severity. If the message cannot be sent, a single attempt is made to
+// reconnect and resend the message before returning an error.
+func (w *Writer) Write(severity Severity, msg string) error {
+
+	line := w.format(severity, msg)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		if _, err := w.conn.Write([]byte(line)); err == nil {
+			return nil
+		}
+	}
+
+	if err := w.connect(); err != nil {
+		return err
+	}
+
+	if _, err := w.conn.Write([]byte(line)); err != nil {
+		return fmt.Errorf(
+			"failed to send message to syslog daemon at %q: %w",
+			w.address,
+			err,
+		)
+	}
+
+	return nil
+}
+
+// Close closes the connection to the syslog daemon.
+func (w *Writer) Close() error {
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+
+	err := w.conn.Close()
+	w.conn = nil
+
+	return err
+}
@@ -0,0 +1,215 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syslog
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc5424Message matches the messages generated by Writer: PRI, VERSION,
+// TIMESTAMP, HOSTNAME, APP-NAME, PROCID, MSGID and STRUCTURED-DATA (both
+// nil) followed by the message.
+var rfc5424Message = regexp.MustCompile(`^<(\d{1,3})>1 (\S+) (\S+) (\S+) (\d+) - - (.*)$`)
+
+// checkMessage confirms that the provided message is RFC 5424 formatted using
+// the expected priority, tag and message text.
+func checkMessage(t *testing.T, message string, wantPriority int, wantTag string, wantMsg string) {
+	t.Helper()
+
+	matches := rfc5424Message.FindStringSubmatch(message)
+	if matches == nil {
+		t.Fatalf("message %q is not RFC 5424 formatted", message)
+	}
+
+	if got, _ := strconv.Atoi(matches[1]); got != wantPriority {
+		t.Errorf("PRI: got %d, want %d", got, wantPriority)
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, matches[2])
+	if err != nil {
+		t.Errorf("TIMESTAMP %q is not RFC 3339 formatted: %v", matches[2], err)
+	}
+	if time.Since(timestamp) > time.Minute {
+		t.Errorf("TIMESTAMP %q is not current", matches[2])
+	}
+
+	if hostname, err := os.Hostname(); err == nil && matches[3] != hostname {
+		t.Errorf("HOSTNAME: got %q, want %q", matches[3], hostname)
+	}
+
+	if matches[4] != wantTag {
+		t.Errorf("APP-NAME: got %q, want %q", matches[4], wantTag)
+	}
+
+	if got := matches[5]; got != strconv.Itoa(os.Getpid()) {
+		t.Errorf("PROCID: got %q, want %d", got, os.Getpid())
+	}
+
+	if matches[6] != wantMsg {
+		t.Errorf("MSG: got %q, want %q", matches[6], wantMsg)
+	}
+}
+
+func TestWriterUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start listener: %v", err)
+	}
+	defer conn.Close()
+
+	w, err := Dial(NetworkUDP, conn.LocalAddr().String(), FacilityLocal0, "brick")
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer w.Close()
+
+	if err := w.Write(SeverityWarning, "disabled user \"alice\"\n"); err != nil {
+		t.Fatalf("failed to write message: %v", err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("failed to set deadline: %v", err)
+	}
+
+	buf := make([]byte, 2048)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("failed to read message: %v", err)
+	}
+
+	// Each datagram holds a single message without a trailing newline.
+	message := string(buf[:n])
+	if strings.HasSuffix(message, "\n") {
+		t.Errorf("datagram %q ends with a newline", message)
+	}
+
+	checkMessage(t, message, int(FacilityLocal0)<<3|int(SeverityWarning), "brick", `disabled user "alice"`)
+}
+
+func TestWriterTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start listener: %v", err)
+	}
+	defer listener.Close()
+
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	w, err := Dial(NetworkTCP, listener.Addr().String(), FacilityDaemon, "brick-siem")
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+
+	messages := []struct {
+		severity Severity
+		msg      string
+		want     string
+	}{
+		{severity: SeverityErr, msg: "first", want: "first"},
+		{severity: SeverityInfo, msg: "second\r\n", want: "second"},
+		{severity: SeverityDebug, msg: "third\n\n", want: "third"},
+	}
+
+	for _, m := range messages {
+		if err := w.Write(m.severity, m.msg); err != nil {
+			t.Fatalf("failed to write message: %v", err)
+		}
+	}
+
+	// Closing the connection ends the stream once all messages are read.
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+
+	// Messages sent over stream connections are newline terminated
+	// (non-transparent framing), so each message is read as one line.
+	for _, m := range messages {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("stream ended before message %q was received", m.want)
+			}
+			checkMessage(t, line, int(FacilityDaemon)<<3|int(m.severity), "brick-siem", m.want)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for message %q", m.want)
+		}
+	}
+
+	select {
+	case line, ok := <-lines:
+		if ok {
+			t.Errorf("unexpected extra line %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for end of stream")
+	}
+}
+
+func TestDialUnsupportedNetwork(t *testing.T) {
+	if _, err := Dial("sctp", "127.0.0.1:514", FacilityDaemon, "brick"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestParseFacility(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Facility
+		wantErr bool
+	}{
+		{name: "daemon", want: FacilityDaemon},
+		{name: "LOCAL0", want: FacilityLocal0},
+		{name: "local7", want: FacilityLocal7},
+		{name: "authpriv", want: FacilityAuthPriv},
+		{name: "local8", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFacility(tt.name)
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatalf("expected error, got %d", got)
+			case !tt.wantErr && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case got != tt.want:
+				t.Fatalf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tmplutils
+
+import (
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Funcs returns the collection of helper functions made available to the
+// built-in templates and to any user-provided template files, both for
+// flat-file entries and for notifications.
+//
+// Functions which accept multiple arguments take the value being operated on
+// last so that they can be used at the end of a pipeline, e.g.,
+// {{ .Alert.Username | replace "." "_" }}.
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		// inc is used by the built-in notification templates to number
+		// processing steps and sessions starting from one.
+		// https://stackoverflow.com/a/25690905/903870
+		"inc": func(i int) int {
+			return i + 1
+		},
+		"trim":  strings.TrimSpace,
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		"quote": strconv.Quote,
+		"replace": func(oldValue string, newValue string, s string) string {
+			return strings.ReplaceAll(s, oldValue, newValue)
+		},
+
+		// now returns the current time in time.RFC3339 format, the same
+		// format used for the ArrivalTime field.
+		"now": func() string {
+			return time.Now().Format(time.RFC3339)
+		},
+
+		// formatTime converts a time.RFC3339 formatted value (e.g.,
+		// ArrivalTime) to the specified Go time layout. The original value is
+		// returned as-is if it cannot be parsed.
+		"formatTime": func(layout string, value string) string {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return value
+			}
+			return t.Format(layout)
+		},
+	}
+}
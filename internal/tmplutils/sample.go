@@ -0,0 +1,60 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tmplutils
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/atc0005/go-ezproxy"
+
+	"github.com/atc0005/brick/internal/events"
+)
+
+// SampleUsername is the username used by SampleAlert and SampleUserSession.
+const SampleUsername string = "sample-user"
+
+// SampleAlert returns an alert with all fields populated. This is used to
+// validate templates at startup so that errors are reported then instead of
+// when the first alert is received.
+func SampleAlert() events.SplunkAlertEvent {
+	return events.SplunkAlertEvent{
+		Username:         SampleUsername,
+		ReportedUsername: "Sample-User",
+		UserIP:           "192.0.2.10",
+		PayloadSenderIP:  "192.0.2.20",
+		ArrivalTime:      time.Now().Format(time.RFC3339),
+		EventTime:        time.Now().Add(-time.Minute).Format(time.RFC3339),
+		Latency:          time.Minute,
+		LocalTime:        time.Now().Format("2006-01-02 15:04:05"),
+		AlertName:        "sample alert",
+		SearchID:         "sample-search-id",
+		EndpointPath:     "/api/v1/users/disable",
+		HTTPMethod:       http.MethodPost,
+		Headers:          http.Header{"Content-Type": {"application/json"}},
+	}
+}
+
+// SampleUserSession returns an EZproxy user session for the user of
+// SampleAlert. This is used alongside SampleAlert to validate templates.
+func SampleUserSession() ezproxy.UserSession {
+	return ezproxy.UserSession{
+		SessionID: "sample-session-id",
+		IPAddress: "192.0.2.10",
+		Username:  SampleUsername,
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usernames
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/atc0005/brick/internal/caller"
+)
+
+// Normalizer applies a consistent set of transformations to usernames
+// reported via alert payloads. The same Normalizer is intended to be applied
+// once when an alert is received so that all later ignore checks, disabled
+// status checks and file entries use the same username value.
+type Normalizer struct {
+
+	// Lowercase controls whether usernames are converted to lowercase.
+	Lowercase bool
+
+	// StripRealm controls whether a trailing realm or domain (e.g.,
+	// jdoe@example.edu) is removed.
+	StripRealm bool
+
+	// StripDomain controls whether a leading Windows-style domain (e.g.,
+	// EXAMPLE\jdoe) is removed.
+	StripDomain bool
+
+	// AliasFile is the fully-qualified path to the optional file containing
+	// alias to canonical username mappings.
+	AliasFile string
+
+	// aliases is the collection of alias to canonical username mappings
+	// loaded from AliasFile. Both keys and values have already been passed
+	// through the other normalization steps.
+	aliases map[string]string
+}
+
+// NewNormalizer constructs a Normalizer using the provided settings. If an
+// alias file is specified it is loaded immediately and an error returned if
+// the file cannot be read or contains invalid entries.
+func NewNormalizer(lowercase bool, stripRealm bool, stripDomain bool, aliasFile string) (*Normalizer, error) {
+
+	n := Normalizer{
+		Lowercase:   lowercase,
+		StripRealm:  stripRealm,
+		StripDomain: stripDomain,
+		AliasFile:   aliasFile,
+		aliases:     make(map[string]string),
+	}
+
+	if aliasFile != "" {
+		if err := n.loadAliases(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &n, nil
+}
+
+// Normalize applies all enabled normalization steps to the provided username
+// and returns the result. Leading and trailing whitespace is always removed.
+// Domain and realm stripping are applied first, then case-folding and
+// finally alias lookup.
+func (n *Normalizer) Normalize(username string) string {
+
+	normalized := n.transform(username)
+
+	if canonical, ok := n.aliases[normalized]; ok {
+		log.Debugf(
+			"%s: username %q is an alias for %q",
+			caller.GetFuncName(),
+			normalized,
+			canonical,
+		)
+		normalized = canonical
+	}
+
+	return normalized
+}
+
+// transform applies all normalization steps except alias lookup.
+func (n *Normalizer) transform(username string) string {
+
+	normalized := strings.TrimSpace(username)
+
+	if n.StripDomain {
+		if idx := strings.LastIndex(normalized, `\`); idx >= 0 {
+			normalized = normalized[idx+1:]
+		}
+	}
+
+	// Only strip the realm if there is something left afterwards; a
+	// leading @ character is left alone.
+	if n.StripRealm {
+		if idx := strings.LastIndex(normalized, "@"); idx > 0 {
+			normalized = normalized[:idx]
+		}
+	}
+
+	if n.Lowercase {
+		normalized = strings.ToLower(normalized)
+	}
+
+	return normalized
+}
+
+// loadAliases reads the alias file and populates the collection of alias to
+// canonical username mappings. Each line is expected to contain an alias and
+// a canonical username separated by whitespace. Lines beginning with a `#`
+// character and blank lines are ignored.
+func (n *Normalizer) loadAliases() error {
+
+	myFuncName := caller.GetFuncName()
+
+	log.Debugf("%s: Attempting to open sanitized version of file %q",
+		myFuncName, filepath.Clean(n.AliasFile))
+
+	f, err := os.Open(filepath.Clean(n.AliasFile))
+	if err != nil {
+		return fmt.Errorf(
+			"%s: error encountered opening username alias file %q: %w",
+			myFuncName,
+			n.AliasFile,
+			err,
+		)
+	}
+
+	// #nosec G307
+	// Believed to be a false-positive from recent gosec release
+	// https://github.com/securego/gosec/issues/714
+	defer func() {
+		if err := f.Close(); err != nil {
+			// Ignore "file already closed" errors
+			if !errors.Is(err, os.ErrClosed) {
+				log.Errorf(
+					"%s: failed to close file %q: %s",
+					myFuncName,
+					n.AliasFile,
+					err.Error(),
+				)
+			}
+		}
+	}()
+
+	s := bufio.NewScanner(f)
+	var lineno int
+	for s.Scan() {
+		lineno++
+		currentLine := strings.TrimSpace(s.Text())
+
+		if currentLine == "" || strings.HasPrefix(currentLine, "#") {
+			continue
+		}
+
+		fields := strings.Fields(currentLine)
+		if len(fields) != 2 {
+			return fmt.Errorf(
+				"%s: invalid entry on line %d of username alias file %q; expected 2 fields, found %d",
+				myFuncName,
+				lineno,
+				n.AliasFile,
+				len(fields),
+			)
+		}
+
+		alias := n.transform(fields[0])
+		canonical := n.transform(fields[1])
+
+		if existing, ok := n.aliases[alias]; ok && existing != canonical {
+			return fmt.Errorf(
+				"%s: conflicting entry on line %d of username alias file %q; alias %q already maps to %q",
+				myFuncName,
+				lineno,
+				n.AliasFile,
+				alias,
+				existing,
+			)
+		}
+
+		n.aliases[alias] = canonical
+	}
+
+	if err := s.Err(); err != nil {
+		return fmt.Errorf(
+			"%s: error reading username alias file %q: %w",
+			myFuncName,
+			n.AliasFile,
+			err,
+		)
+	}
+
+	log.Debugf(
+		"%s: Loaded %d username aliases from %q",
+		myFuncName,
+		len(n.aliases),
+		n.AliasFile,
+	)
+
+	return nil
+}
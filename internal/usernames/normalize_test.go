@@ -0,0 +1,214 @@
+// Copyright 2020 Adam Chalkley
+//
+// https://github.com/atc0005/brick
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usernames
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeAliasFile writes the provided content to an alias file and returns
+// the path to it.
+func writeAliasFile(t *testing.T, content string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "users.brick-aliases.txt")
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write alias file: %v", err)
+	}
+
+	return filename
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name        string
+		lowercase   bool
+		stripRealm  bool
+		stripDomain bool
+		username    string
+		want        string
+	}{
+		{
+			name:     "no steps enabled",
+			username: " EXAMPLE\\JDoe@Example.EDU ",
+			want:     "EXAMPLE\\JDoe@Example.EDU",
+		},
+		{
+			name:      "lowercase",
+			lowercase: true,
+			username:  "JDoe",
+			want:      "jdoe",
+		},
+		{
+			name:       "strip realm",
+			stripRealm: true,
+			username:   "JDoe@Example.EDU",
+			want:       "JDoe",
+		},
+		{
+			name:       "strip realm keeps all but the last realm",
+			stripRealm: true,
+			username:   "jdoe@dept@example.edu",
+			want:       "jdoe@dept",
+		},
+		{
+			name:       "strip realm leaves leading @ alone",
+			stripRealm: true,
+			username:   "@jdoe",
+			want:       "@jdoe",
+		},
+		{
+			name:       "strip realm leaves lone @ alone",
+			stripRealm: true,
+			username:   "@",
+			want:       "@",
+		},
+		{
+			name:        "strip domain",
+			stripDomain: true,
+			username:    "EXAMPLE\\JDoe",
+			want:        "JDoe",
+		},
+		{
+			name:        "strip domain keeps value after the last separator",
+			stripDomain: true,
+			username:    "FOREST\\EXAMPLE\\jdoe",
+			want:        "jdoe",
+		},
+		{
+			name:        "domain removed before realm",
+			stripRealm:  true,
+			stripDomain: true,
+			username:    "jdoe@EXAMPLE\\jsmith@example.edu",
+			want:        "jsmith",
+		},
+		{
+			name:        "all steps",
+			lowercase:   true,
+			stripRealm:  true,
+			stripDomain: true,
+			username:    "EXAMPLE\\JDoe@Example.EDU",
+			want:        "jdoe",
+		},
+		{
+			name:        "leading @ left after domain removal",
+			lowercase:   true,
+			stripRealm:  true,
+			stripDomain: true,
+			username:    "EXAMPLE\\@JDoe",
+			want:        "@jdoe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := NewNormalizer(tt.lowercase, tt.stripRealm, tt.stripDomain, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := n.Normalize(tt.username); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.username, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeAliases(t *testing.T) {
+	aliasFile := writeAliasFile(t, `
+# comments and blank lines are ignored
+
+JSmith@Example.EDU   John.Smith
+EXAMPLE\jsmith2      john.smith
+js                   jsmith
+`)
+
+	n, err := NewNormalizer(true, true, true, aliasFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		username string
+		want     string
+	}{
+		// Aliases and canonical names are normalized when loaded, so the
+		// alias matches however it is reported.
+		{username: "jsmith@example.edu", want: "john.smith"},
+		{username: "OTHER\\JSMITH", want: "john.smith"},
+		{username: "jsmith2@example.edu", want: "john.smith"},
+
+		// Alias lookup is applied once; the result is not looked up again.
+		{username: "js", want: "jsmith"},
+
+		// Canonical names and other usernames are left as-is.
+		{username: "John.Smith", want: "john.smith"},
+		{username: "jdoe", want: "jdoe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			if got := n.Normalize(tt.username); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.username, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewNormalizerAliasFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "too few fields",
+			content: "jsmith\n",
+		},
+		{
+			name:    "too many fields",
+			content: "jsmith john.smith extra\n",
+		},
+		{
+			name:    "conflicting entries after normalization",
+			content: "JSmith john.smith\njsmith@example.edu jane.smith\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewNormalizer(true, true, false, writeAliasFile(t, tt.content)); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+
+	t.Run("duplicate entries", func(t *testing.T) {
+		content := "JSmith john.smith\njsmith@example.edu John.Smith\n"
+		if _, err := NewNormalizer(true, true, false, writeAliasFile(t, content)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "missing.txt")
+		if _, err := NewNormalizer(true, false, false, missing); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+}